@@ -14,7 +14,7 @@ import (
 func clear() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "clear",
-		Short: "Clears config and log files",
+		Short: "Clears config, inventory, and log files",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			log := logger.New()
 
@@ -27,6 +27,15 @@ func clear() *cobra.Command {
 				log.Info().Msg("removed config file")
 			}
 
+			inventoryFile, ok := viper.Get("inventory-path").(string)
+
+			if ok && inventoryFile != "" {
+				if err := os.RemoveAll(inventoryFile); err != nil {
+					return err
+				}
+				log.Info().Msg("removed inventory file")
+			}
+
 			logFile, ok := viper.Get("log-file").(string)
 
 			if ok && logFile != "" {
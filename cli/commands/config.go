@@ -0,0 +1,719 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/robgonnella/go-lanscan/pkg/network"
+	"github.com/robgonnella/ops/internal/config"
+	"github.com/robgonnella/ops/internal/core"
+	"github.com/robgonnella/ops/internal/exception"
+	"github.com/spf13/cobra"
+)
+
+/**
+ * Commands to manage configurations without the ui
+ */
+func configure() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manages configurations",
+		Long: `Manages configurations without the terminal ui. Configs can be referenced
+by id or name. Commands that return configs print them as json.`,
+	}
+
+	cmd.AddCommand(configList())
+	cmd.AddCommand(configShow())
+	cmd.AddCommand(configCreate())
+	cmd.AddCommand(configSet())
+	cmd.AddCommand(configDelete())
+	cmd.AddCommand(configUse())
+
+	return cmd
+}
+
+func configList() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Lists all configs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return &ExitError{
+					Code: ExitCodeUsage,
+					Err:  fmt.Errorf("unknown output format: %s", output),
+				}
+			}
+
+			service, err := core.CreateConfigService(defaultInterface())
+
+			if err != nil {
+				return err
+			}
+
+			confs, err := service.GetAll()
+
+			if err != nil {
+				return err
+			}
+
+			if output == "json" {
+				return writeJSON(cmd.OutOrStdout(), confs)
+			}
+
+			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+
+			fmt.Fprintln(tw, "ID\tNAME\tINTERFACE\tSSH USER\tACTIVE")
+
+			for _, c := range confs {
+				active := ""
+
+				if inUse(service, c) {
+					active = "*"
+				}
+
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", c.ID, c.Name, c.Interface, c.SSH.User, active)
+			}
+
+			return tw.Flush()
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format: table, json")
+
+	return cmd
+}
+
+func configShow() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show [id|name]",
+		Short: "Shows a config - defaults to the active config for the default interface",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			iface := defaultInterface()
+
+			service, err := core.CreateConfigService(iface)
+
+			if err != nil {
+				return err
+			}
+
+			var conf *config.Config
+
+			if len(args) == 0 {
+				conf, err = service.GetByInterface(iface)
+			} else {
+				conf, err = findConfig(service, args[0])
+			}
+
+			if err != nil {
+				return err
+			}
+
+			return writeJSON(cmd.OutOrStdout(), conf)
+		},
+	}
+
+	return cmd
+}
+
+func configCreate() *cobra.Command {
+	flags := &configFlags{}
+
+	var use bool
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Creates a new config",
+		Long: `Creates a new config. Unset values use the same defaults as configs
+created by the terminal ui.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			iface := defaultInterface()
+
+			service, err := core.CreateConfigService(iface)
+
+			if err != nil {
+				return err
+			}
+
+			conf := core.DefaultConfig(iface)
+
+			if err := flags.apply(cmd, &conf); err != nil {
+				return &ExitError{Code: ExitCodeUsage, Err: err}
+			}
+
+			if err := unusedName(service, conf); err != nil {
+				return &ExitError{Code: ExitCodeUsage, Err: err}
+			}
+
+			created, err := service.Create(&conf)
+
+			if err != nil {
+				return err
+			}
+
+			if use {
+				if created, err = service.Use(created.ID); err != nil {
+					return err
+				}
+			}
+
+			return writeJSON(cmd.OutOrStdout(), created)
+		},
+	}
+
+	flags.register(cmd)
+
+	cmd.Flags().BoolVar(&use, "use", false, "make the new config the active config for its interface")
+
+	return cmd
+}
+
+func configSet() *cobra.Command {
+	flags := &configFlags{}
+
+	var removeOverrides []string
+	var removeAliases []string
+	var removeActions []string
+
+	cmd := &cobra.Command{
+		Use:   "set <id|name>",
+		Short: "Updates an existing config",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			service, err := core.CreateConfigService(defaultInterface())
+
+			if err != nil {
+				return err
+			}
+
+			conf, err := findConfig(service, args[0])
+
+			if err != nil {
+				return err
+			}
+
+			overrides := slices.Clone(conf.SSH.Overrides)
+
+			for _, target := range removeOverrides {
+				idx := slices.IndexFunc(overrides, func(o config.SSHOverride) bool {
+					return o.Target == target
+				})
+
+				if idx == -1 {
+					return &ExitError{
+						Code: ExitCodeUsage,
+						Err:  fmt.Errorf("no ssh override found for target: %s", target),
+					}
+				}
+
+				overrides = slices.Delete(overrides, idx, idx+1)
+			}
+
+			conf.SSH.Overrides = overrides
+
+			for _, alias := range removeAliases {
+				if _, ok := conf.Aliases[alias]; !ok {
+					return &ExitError{
+						Code: ExitCodeUsage,
+						Err:  fmt.Errorf("no alias found: %s", alias),
+					}
+				}
+
+				delete(conf.Aliases, alias)
+			}
+
+			actions := slices.Clone(conf.Actions)
+
+			for _, key := range removeActions {
+				idx := slices.IndexFunc(actions, func(a config.Action) bool {
+					return a.Key == key
+				})
+
+				if idx == -1 {
+					return &ExitError{
+						Code: ExitCodeUsage,
+						Err:  fmt.Errorf("no action found for key: %s", key),
+					}
+				}
+
+				actions = slices.Delete(actions, idx, idx+1)
+			}
+
+			conf.Actions = actions
+
+			if err := flags.apply(cmd, conf); err != nil {
+				return &ExitError{Code: ExitCodeUsage, Err: err}
+			}
+
+			if err := unusedName(service, *conf); err != nil {
+				return &ExitError{Code: ExitCodeUsage, Err: err}
+			}
+
+			updated, err := service.Update(conf)
+
+			if err != nil {
+				return err
+			}
+
+			for _, a := range updated.ReservedActions() {
+				fmt.Fprintf(
+					cmd.ErrOrStderr(),
+					"warning: action %q is ignored because key %q is reserved - remove it with --remove-action\n",
+					a.Label,
+					a.Key,
+				)
+			}
+
+			return writeJSON(cmd.OutOrStdout(), updated)
+		},
+	}
+
+	flags.register(cmd)
+
+	cmd.Flags().StringArrayVar(
+		&removeOverrides,
+		"remove-override",
+		[]string{},
+		"remove the ssh override for the given target (repeatable)",
+	)
+	cmd.Flags().StringArrayVar(
+		&removeAliases,
+		"remove-alias",
+		[]string{},
+		"remove the given host alias (repeatable)",
+	)
+	cmd.Flags().StringArrayVar(
+		&removeActions,
+		"remove-action",
+		[]string{},
+		"remove the custom action bound to the given key (repeatable)",
+	)
+
+	return cmd
+}
+
+func configDelete() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "delete <id|name>",
+		Short: "Deletes a config",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			service, err := core.CreateConfigService(defaultInterface())
+
+			if err != nil {
+				return err
+			}
+
+			conf, err := findConfig(service, args[0])
+
+			if err != nil {
+				return err
+			}
+
+			if !force && inUse(service, conf) {
+				return &ExitError{
+					Code: ExitCodeUsage,
+					Err: fmt.Errorf(
+						"cannot delete active config for interface %s without --force",
+						conf.Interface,
+					),
+				}
+			}
+
+			return service.Delete(conf.ID)
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "delete the config even if it is active")
+
+	return cmd
+}
+
+func configUse() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "use <id|name>",
+		Short: "Makes a config the active config for its interface",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			service, err := core.CreateConfigService(defaultInterface())
+
+			if err != nil {
+				return err
+			}
+
+			conf, err := findConfig(service, args[0])
+
+			if err != nil {
+				return err
+			}
+
+			active, err := service.Use(conf.ID)
+
+			if err != nil {
+				return err
+			}
+
+			return writeJSON(cmd.OutOrStdout(), active)
+		},
+	}
+
+	return cmd
+}
+
+// configFlags flags shared by commands that create or update configs
+type configFlags struct {
+	name           string
+	iface          string
+	sshUser        string
+	sshIdentity    string
+	sshPort        string
+	sshProxyJump   string
+	sshNative      bool
+	sshConfig      bool
+	scanInterval   string
+	scanTimeout    string
+	scanListenPort string
+	offlineAfter   int
+	servicePorts   []string
+	launcher       string
+	launchTemplate string
+	overrides      []string
+	aliases        []string
+	actions        []string
+}
+
+func (f *configFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.name, "name", "", "config name")
+	cmd.Flags().StringVar(&f.iface, "interface", "", "network interface to scan")
+	cmd.Flags().StringVar(&f.sshUser, "ssh-user", "", "default ssh user")
+	cmd.Flags().StringVar(&f.sshIdentity, "ssh-identity", "", "default ssh identity file")
+	cmd.Flags().StringVar(&f.sshPort, "ssh-port", "", "default ssh port")
+	cmd.Flags().StringVar(&f.sshProxyJump, "ssh-proxy-jump", "", "default jump hosts e.g. admin@bastion:2222,bastion2")
+	cmd.Flags().BoolVar(&f.sshNative, "ssh-native", false, "use the in-process ssh client to gather details")
+	cmd.Flags().BoolVar(&f.sshConfig, "ssh-config", false, "read user, identity, and port for each host from ~/.ssh/config")
+	cmd.Flags().StringVar(&f.scanInterval, "scan-interval", "", "time between network scans e.g. 30s")
+	cmd.Flags().StringVar(&f.scanTimeout, "scan-timeout", "", "time to wait for scan responses e.g. 5s")
+	cmd.Flags().StringVar(&f.scanListenPort, "scan-listen-port", "", "source port used for syn scanning")
+	cmd.Flags().IntVar(&f.offlineAfter, "offline-after", 0, "missed scans before a host is considered offline")
+	cmd.Flags().StringSliceVar(&f.servicePorts, "service-ports", []string{}, "service ports to scan (comma separated)")
+	cmd.Flags().StringVar(&f.launcher, "session-launcher", "", "how ssh sessions are opened from the ui: inline, tmux-window, tmux-pane, screen, template")
+	cmd.Flags().StringVar(&f.launchTemplate, "session-template", "", "command template for the template session launcher e.g. 'wezterm cli spawn -- {{.Command}}'")
+	cmd.Flags().StringArrayVar(
+		&f.overrides,
+		"override",
+		[]string{},
+		"add or replace an ssh override e.g. target=192.168.1.2,user=root,identity=~/.ssh/id_rsa,port=2222,proxyJump=bastion (repeatable)",
+	)
+	cmd.Flags().StringArrayVar(
+		&f.aliases,
+		"alias",
+		[]string{},
+		"add or replace a host alias e.g. db=192.168.1.2 or db=aa:bb:cc:dd:ee:ff (repeatable)",
+	)
+	cmd.Flags().StringArrayVar(
+		&f.actions,
+		"action",
+		[]string{},
+		"add or replace a custom action e.g. 'key=v,label=vnc,command=vncviewer {{.IP}},when=port 5900 open' (repeatable)",
+	)
+}
+
+// applies all flags that were explicitly set to the config and validates
+// the result
+func (f *configFlags) apply(cmd *cobra.Command, conf *config.Config) error {
+	changed := cmd.Flags().Changed
+
+	if changed("name") {
+		conf.Name = f.name
+	}
+
+	if changed("interface") {
+		conf.Interface = f.iface
+	}
+
+	if changed("ssh-user") {
+		conf.SSH.User = f.sshUser
+	}
+
+	if changed("ssh-identity") {
+		conf.SSH.Identity = f.sshIdentity
+	}
+
+	if changed("ssh-port") {
+		conf.SSH.Port = f.sshPort
+	}
+
+	if changed("ssh-proxy-jump") {
+		conf.SSH.ProxyJump = f.sshProxyJump
+	}
+
+	if changed("ssh-native") {
+		conf.SSH.Native = f.sshNative
+	}
+
+	if changed("ssh-config") {
+		conf.SSH.UseSSHConfig = f.sshConfig
+	}
+
+	if changed("scan-interval") {
+		conf.Scan.Interval = f.scanInterval
+	}
+
+	if changed("scan-timeout") {
+		conf.Scan.Timeout = f.scanTimeout
+	}
+
+	if changed("scan-listen-port") {
+		conf.Scan.ListenPort = f.scanListenPort
+	}
+
+	if changed("offline-after") {
+		conf.Scan.OfflineAfter = f.offlineAfter
+	}
+
+	if changed("service-ports") {
+		conf.Scan.ServicePorts = f.servicePorts
+	}
+
+	if changed("session-launcher") {
+		conf.Session.Launcher = config.LauncherKind(f.launcher)
+	}
+
+	if changed("session-template") {
+		conf.Session.Template = f.launchTemplate
+	}
+
+	for _, value := range f.overrides {
+		override, err := parseOverride(value)
+
+		if err != nil {
+			return err
+		}
+
+		idx := slices.IndexFunc(conf.SSH.Overrides, func(o config.SSHOverride) bool {
+			return o.Target == override.Target
+		})
+
+		if idx == -1 {
+			conf.SSH.Overrides = append(conf.SSH.Overrides, override)
+		} else {
+			conf.SSH.Overrides[idx] = override
+		}
+	}
+
+	for _, value := range f.aliases {
+		alias, target, ok := strings.Cut(value, "=")
+
+		if !ok || alias == "" || target == "" {
+			return fmt.Errorf("invalid alias: %s", value)
+		}
+
+		if conf.Aliases == nil {
+			conf.Aliases = map[string]string{}
+		}
+
+		conf.Aliases[alias] = target
+	}
+
+	for _, value := range f.actions {
+		action, err := parseAction(value)
+
+		if err != nil {
+			return err
+		}
+
+		// existing actions with reserved keys are only warned about but new
+		// ones are rejected
+		if err := action.Validate(); err != nil {
+			return err
+		}
+
+		idx := slices.IndexFunc(conf.Actions, func(a config.Action) bool {
+			return a.Key == action.Key
+		})
+
+		if idx == -1 {
+			conf.Actions = append(conf.Actions, action)
+		} else {
+			conf.Actions[idx] = action
+		}
+	}
+
+	return conf.Validate()
+}
+
+// parses an override in the form
+// target=...,user=...,identity=...,port=...,proxyJump=... where proxyJump
+// may be a comma separated chain of jump hosts
+func parseOverride(value string) (config.SSHOverride, error) {
+	override := config.SSHOverride{}
+	prevKey := ""
+
+	for _, part := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(strings.TrimSpace(part), "=")
+
+		if !ok && prevKey == "proxyJump" {
+			override.ProxyJump += "," + strings.TrimSpace(part)
+			continue
+		}
+
+		if !ok {
+			return override, fmt.Errorf("invalid ssh override: %s", value)
+		}
+
+		prevKey = key
+
+		switch key {
+		case "target":
+			override.Target = val
+		case "user":
+			override.User = val
+		case "identity":
+			override.Identity = val
+		case "port":
+			override.Port = val
+		case "proxyJump":
+			override.ProxyJump = val
+		default:
+			return override, fmt.Errorf("unknown ssh override field: %s", key)
+		}
+	}
+
+	if override.Target == "" {
+		return override, fmt.Errorf("ssh override must include a target: %s", value)
+	}
+
+	return override, nil
+}
+
+// fields accepted by parseAction
+var actionFields = []string{"key", "label", "command", "when", "interactive"}
+
+// parses an action in the form
+// key=...,label=...,command=...,when=...,interactive=true where when may be
+// repeated for each condition and commas in the command are kept
+func parseAction(value string) (config.Action, error) {
+	action := config.Action{}
+	prevKey := ""
+
+	for _, part := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(strings.TrimSpace(part), "=")
+
+		if (!ok || !slices.Contains(actionFields, key)) && prevKey == "command" {
+			action.Command += "," + part
+			continue
+		}
+
+		if !ok {
+			return action, fmt.Errorf("invalid action: %s", value)
+		}
+
+		prevKey = key
+
+		switch key {
+		case "key":
+			action.Key = val
+		case "label":
+			action.Label = val
+		case "command":
+			action.Command = val
+		case "when":
+			action.Conditions = append(action.Conditions, val)
+		case "interactive":
+			interactive, err := strconv.ParseBool(val)
+
+			if err != nil {
+				return action, fmt.Errorf("invalid action interactive value: %s", val)
+			}
+
+			action.Interactive = interactive
+		default:
+			return action, fmt.Errorf("unknown action field: %s", key)
+		}
+	}
+
+	if action.Key == "" {
+		return action, fmt.Errorf("action must include a key: %s", value)
+	}
+
+	return action, nil
+}
+
+// finds a config by id or name
+func findConfig(service config.Service, ref string) (*config.Config, error) {
+	confs, err := service.GetAll()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range confs {
+		if c.ID == ref {
+			return service.Get(c.ID)
+		}
+	}
+
+	for _, c := range confs {
+		if c.Name == ref {
+			return service.Get(c.ID)
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", exception.ErrRecordNotFound, ref)
+}
+
+// returns an error if another config already uses the config's name
+func unusedName(service config.Service, conf config.Config) error {
+	existing, err := findConfig(service, conf.Name)
+
+	if errors.Is(err, exception.ErrRecordNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if existing.ID != conf.ID {
+		return fmt.Errorf("config name already in use: %s", conf.Name)
+	}
+
+	return nil
+}
+
+// returns true if the config is the one used for its interface
+func inUse(service config.Service, conf *config.Config) bool {
+	active, err := service.GetByInterface(conf.Interface)
+	return err == nil && active.ID == conf.ID
+}
+
+// returns the name of the default network interface or an empty string if
+// it cannot be determined
+func defaultInterface() string {
+	networkInfo, err := network.NewDefaultNetwork()
+
+	if err != nil {
+		return ""
+	}
+
+	return networkInfo.Interface().Name
+}
+
+// writes the value as indented json
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,256 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/robgonnella/ops/internal/core"
+	"github.com/robgonnella/ops/internal/logger"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+/**
+ * Command to copy files to or from many hosts over sftp without the ui
+ */
+func cp() *cobra.Command {
+	var configRef string
+	var all bool
+	var workers int
+	var timeout time.Duration
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "cp <source> <destination>",
+		Short: "Copies a file to or from many hosts over sftp",
+		Long: `Copies a file to or from one or more hosts over sftp. Remote paths are
+written as hosts:path where hosts is a comma separated list of hostnames,
+ips, mac addresses, or aliases from the config. Use --all with :path to
+copy to or from every online host with ssh enabled. The same default and
+override ssh settings used by the terminal ui are applied.
+
+Uploading to a remote directory copies the file into it. When downloading
+from more than one host each file is saved under <destination>/<ip>/.
+
+Exit codes:
+  0  file copied for all hosts
+  1  file could not be copied
+  2  invalid arguments or flags
+  3  file could not be copied for one or more hosts`,
+		Example: `  ops cp ./script.sh web01,web02:/tmp/
+  ops cp web01:/var/log/syslog ./logs/
+  ops cp --all :/etc/os-release ./releases`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req, refs, err := parseTransferArgs(args[0], args[1])
+
+			if err != nil {
+				return &ExitError{Code: ExitCodeUsage, Err: err}
+			}
+
+			if all == (len(refs) > 0) {
+				return &ExitError{
+					Code: ExitCodeUsage,
+					Err:  errors.New("provide either one or more hosts or --all"),
+				}
+			}
+
+			if output != "text" && output != "json" {
+				return &ExitError{
+					Code: ExitCodeUsage,
+					Err:  fmt.Errorf("unknown output format: %s", output),
+				}
+			}
+
+			// keep stderr quiet unless logs were explicitly requested
+			if zerolog.GlobalLevel() == zerolog.InfoLevel {
+				logger.SetGlobalLevel(zerolog.WarnLevel)
+			}
+
+			conf, selected, err := selectHosts(configRef, refs, all)
+
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := signal.NotifyContext(
+				cmd.Context(),
+				os.Interrupt,
+				syscall.SIGTERM,
+			)
+
+			defer cancel()
+
+			opts := core.TransferOptions{Workers: workers, Timeout: timeout}
+
+			if output == "text" {
+				opts.OnProgress = printTransferProgress(cmd.ErrOrStderr())
+			}
+
+			results := core.Transfer(
+				ctx,
+				core.CreateFileTransferer(),
+				core.ExecTargets(*conf, selected),
+				req,
+				opts,
+			)
+
+			if output == "json" {
+				err = writeJSON(cmd.OutOrStdout(), results)
+			} else {
+				err = writeTransferSummary(cmd.OutOrStdout(), results)
+			}
+
+			if err != nil {
+				return &ExitError{Code: ExitCodeError, Err: err}
+			}
+
+			return transferErrors(results)
+		},
+	}
+
+	cmd.Flags().StringVar(&configRef, "config", "", "id or name of the config to use - defaults to the active config")
+	cmd.Flags().BoolVar(&all, "all", false, "copy to or from every online host with ssh enabled")
+	cmd.Flags().IntVarP(&workers, "workers", "w", core.DefaultExecWorkers, "maximum number of hosts to copy for at once")
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "maximum time allowed for each host - 0 for no limit")
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "output format: text, json")
+
+	return cmd
+}
+
+// returns the transfer request and host references for the given source
+// and destination. Exactly one of them must be a remote hosts:path.
+func parseTransferArgs(source, destination string) (core.TransferRequest, []string, error) {
+	srcRefs, srcPath, srcRemote := parseRemotePath(source)
+	dstRefs, dstPath, dstRemote := parseRemotePath(destination)
+
+	switch {
+	case srcRemote && dstRemote:
+		return core.TransferRequest{}, nil, errors.New("copying between remote hosts is not supported")
+	case srcRemote:
+		return core.TransferRequest{
+			Direction:  core.TransferDownload,
+			LocalPath:  destination,
+			RemotePath: srcPath,
+		}, srcRefs, nil
+	case dstRemote:
+		return core.TransferRequest{
+			Direction:  core.TransferUpload,
+			LocalPath:  source,
+			RemotePath: dstPath,
+		}, dstRefs, nil
+	default:
+		return core.TransferRequest{}, nil, errors.New("either source or destination must be a remote hosts:path")
+	}
+}
+
+// splits a hosts:path argument into host references and path. The last
+// colon is used so mac addresses can be used as host references. Local
+// paths containing a colon after a path separator are not treated as remote.
+func parseRemotePath(arg string) ([]string, string, bool) {
+	idx := strings.LastIndex(arg, ":")
+
+	if idx == -1 {
+		return nil, "", false
+	}
+
+	hosts, path := arg[:idx], arg[idx+1:]
+
+	if strings.ContainsAny(hosts, `/\`) {
+		return nil, "", false
+	}
+
+	refs := []string{}
+
+	for _, ref := range strings.Split(hosts, ",") {
+		if ref = strings.TrimSpace(ref); ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+
+	if path == "" {
+		path = "."
+	}
+
+	return refs, path, true
+}
+
+// returns a progress handler that prints each host's progress in 25%
+// increments
+func printTransferProgress(w io.Writer) func(core.TransferProgress) {
+	mux := sync.Mutex{}
+	printed := map[string]int{}
+
+	return func(p core.TransferProgress) {
+		mux.Lock()
+		defer mux.Unlock()
+
+		step := p.Percent() / 25 * 25
+
+		if last, ok := printed[p.Target.ID]; ok && step <= last {
+			return
+		}
+
+		printed[p.Target.ID] = step
+
+		fmt.Fprintf(
+			w,
+			"[%s] %d%% (%d / %d bytes)\n",
+			execHostLabel(p.Target.Hostname, p.Target.IP),
+			step,
+			p.Transferred,
+			p.Total,
+		)
+	}
+}
+
+// prints the destination, size, and duration for each host
+func writeTransferSummary(w io.Writer, results []core.TransferResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "HOST\tIP\tBYTES\tDURATION\tDESTINATION\tERROR")
+
+	for _, r := range results {
+		fmt.Fprintf(
+			tw,
+			"%s\t%s\t%d\t%s\t%s\t%s\n",
+			execHostLabel(r.Hostname, r.IP),
+			r.IP,
+			r.Bytes,
+			r.Duration.Round(time.Millisecond),
+			r.Destination,
+			r.Error,
+		)
+	}
+
+	return tw.Flush()
+}
+
+// returns an exit error if the file could not be copied for any host
+func transferErrors(results []core.TransferResult) error {
+	count := 0
+
+	for _, r := range results {
+		if r.Failed() {
+			count++
+		}
+	}
+
+	if count == 0 {
+		return nil
+	}
+
+	return &ExitError{
+		Code: ExitCodeHostErrors,
+		Err:  fmt.Errorf("copy failed for %d host(s)", count),
+	}
+}
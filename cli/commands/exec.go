@@ -0,0 +1,253 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/robgonnella/ops/internal/config"
+	"github.com/robgonnella/ops/internal/core"
+	"github.com/robgonnella/ops/internal/discovery"
+	"github.com/robgonnella/ops/internal/inventory"
+	"github.com/robgonnella/ops/internal/logger"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+/**
+ * Command to run a command on many hosts in parallel without the ui
+ */
+func execCmd() *cobra.Command {
+	var configRef string
+	var all bool
+	var workers int
+	var timeout time.Duration
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "exec [hosts...] -- <command>",
+		Short: "Runs a command over ssh on many hosts in parallel",
+		Long: `Runs a command over ssh on each of the given hosts in parallel. Hosts may be
+referenced by hostname, ip, mac address, or an alias from the config. With
+--all the command is run on every online host with ssh enabled. The same
+default and override ssh settings used by the terminal ui are applied.
+
+With text output each line is printed as it is produced prefixed with the
+host it came from, followed by a summary of exit codes and durations. With
+json output the full results are printed once all hosts have finished.
+
+Exit codes:
+  0  command succeeded on all hosts
+  1  command could not be run
+  2  invalid arguments or flags
+  3  command failed or exited non-zero on one or more hosts`,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if cmd.ArgsLenAtDash() != -1 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+
+			return completeHosts(&configRef)(cmd, nil, toComplete)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dash := cmd.ArgsLenAtDash()
+
+			if dash == -1 || dash == len(args) {
+				return &ExitError{
+					Code: ExitCodeUsage,
+					Err:  errors.New("a command must be provided after --"),
+				}
+			}
+
+			refs := args[:dash]
+			command := strings.Join(args[dash:], " ")
+
+			if all == (len(refs) > 0) {
+				return &ExitError{
+					Code: ExitCodeUsage,
+					Err:  errors.New("provide either one or more hosts or --all"),
+				}
+			}
+
+			if output != "text" && output != "json" {
+				return &ExitError{
+					Code: ExitCodeUsage,
+					Err:  fmt.Errorf("unknown output format: %s", output),
+				}
+			}
+
+			// keep stderr quiet unless logs were explicitly requested
+			if zerolog.GlobalLevel() == zerolog.InfoLevel {
+				logger.SetGlobalLevel(zerolog.WarnLevel)
+			}
+
+			conf, selected, err := selectHosts(configRef, refs, all)
+
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := signal.NotifyContext(
+				cmd.Context(),
+				os.Interrupt,
+				syscall.SIGTERM,
+			)
+
+			defer cancel()
+
+			opts := core.ExecOptions{Workers: workers, Timeout: timeout}
+
+			if output == "text" {
+				opts.OnOutput = printExecOutput(cmd.OutOrStdout(), cmd.ErrOrStderr())
+			}
+
+			results := core.Exec(
+				ctx,
+				core.CreateCommandRunner(),
+				core.ExecTargets(*conf, selected),
+				command,
+				opts,
+			)
+
+			if output == "json" {
+				err = writeJSON(cmd.OutOrStdout(), results)
+			} else {
+				err = writeExecSummary(cmd.OutOrStdout(), results)
+			}
+
+			if err != nil {
+				return &ExitError{Code: ExitCodeError, Err: err}
+			}
+
+			return execErrors(results)
+		},
+	}
+
+	cmd.Flags().StringVar(&configRef, "config", "", "id or name of the config to use - defaults to the active config")
+	cmd.Flags().BoolVar(&all, "all", false, "run on every online host with ssh enabled")
+	cmd.Flags().IntVarP(&workers, "workers", "w", core.DefaultExecWorkers, "maximum number of hosts to run on at once")
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "maximum time allowed for each host - 0 for no limit")
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "output format: text, json")
+
+	return cmd
+}
+
+// returns the config and the hosts referenced by hostname, ip, mac address,
+// or alias, or every online host with ssh enabled if all is true
+func selectHosts(configRef string, refs []string, all bool) (*config.Config, []*inventory.Host, error) {
+	conf, inventoryService, err := loadConfigAndInventory(configRef)
+
+	if err != nil {
+		return nil, nil, &ExitError{Code: ExitCodeError, Err: err}
+	}
+
+	hosts, err := inventoryService.GetAll(conf.ID)
+
+	if err != nil {
+		return nil, nil, &ExitError{Code: ExitCodeError, Err: err}
+	}
+
+	selected := []*inventory.Host{}
+
+	if all {
+		for _, h := range hosts {
+			if h.Status == discovery.ServerOnline && h.Port.Status == discovery.PortOpen {
+				selected = append(selected, h)
+			}
+		}
+	}
+
+	for _, ref := range refs {
+		host, err := resolveHost(conf, hosts, ref)
+
+		if err != nil {
+			return nil, nil, &ExitError{Code: ExitCodeUsage, Err: err}
+		}
+
+		selected = append(selected, host)
+	}
+
+	if len(selected) == 0 {
+		return nil, nil, &ExitError{
+			Code: ExitCodeError,
+			Err:  errors.New("no online hosts with ssh enabled"),
+		}
+	}
+
+	return conf, selected, nil
+}
+
+// returns an output handler that prints each line prefixed with its host
+func printExecOutput(stdout, stderr io.Writer) func(core.ExecOutput) {
+	mux := sync.Mutex{}
+
+	return func(o core.ExecOutput) {
+		mux.Lock()
+		defer mux.Unlock()
+
+		w := stdout
+
+		if o.Stream == core.ExecStderr {
+			w = stderr
+		}
+
+		fmt.Fprintf(w, "[%s] %s\n", execHostLabel(o.Target.Hostname, o.Target.IP), o.Line)
+	}
+}
+
+// prints the exit code and duration for each host
+func writeExecSummary(w io.Writer, results []core.ExecResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "HOST\tIP\tEXIT\tDURATION\tERROR")
+
+	for _, r := range results {
+		fmt.Fprintf(
+			tw,
+			"%s\t%s\t%d\t%s\t%s\n",
+			execHostLabel(r.Hostname, r.IP),
+			r.IP,
+			r.ExitCode,
+			r.Duration.Round(time.Millisecond),
+			r.Error,
+		)
+	}
+
+	return tw.Flush()
+}
+
+// returns an exit error if the command failed on any host
+func execErrors(results []core.ExecResult) error {
+	count := 0
+
+	for _, r := range results {
+		if r.Failed() {
+			count++
+		}
+	}
+
+	if count == 0 {
+		return nil
+	}
+
+	return &ExitError{
+		Code: ExitCodeHostErrors,
+		Err:  fmt.Errorf("command failed on %d host(s)", count),
+	}
+}
+
+// returns the hostname if known otherwise the ip
+func execHostLabel(hostname, ip string) string {
+	if hostname == "" || hostname == "Unknown" {
+		return ip
+	}
+
+	return hostname
+}
@@ -0,0 +1,28 @@
+package commands
+
+// Exit codes used by commands that report more than success or failure
+const (
+	// ExitCodeError the command failed
+	ExitCodeError = 1
+	// ExitCodeUsage the command was given invalid arguments or flags
+	ExitCodeUsage = 2
+	// ExitCodeHostErrors the command completed but one or more hosts
+	// reported errors
+	ExitCodeHostErrors = 3
+)
+
+// ExitError is returned by commands that need to exit with a specific code
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+// Error returns the underlying error message
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
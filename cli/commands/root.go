@@ -52,6 +52,12 @@ func Root(props *CommandProps) *cobra.Command {
 	cmd.PersistentFlags().BoolVar(&silent, "silent", false, "disables all logging")
 
 	cmd.AddCommand(clear())
+	cmd.AddCommand(configure())
+	cmd.AddCommand(cp())
+	cmd.AddCommand(execCmd())
+	cmd.AddCommand(scan(props))
+	cmd.AddCommand(serve(props))
+	cmd.AddCommand(sshCmd())
 	cmd.AddCommand(version())
 
 	return cmd
@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/robgonnella/go-lanscan/pkg/network"
+	"github.com/robgonnella/ops/internal/core"
+	"github.com/robgonnella/ops/internal/logger"
+	"github.com/robgonnella/ops/internal/report"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+/**
+ * Command to scan the network without the ui and print discovered hosts
+ */
+func scan(props *CommandProps) *cobra.Command {
+	var once bool
+	var watch bool
+	var output string
+
+	formats := make([]string, len(report.Formats))
+
+	for i, f := range report.Formats {
+		formats[i] = string(f)
+	}
+
+	cmd := &cobra.Command{
+		Use:   "scan",
+		Short: "Scans the network and prints discovered hosts",
+		Long: `Scans the network without the terminal ui and prints discovered hosts.
+By default a single scan is performed. With --watch the network is scanned on
+the configured interval until interrupted and hosts are printed after each
+scan.
+
+Exit codes:
+  0  scan completed
+  1  scan failed
+  2  invalid arguments or flags
+  3  scan completed but one or more hosts reported errors (with --watch, in
+     the last completed scan)`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := report.ParseFormat(output)
+
+			if err != nil {
+				return &ExitError{Code: ExitCodeUsage, Err: err}
+			}
+
+			// keep stderr quiet unless logs were explicitly requested
+			if zerolog.GlobalLevel() == zerolog.InfoLevel {
+				logger.SetGlobalLevel(zerolog.WarnLevel)
+			}
+
+			networkInfo, err := network.NewDefaultNetwork()
+
+			if err != nil {
+				return &ExitError{Code: ExitCodeError, Err: err}
+			}
+
+			service, conf, err := core.CreateScannerService(networkInfo, props.EventManager)
+
+			if err != nil {
+				return &ExitError{Code: ExitCodeError, Err: err}
+			}
+
+			ctx, cancel := signal.NotifyContext(
+				cmd.Context(),
+				os.Interrupt,
+				syscall.SIGTERM,
+			)
+
+			defer cancel()
+
+			go func() {
+				<-ctx.Done()
+				service.Stop()
+			}()
+
+			// exit status of the last completed scan
+			var status error
+
+			for {
+				results, err := service.ScanOnce()
+
+				if ctx.Err() != nil {
+					// interrupted
+					return status
+				}
+
+				if err != nil {
+					return &ExitError{Code: ExitCodeError, Err: err}
+				}
+
+				hosts, err := report.Hosts(conf.ID, results)
+
+				if err != nil {
+					return &ExitError{Code: ExitCodeError, Err: err}
+				}
+
+				if err := report.Write(cmd.OutOrStdout(), format, hosts); err != nil {
+					return &ExitError{Code: ExitCodeError, Err: err}
+				}
+
+				status = hostErrors(hosts)
+
+				if !watch {
+					return status
+				}
+
+				select {
+				case <-ctx.Done():
+					return status
+				case <-time.After(conf.Scan.GetInterval()):
+				}
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&watch, "watch", false, "scan on the configured interval until interrupted")
+	cmd.Flags().BoolVar(&once, "once", false, "perform a single scan and exit")
+	cmd.Flags().MarkDeprecated("once", "a single scan is now the default")
+	cmd.Flags().StringVarP(
+		&output,
+		"output",
+		"o",
+		string(report.FormatTable),
+		"output format: "+strings.Join(formats, ", "),
+	)
+
+	return cmd
+}
+
+// returns an exit error if any hosts reported errors
+func hostErrors(hosts []report.Host) error {
+	count := 0
+
+	for _, h := range hosts {
+		if h.DetailError != "" {
+			count++
+		}
+	}
+
+	if count == 0 {
+		return nil
+	}
+
+	return &ExitError{
+		Code: ExitCodeHostErrors,
+		Err:  fmt.Errorf("%d host(s) reported errors", count),
+	}
+}
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/robgonnella/go-lanscan/pkg/network"
+	"github.com/robgonnella/ops/internal/core"
+	"github.com/robgonnella/ops/internal/event"
+	"github.com/robgonnella/ops/internal/logger"
+	"github.com/robgonnella/ops/internal/server"
+	"github.com/spf13/cobra"
+)
+
+/**
+ * Command to run network monitoring as a daemon with a local api
+ */
+func serve(props *CommandProps) *cobra.Command {
+	var socketPath string
+	var addr string
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Runs network monitoring as a daemon exposing a local HTTP/JSON api",
+		Long: `Runs network monitoring without the terminal ui and exposes discovered
+hosts and configurations through a local HTTP/JSON api. The api listens on
+a unix socket when --socket is provided, otherwise on a localhost address.
+
+Routes:
+  GET  /api/v1/hosts            list hosts for the active config
+  GET  /api/v1/hosts/{id}       get a single host by id (mac address)
+  GET  /api/v1/configs          list all configs
+  GET  /api/v1/configs/current  get the active config
+  PUT  /api/v1/configs/current  switch the active config {"id": "..."}
+  POST /api/v1/scan             trigger an immediate network scan
+  GET  /api/v1/events           stream events as server-sent events
+
+Events can be filtered using the query string parameters "type" (event
+types), "ip" (ip addresses or CIDR blocks), and "config" (config id).`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log := logger.New()
+
+			networkInfo, err := network.NewDefaultNetwork()
+
+			if err != nil {
+				return err
+			}
+
+			appCore, err := core.CreateNewAppCore(networkInfo, props.EventManager, false)
+
+			if err != nil {
+				return err
+			}
+
+			listener, err := server.Listen(socketPath, addr)
+
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := signal.NotifyContext(
+				cmd.Context(),
+				os.Interrupt,
+				syscall.SIGTERM,
+			)
+
+			defer cancel()
+
+			fatalChan := make(chan event.Event)
+			listenerID := props.EventManager.RegisterListener(
+				event.FatalErrorEventType,
+				fatalChan,
+			)
+
+			defer props.EventManager.RemoveListener(listenerID)
+
+			fatalErrs := make(chan error, 1)
+
+			go func() {
+				select {
+				case evt := <-fatalChan:
+					err, _ := evt.Payload.(error)
+					log.Error().Err(err).Msg("network monitoring failed")
+					fatalErrs <- err
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+
+			appCore.StartDaemon()
+
+			defer func() {
+				if err := appCore.Stop(); err != nil {
+					log.Error().Err(err).Msg("failed to stop network monitoring")
+				}
+			}()
+
+			if err := server.NewServer(appCore, props.EventManager).Serve(ctx, listener); err != nil {
+				return err
+			}
+
+			select {
+			case err := <-fatalErrs:
+				return err
+			default:
+				return nil
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&socketPath, "socket", "", "unix socket path to listen on")
+	cmd.Flags().StringVar(&addr, "addr", server.DefaultAddr, "localhost address to listen on when no socket is provided")
+
+	return cmd
+}
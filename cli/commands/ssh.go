@@ -0,0 +1,198 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/robgonnella/ops/internal/config"
+	"github.com/robgonnella/ops/internal/core"
+	"github.com/robgonnella/ops/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+/**
+ * Command to ssh to a known host without launching the ui
+ */
+func sshCmd() *cobra.Command {
+	var configRef string
+	var trust bool
+
+	cmd := &cobra.Command{
+		Use:   "ssh <host> [-- ssh args...]",
+		Short: "SSH to a known host by hostname, ip, mac address, or alias",
+		Long: `SSH to a host found during a previous scan. The host may be referenced by
+hostname, ip, mac address, or an alias from the config. The same default
+and override ssh settings used by the terminal ui are applied. Any
+arguments after the host are passed to ssh. IPs that have not been
+discovered yet are connected to directly.`,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeHosts(&configRef),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf, inventoryService, err := loadConfigAndInventory(configRef)
+
+			if err != nil {
+				return err
+			}
+
+			hosts, err := inventoryService.GetAll(conf.ID)
+
+			if err != nil {
+				return err
+			}
+
+			host, err := resolveHost(conf, hosts, args[0])
+
+			if err != nil {
+				return &ExitError{Code: ExitCodeUsage, Err: err}
+			}
+
+			if change, ok := host.HostKeyChange(); ok {
+				fmt.Fprintln(cmd.ErrOrStderr(), change.Warning())
+
+				if !trust {
+					return &ExitError{
+						Code: ExitCodeError,
+						Err:  errors.New("refusing to connect - use --trust-host-key to trust the new key"),
+					}
+				}
+
+				if _, err := inventoryService.TrustHostKey(conf.ID, host.ID); err != nil {
+					return err
+				}
+			}
+
+			creds := conf.SSHCredentials(host.Result().SSHTarget())
+
+			sshCmd := exec.Command("ssh", append(creds.Args(host.IP), args[1:]...)...)
+
+			sshCmd.Stdout = os.Stdout
+			sshCmd.Stderr = os.Stderr
+			sshCmd.Stdin = os.Stdin
+
+			if err := sshCmd.Run(); err != nil {
+				exitErr := &exec.ExitError{}
+
+				if errors.As(err, &exitErr) {
+					// exit with the same code as ssh - ssh reports its own errors
+					os.Exit(exitErr.ExitCode())
+				}
+
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&configRef, "config", "", "id or name of the config to use - defaults to the active config")
+	cmd.Flags().BoolVar(&trust, "trust-host-key", false, "trust a changed ssh host key and connect")
+
+	return cmd
+}
+
+// returns the config with the given id or name, or the active config for
+// the default interface, along with the inventory service
+func loadConfigAndInventory(configRef string) (*config.Config, *inventory.InventoryService, error) {
+	iface := defaultInterface()
+
+	configService, err := core.CreateConfigService(iface)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var conf *config.Config
+
+	if configRef == "" {
+		conf, err = configService.GetByInterface(iface)
+	} else {
+		conf, err = findConfig(configService, configRef)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	inventoryService, err := core.CreateInventoryService()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return conf, inventoryService, nil
+}
+
+// resolves a host by alias, hostname, ip, or mac address. IPs that have not
+// been discovered yet are returned as a host with only an ip so they can
+// still be connected to directly.
+func resolveHost(conf *config.Config, hosts []*inventory.Host, ref string) (*inventory.Host, error) {
+	target := ref
+
+	if aliased, ok := conf.ResolveAlias(target); ok {
+		target = aliased
+	}
+
+	host, err := inventory.FindHost(hosts, target)
+
+	if err != nil {
+		if net.ParseIP(target) == nil {
+			return nil, err
+		}
+
+		return &inventory.Host{ID: target, ConfigID: conf.ID, IP: target}, nil
+	}
+
+	return host, nil
+}
+
+// completes known hostnames, ips, and aliases for the first argument
+func completeHosts(configRef *string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		conf, inventoryService, err := loadConfigAndInventory(*configRef)
+
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		hosts, err := inventoryService.GetAll(conf.ID)
+
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		completions := []string{}
+
+		add := func(value, description string) {
+			if value == "" || !strings.HasPrefix(value, toComplete) {
+				return
+			}
+
+			completions = append(completions, value+"\t"+description)
+		}
+
+		for alias, target := range conf.Aliases {
+			add(alias, "alias for "+target)
+		}
+
+		for _, h := range hosts {
+			if h.Hostname != "" && h.Hostname != "Unknown" {
+				add(h.Hostname, h.IP)
+			}
+
+			add(h.IP, h.Hostname)
+		}
+
+		slices.Sort(completions)
+
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
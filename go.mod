@@ -6,7 +6,9 @@ require (
 	github.com/gdamore/tcell/v2 v2.7.0
 	github.com/google/uuid v1.6.0
 	github.com/goombaio/namegenerator v0.0.0-20181006234301-989e774b106e
+	github.com/kevinburke/ssh_config v1.2.0
 	github.com/magiconair/properties v1.8.7
+	github.com/pkg/sftp v1.13.6
 	github.com/rivo/tview v0.0.0-20240204151237-861aa94d61c8
 	github.com/robgonnella/go-lanscan v1.15.0
 	github.com/rs/zerolog v1.32.0
@@ -14,6 +16,8 @@ require (
 	github.com/spf13/viper v1.18.2
 	github.com/stretchr/testify v1.9.0
 	go.uber.org/mock v0.4.0
+	golang.org/x/crypto v0.21.0
+	golang.org/x/net v0.22.0
 )
 
 require (
@@ -23,6 +27,7 @@ require (
 	github.com/google/gopacket v1.1.19 // indirect
 	github.com/hashicorp/hcl v1.0.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	github.com/kr/fs v0.1.0 // indirect
 	github.com/jackpal/gateway v1.0.14 // indirect
 	github.com/klauspost/oui v0.0.0-20150225163751-35b4deb627f8 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
@@ -45,7 +50,6 @@ require (
 	github.com/thediveo/netdb v1.1.1 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
 	golang.org/x/exp v0.0.0-20240205201215-2c58cdc269a3 // indirect
-	golang.org/x/net v0.22.0 // indirect
 	golang.org/x/sys v0.18.0 // indirect
 	golang.org/x/term v0.18.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
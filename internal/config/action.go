@@ -0,0 +1,218 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// reservedActionKeys keys already used by the server table that cannot be
+// bound to custom actions
+var reservedActionKeys = []string{
+	"s", "p", "x", "f", " ", ":", "/", "o", "O", "j", "k", "h", "l", "g", "G",
+}
+
+// Action represents a user defined command that can be run for a server
+// from the terminal ui e.g. opening a vnc viewer. Command is a template
+// that may reference {{.IP}}, {{.Hostname}}, {{.User}}, {{.Port}}, and
+// {{.Identity}} where user, port, and identity are the server's resolved
+// ssh settings. Values are quoted for the shell when rendered.
+type Action struct {
+	// Key single character key that runs the action
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	// Command template run with sh -c
+	Command string `json:"command"`
+	// Conditions that must all be met for the action to apply to a server
+	// e.g. "port 5900 open"
+	Conditions []string `json:"conditions,omitempty"`
+	// Interactive runs the command using the session launcher instead of in
+	// the background e.g. for terminal based consoles
+	Interactive bool `json:"interactive,omitempty"`
+}
+
+// ActionTarget represents what is known about a host when checking action
+// conditions
+type ActionTarget struct {
+	IP        string
+	MAC       string
+	Hostname  string
+	Vendor    string
+	OS        string
+	Online    bool
+	SSH       bool
+	OpenPorts []uint16
+}
+
+// Reserved returns true if the action's key is bound to a built in key in
+// the servers view. These actions are ignored.
+func (a Action) Reserved() bool {
+	return slices.Contains(reservedActionKeys, a.Key)
+}
+
+// Validate returns an error if the action can never be run
+func (a Action) Validate() error {
+	if utf8.RuneCountInString(a.Key) != 1 {
+		return fmt.Errorf("action key must be a single character: %q", a.Key)
+	}
+
+	if a.Reserved() {
+		return fmt.Errorf("action key is already in use: %q", a.Key)
+	}
+
+	if a.Label == "" {
+		return errors.New("action label cannot be empty")
+	}
+
+	if a.Command == "" {
+		return errors.New("action command cannot be empty")
+	}
+
+	if _, err := template.New("action").Parse(a.Command); err != nil {
+		return fmt.Errorf("invalid action command: %w", err)
+	}
+
+	for _, c := range a.Conditions {
+		if _, err := parseActionCondition(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Applies returns true if the host meets all of the action's conditions
+func (a Action) Applies(target ActionTarget) bool {
+	for _, c := range a.Conditions {
+		match, err := parseActionCondition(c)
+
+		if err != nil || !match(target) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ActionsFor returns the actions that apply to the given host in the order
+// they are configured
+func (c Config) ActionsFor(target ActionTarget) []Action {
+	actions := []Action{}
+
+	for _, a := range c.Actions {
+		if !a.Reserved() && a.Applies(target) {
+			actions = append(actions, a)
+		}
+	}
+
+	return actions
+}
+
+// ReservedActions returns the actions that are ignored because their key
+// is bound to a built in key e.g. keys reserved after the action was added
+func (c Config) ReservedActions() []Action {
+	actions := []Action{}
+
+	for _, a := range c.Actions {
+		if a.Reserved() {
+			actions = append(actions, a)
+		}
+	}
+
+	return actions
+}
+
+// parses a condition in one of the forms:
+//
+//	online | offline
+//	ssh enabled | ssh disabled
+//	port <port> open | port <port> closed
+//	hostname <pattern>
+//	os <text>
+//	vendor <text>
+//
+// os and vendor match if the host's value contains the text and hostname
+// patterns are globs, all ignoring case.
+func parseActionCondition(condition string) (func(ActionTarget) bool, error) {
+	fields := strings.Fields(strings.ToLower(condition))
+
+	invalid := fmt.Errorf("invalid action condition: %q", condition)
+
+	if len(fields) == 0 {
+		return nil, invalid
+	}
+
+	switch fields[0] {
+	case "online", "offline":
+		if len(fields) != 1 {
+			return nil, invalid
+		}
+
+		online := fields[0] == "online"
+
+		return func(t ActionTarget) bool { return t.Online == online }, nil
+	case "ssh":
+		if len(fields) != 2 || (fields[1] != "enabled" && fields[1] != "disabled") {
+			return nil, invalid
+		}
+
+		enabled := fields[1] == "enabled"
+
+		return func(t ActionTarget) bool { return t.SSH == enabled }, nil
+	case "port":
+		if len(fields) != 3 || (fields[2] != "open" && fields[2] != "closed") {
+			return nil, invalid
+		}
+
+		port, err := strconv.ParseUint(fields[1], 10, 16)
+
+		if err != nil || port == 0 {
+			return nil, invalid
+		}
+
+		open := fields[2] == "open"
+
+		return func(t ActionTarget) bool {
+			return slices.Contains(t.OpenPorts, uint16(port)) == open
+		}, nil
+	case "hostname":
+		if len(fields) != 2 {
+			return nil, invalid
+		}
+
+		pattern := fields[1]
+
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, invalid
+		}
+
+		return func(t ActionTarget) bool {
+			matched, _ := path.Match(pattern, strings.ToLower(t.Hostname))
+			return t.Hostname != "" && matched
+		}, nil
+	case "os", "vendor":
+		if len(fields) < 2 {
+			return nil, invalid
+		}
+
+		field := fields[0]
+		text := strings.Join(fields[1:], " ")
+
+		return func(t ActionTarget) bool {
+			value := t.OS
+
+			if field == "vendor" {
+				value = t.Vendor
+			}
+
+			return strings.Contains(strings.ToLower(value), text)
+		}, nil
+	default:
+		return nil, invalid
+	}
+}
@@ -0,0 +1,107 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/robgonnella/ops/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActions(t *testing.T) {
+	vnc := config.Action{
+		Key:        "v",
+		Label:      "vnc",
+		Command:    "vncviewer {{.IP}}",
+		Conditions: []string{"port 5900 open"},
+	}
+
+	ipmi := config.Action{
+		Key:         "i",
+		Label:       "ipmi console",
+		Command:     "ipmitool -H {{.IP}} -U {{.User}} sol activate",
+		Conditions:  []string{"online", "vendor Super Micro", "ssh disabled"},
+		Interactive: true,
+	}
+
+	web := config.Action{
+		Key:        "w",
+		Label:      "web",
+		Command:    "xdg-open http://{{.Hostname}}",
+		Conditions: []string{"hostname WEB-*", "os ubuntu", "port 443 closed"},
+	}
+
+	always := config.Action{Key: "P", Label: "ping", Command: "ping -c 1 {{.IP}}"}
+
+	conf := config.Config{Actions: []config.Action{vnc, ipmi, web, always}}
+
+	t.Run("returns actions whose conditions are met", func(st *testing.T) {
+		actions := conf.ActionsFor(config.ActionTarget{
+			IP:        "192.168.1.2",
+			Online:    true,
+			OpenPorts: []uint16{22, 5900},
+		})
+
+		assert.Equal(st, []config.Action{vnc, always}, actions)
+
+		actions = conf.ActionsFor(config.ActionTarget{
+			IP:     "192.168.1.3",
+			Vendor: "Super Micro Computer, Inc.",
+			Online: true,
+		})
+
+		assert.Equal(st, []config.Action{ipmi, always}, actions)
+
+		actions = conf.ActionsFor(config.ActionTarget{
+			IP:        "192.168.1.4",
+			Hostname:  "web-01",
+			OS:        "Ubuntu 22.04",
+			Online:    true,
+			SSH:       true,
+			OpenPorts: []uint16{22, 80},
+		})
+
+		assert.Equal(st, []config.Action{web, always}, actions)
+	})
+
+	t.Run("ignores actions bound to reserved keys", func(st *testing.T) {
+		search := config.Action{Key: "/", Label: "search", Command: "open https://{{.IP}}"}
+
+		reserved := conf
+		reserved.Name = "office"
+		reserved.Interface = "eth0"
+		reserved.SSH = config.SSHConfig{Port: "22"}
+		reserved.Actions = []config.Action{search, always}
+
+		assert.True(st, search.Reserved())
+		assert.False(st, always.Reserved())
+		assert.Equal(st, []config.Action{search}, reserved.ReservedActions())
+		assert.Equal(st, []config.Action{always}, reserved.ActionsFor(config.ActionTarget{}))
+		assert.NoError(st, reserved.Validate())
+		assert.Error(st, search.Validate())
+	})
+
+	t.Run("validates actions", func(st *testing.T) {
+		assert.NoError(st, vnc.Validate())
+		assert.NoError(st, ipmi.Validate())
+		assert.NoError(st, web.Validate())
+		assert.NoError(st, always.Validate())
+
+		invalid := []config.Action{
+			{Key: "", Label: "vnc", Command: "vncviewer {{.IP}}"},
+			{Key: "vv", Label: "vnc", Command: "vncviewer {{.IP}}"},
+			{Key: "s", Label: "vnc", Command: "vncviewer {{.IP}}"},
+			{Key: "v", Command: "vncviewer {{.IP}}"},
+			{Key: "v", Label: "vnc"},
+			{Key: "v", Label: "vnc", Command: "vncviewer {{.IP"},
+			{Key: "v", Label: "vnc", Command: "vncviewer", Conditions: []string{"port vnc open"}},
+			{Key: "v", Label: "vnc", Command: "vncviewer", Conditions: []string{"port 5900 listening"}},
+			{Key: "v", Label: "vnc", Command: "vncviewer", Conditions: []string{"ssh"}},
+			{Key: "v", Label: "vnc", Command: "vncviewer", Conditions: []string{"hostname web-[a"}},
+			{Key: "v", Label: "vnc", Command: "vncviewer", Conditions: []string{"uptime 5d"}},
+		}
+
+		for _, a := range invalid {
+			assert.Error(st, a.Validate(), a)
+		}
+	})
+}
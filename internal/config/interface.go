@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 //go:generate mockgen -destination=../mock/config/mock_config.go -package=mock_config . Repo,Service
 
 // SSHOverride represents the config needed to
@@ -9,22 +11,82 @@ type SSHOverride struct {
 	User     string `json:"user"`
 	Identity string `json:"identity"`
 	Port     string `json:"port"`
+	// ProxyJump jump hosts used for matching targets - "none" disables
+	// the default jump hosts
+	ProxyJump string `json:"proxyJump,omitempty"`
 }
 
-// SSHConfig represents the config needed to ssh to servers
+// SSHConfig represents the config needed to ssh to servers. When
+// UseSSHConfig is set the user, identity, port, and proxy jump for each host
+// are read from ~/.ssh/config before falling back to the defaults.
+// ProxyJump is a comma separated chain of [user@]host[:port] jump hosts
+// connected through in order, like ssh -J.
 type SSHConfig struct {
-	User      string        `json:"user"`
-	Identity  string        `json:"identity"`
-	Port      string        `json:"port"`
-	Overrides []SSHOverride `json:"overrides"`
+	User         string        `json:"user"`
+	Identity     string        `json:"identity"`
+	Port         string        `json:"port"`
+	ProxyJump    string        `json:"proxyJump,omitempty"`
+	Native       bool          `json:"native"`
+	UseSSHConfig bool          `json:"useSSHConfig,omitempty"`
+	Overrides    []SSHOverride `json:"overrides"`
+}
+
+const (
+	// DefaultScanInterval default time to wait between network scans
+	DefaultScanInterval = time.Second * 30
+	// DefaultScanTimeout default time to wait for responses during the ARP
+	// and SYN phases of a network scan
+	DefaultScanTimeout = time.Second * 5
+	// DefaultScanListenPort default source port used for SYN scanning
+	DefaultScanListenPort uint16 = 54321
+	// DefaultOfflineAfter default number of consecutive scans a host can miss
+	// before it is considered offline
+	DefaultOfflineAfter = 3
+)
+
+// DefaultServicePorts default ports scanned in addition to ssh ports -
+// http, https, rdp, vnc, ipmi, and printers
+var DefaultServicePorts = []string{"80", "443", "3389", "5900", "623", "9100"}
+
+// ScanConfig represents the config used when scanning the network
+type ScanConfig struct {
+	Interval     string   `json:"interval"`
+	Timeout      string   `json:"timeout"`
+	ListenPort   string   `json:"listenPort"`
+	OfflineAfter int      `json:"offlineAfter"`
+	ServicePorts []string `json:"servicePorts"`
+	// ConcurrentDetails runs all detail providers at the same time instead
+	// of one at a time in priority order
+	ConcurrentDetails bool `json:"concurrentDetails,omitempty"`
+}
+
+// SessionConfig represents how interactive ssh sessions are opened from the
+// terminal ui. Template is a command run with sh -c when using the template
+// launcher and may reference {{.IP}}, {{.Hostname}}, {{.User}}, {{.Port}},
+// {{.Identity}}, and {{.Command}} (the full ssh command) which are all
+// quoted for the shell.
+type SessionConfig struct {
+	Launcher LauncherKind `json:"launcher,omitempty"`
+	Template string       `json:"template,omitempty"`
 }
 
 // Config represents the data structure of our user provided json configuration
 type Config struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	SSH       SSHConfig `json:"ssh"`
-	Interface string    `json:"interface"`
+	ID        string        `json:"id"`
+	Name      string        `json:"name"`
+	SSH       SSHConfig     `json:"ssh"`
+	Scan      ScanConfig    `json:"scan"`
+	Session   SessionConfig `json:"session"`
+	Sort      ServerSort    `json:"sort"`
+	Interface string        `json:"interface"`
+	// Aliases maps short names to host ids (mac addresses) or ips
+	Aliases map[string]string `json:"aliases,omitempty"`
+	// Actions custom commands that can be run for servers from the
+	// terminal ui
+	Actions []Action `json:"actions,omitempty"`
+	// Active marks the config used for its interface when more than one
+	// config exists for the same interface
+	Active bool `json:"active,omitempty"`
 }
 
 // Configs represents our collection of json configs
@@ -50,4 +112,5 @@ type Service interface {
 	Create(conf *Config) (*Config, error)
 	Update(conf *Config) (*Config, error)
 	Delete(id string) error
+	Use(id string) (*Config, error)
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"slices"
 	"sync"
@@ -145,7 +146,9 @@ func (r *JSONRepo) Delete(id string) error {
 	return r.write()
 }
 
-// GetByInterface returns config associated with specific interface name
+// GetByInterface returns config associated with specific interface name.
+// The active config for the interface is returned if one is set, otherwise
+// the last config created for the interface is returned.
 func (r *JSONRepo) GetByInterface(ifaceName string) (*Config, error) {
 	r.mux.Lock()
 	defer r.mux.Unlock()
@@ -153,9 +156,15 @@ func (r *JSONRepo) GetByInterface(ifaceName string) (*Config, error) {
 	var conf *Config
 
 	for _, c := range r.configs {
-		if c.Interface == ifaceName {
-			conf = copyConfig(c)
+		if c.Interface != ifaceName {
+			continue
+		}
+
+		if c.Active {
+			return copyConfig(c), nil
 		}
+
+		conf = copyConfig(c)
 	}
 
 	if conf == nil {
@@ -255,11 +264,20 @@ func copyConfig(c *Config) *Config {
 		ID:   c.ID,
 		Name: c.Name,
 		SSH: SSHConfig{
-			User:      c.SSH.User,
-			Identity:  c.SSH.Identity,
-			Port:      c.SSH.Port,
-			Overrides: c.SSH.Overrides,
+			User:         c.SSH.User,
+			Identity:     c.SSH.Identity,
+			Port:         c.SSH.Port,
+			ProxyJump:    c.SSH.ProxyJump,
+			Native:       c.SSH.Native,
+			UseSSHConfig: c.SSH.UseSSHConfig,
+			Overrides:    c.SSH.Overrides,
 		},
+		Scan:      c.Scan,
+		Session:   c.Session,
+		Sort:      c.Sort,
 		Interface: c.Interface,
+		Aliases:   maps.Clone(c.Aliases),
+		Actions:   c.Actions,
+		Active:    c.Active,
 	}
 }
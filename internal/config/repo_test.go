@@ -14,6 +14,8 @@ func assertEqualConf(t *testing.T, expected, actual *config.Config) {
 	assert.Equal(t, expected.Name, actual.Name)
 	assert.Equal(t, expected.SSH.User, actual.SSH.User)
 	assert.Equal(t, expected.SSH.Identity, actual.SSH.Identity)
+	assert.Equal(t, expected.SSH.Native, actual.SSH.Native)
+	assert.Equal(t, expected.Scan, actual.Scan)
 
 	for i, o := range expected.SSH.Overrides {
 		assert.Equal(t, o.Target, actual.SSH.Overrides[i].Target)
@@ -88,6 +90,12 @@ func TestConfigJsonRepo(t *testing.T) {
 					},
 				},
 			},
+			Scan: config.ScanConfig{
+				Interval:     "1m",
+				Timeout:      "2s",
+				ListenPort:   "12345",
+				OfflineAfter: 2,
+			},
 			Interface: "test",
 		}
 
@@ -200,4 +208,26 @@ func TestConfigJsonRepo(t *testing.T) {
 		assert.NoError(st, err)
 		assertEqualConf(st, newConf2, foundConf)
 	})
+
+	t.Run("gets active config by interface", func(st *testing.T) {
+		active, err := repo.Create(&config.Config{
+			Name:      "test6",
+			Interface: "en2",
+			Active:    true,
+		})
+
+		assert.NoError(st, err)
+
+		_, err = repo.Create(&config.Config{
+			Name:      "test7",
+			Interface: "en2",
+		})
+
+		assert.NoError(st, err)
+
+		foundConf, err := repo.GetByInterface("en2")
+
+		assert.NoError(st, err)
+		assertEqualConf(st, active, foundConf)
+	})
 }
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// GetInterval returns the configured scan interval or the default if unset
+func (c ScanConfig) GetInterval() time.Duration {
+	d, err := parsePositiveDuration(c.Interval)
+
+	if err != nil || d == 0 {
+		return DefaultScanInterval
+	}
+
+	return d
+}
+
+// GetTimeout returns the configured scan timeout or the default if unset
+func (c ScanConfig) GetTimeout() time.Duration {
+	d, err := parsePositiveDuration(c.Timeout)
+
+	if err != nil || d == 0 {
+		return DefaultScanTimeout
+	}
+
+	return d
+}
+
+// GetListenPort returns the configured scan source port or the default
+// if unset
+func (c ScanConfig) GetListenPort() uint16 {
+	port, err := parsePort(c.ListenPort)
+
+	if err != nil || port == 0 {
+		return DefaultScanListenPort
+	}
+
+	return port
+}
+
+// GetOfflineAfter returns the configured number of missed scans before a
+// host is considered offline or the default if unset
+func (c ScanConfig) GetOfflineAfter() int {
+	if c.OfflineAfter <= 0 {
+		return DefaultOfflineAfter
+	}
+
+	return c.OfflineAfter
+}
+
+// Validate returns an error if any of the scan settings are invalid.
+// Empty values are valid and result in defaults being used.
+func (c ScanConfig) Validate() error {
+	if _, err := parsePositiveDuration(c.Interval); err != nil {
+		return fmt.Errorf("invalid scan interval: %w", err)
+	}
+
+	if _, err := parsePositiveDuration(c.Timeout); err != nil {
+		return fmt.Errorf("invalid scan timeout: %w", err)
+	}
+
+	if _, err := parsePort(c.ListenPort); err != nil {
+		return fmt.Errorf("invalid scan listen port: %w", err)
+	}
+
+	if c.OfflineAfter < 0 {
+		return fmt.Errorf("invalid offline after value: %d", c.OfflineAfter)
+	}
+
+	for _, p := range c.ServicePorts {
+		if port, err := parsePort(p); err != nil || port == 0 {
+			return fmt.Errorf("invalid service port: %s", p)
+		}
+	}
+
+	return nil
+}
+
+// ScanPorts returns all ports that should be scanned for this config -
+// the default ssh port, ssh override ports, and service ports
+func (c Config) ScanPorts() []string {
+	ports := []string{c.SSH.Port}
+
+	for _, o := range c.SSH.Overrides {
+		if o.Port != "" && !slices.Contains(ports, o.Port) {
+			ports = append(ports, o.Port)
+		}
+	}
+
+	for _, p := range c.Scan.ServicePorts {
+		if !slices.Contains(ports, p) {
+			ports = append(ports, p)
+		}
+	}
+
+	return ports
+}
+
+// helpers
+
+func parsePositiveDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(value)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be greater than zero: %s", value)
+	}
+
+	return d, nil
+}
+
+func parsePort(value string) (uint16, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	port, err := strconv.ParseUint(value, 10, 16)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return uint16(port), nil
+}
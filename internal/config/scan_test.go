@@ -0,0 +1,64 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robgonnella/ops/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanConfig(t *testing.T) {
+	t.Run("returns defaults when unset", func(st *testing.T) {
+		scanConf := config.ScanConfig{}
+
+		assert.Equal(st, config.DefaultScanInterval, scanConf.GetInterval())
+		assert.Equal(st, config.DefaultScanTimeout, scanConf.GetTimeout())
+		assert.Equal(st, config.DefaultScanListenPort, scanConf.GetListenPort())
+		assert.Equal(st, config.DefaultOfflineAfter, scanConf.GetOfflineAfter())
+		assert.NoError(st, scanConf.Validate())
+	})
+
+	t.Run("returns configured values", func(st *testing.T) {
+		scanConf := config.ScanConfig{
+			Interval:     "1m",
+			Timeout:      "500ms",
+			ListenPort:   "12345",
+			OfflineAfter: 5,
+		}
+
+		assert.Equal(st, time.Minute, scanConf.GetInterval())
+		assert.Equal(st, time.Millisecond*500, scanConf.GetTimeout())
+		assert.Equal(st, uint16(12345), scanConf.GetListenPort())
+		assert.Equal(st, 5, scanConf.GetOfflineAfter())
+		assert.NoError(st, scanConf.Validate())
+	})
+
+	t.Run("returns error for invalid values", func(st *testing.T) {
+		assert.Error(st, config.ScanConfig{Interval: "thirty"}.Validate())
+		assert.Error(st, config.ScanConfig{Interval: "-30s"}.Validate())
+		assert.Error(st, config.ScanConfig{Timeout: "0s"}.Validate())
+		assert.Error(st, config.ScanConfig{ListenPort: "70000"}.Validate())
+		assert.Error(st, config.ScanConfig{OfflineAfter: -1}.Validate())
+		assert.Error(st, config.ScanConfig{ServicePorts: []string{"http"}}.Validate())
+		assert.Error(st, config.ScanConfig{ServicePorts: []string{"0"}}.Validate())
+	})
+
+	t.Run("returns unique scan ports", func(st *testing.T) {
+		conf := config.Config{
+			SSH: config.SSHConfig{
+				Port: "22",
+				Overrides: []config.SSHOverride{
+					{Target: "192.168.1.2", Port: "2222"},
+					{Target: "192.168.1.3"},
+					{Target: "192.168.1.4", Port: "22"},
+				},
+			},
+			Scan: config.ScanConfig{
+				ServicePorts: []string{"80", "2222", "443"},
+			},
+		}
+
+		assert.Equal(st, []string{"22", "2222", "80", "443"}, conf.ScanPorts())
+	})
+}
@@ -40,3 +40,35 @@ func (s *ConfigService) Update(conf *Config) (*Config, error) {
 func (s *ConfigService) Delete(id string) error {
 	return s.repo.Delete(id)
 }
+
+// Use marks a config as the active config for its interface
+func (s *ConfigService) Use(id string) (*Config, error) {
+	conf, err := s.repo.Get(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	confs, err := s.repo.GetAll()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range confs {
+		if c.ID == conf.ID || c.Interface != conf.Interface || !c.Active {
+			continue
+		}
+
+		inactive := *c
+		inactive.Active = false
+
+		if _, err := s.repo.Update(&inactive); err != nil {
+			return nil, err
+		}
+	}
+
+	conf.Active = true
+
+	return s.repo.Update(conf)
+}
@@ -117,6 +117,30 @@ func TestConfigService(t *testing.T) {
 		assert.NoError(st, err)
 	})
 
+	t.Run("uses config", func(st *testing.T) {
+		previous := &config.Config{ID: "11", Name: "previous", Interface: "test", Active: true}
+		other := &config.Config{ID: "12", Name: "other", Interface: "other", Active: true}
+		conf := &config.Config{ID: "13", Name: "next", Interface: "test"}
+
+		mockRepo.EXPECT().Get(conf.ID).Return(conf, nil)
+		mockRepo.EXPECT().GetAll().Return([]*config.Config{previous, other, conf}, nil)
+
+		inactive := *previous
+		inactive.Active = false
+
+		mockRepo.EXPECT().Update(&inactive).Return(&inactive, nil)
+
+		active := *conf
+		active.Active = true
+
+		mockRepo.EXPECT().Update(&active).Return(&active, nil)
+
+		used, err := service.Use(conf.ID)
+
+		assert.NoError(st, err)
+		assert.True(st, used.Active)
+	})
+
 	t.Run("gets last config by cidr", func(st *testing.T) {
 		ifaceName := "test"
 
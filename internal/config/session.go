@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"text/template"
+)
+
+// LauncherKind represents how interactive ssh sessions are opened
+type LauncherKind string
+
+const (
+	// LauncherInline suspends the terminal ui and runs ssh in the same
+	// terminal until the session ends
+	LauncherInline LauncherKind = "inline"
+	// LauncherTmuxWindow opens ssh in a new tmux window
+	LauncherTmuxWindow LauncherKind = "tmux-window"
+	// LauncherTmuxPane opens ssh in a new tmux pane split from the current
+	// pane
+	LauncherTmuxPane LauncherKind = "tmux-pane"
+	// LauncherScreen opens ssh in a new GNU screen window
+	LauncherScreen LauncherKind = "screen"
+	// LauncherTemplate runs a user supplied command template e.g. to open a
+	// new terminal tab
+	LauncherTemplate LauncherKind = "template"
+)
+
+// LauncherKinds all supported launchers
+var LauncherKinds = []LauncherKind{
+	LauncherInline,
+	LauncherTmuxWindow,
+	LauncherTmuxPane,
+	LauncherScreen,
+	LauncherTemplate,
+}
+
+// Kind returns the configured launcher defaulting to inline
+func (c SessionConfig) Kind() LauncherKind {
+	if c.Launcher == "" {
+		return LauncherInline
+	}
+
+	return c.Launcher
+}
+
+// Validate returns an error if the launcher is unknown or the template is
+// missing or invalid
+func (c SessionConfig) Validate() error {
+	switch c.Kind() {
+	case LauncherInline, LauncherTmuxWindow, LauncherTmuxPane, LauncherScreen:
+		return nil
+	case LauncherTemplate:
+		if c.Template == "" {
+			return fmt.Errorf("session launcher template cannot be empty")
+		}
+
+		if _, err := template.New("session").Parse(c.Template); err != nil {
+			return fmt.Errorf("invalid session launcher template: %w", err)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown session launcher: %s", c.Launcher)
+	}
+}
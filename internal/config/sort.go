@@ -0,0 +1,49 @@
+package config
+
+import "fmt"
+
+// SortColumn represents a server table column servers can be sorted by
+type SortColumn string
+
+const (
+	// SortDefault sorts servers with ssh enabled first and then by ip
+	SortDefault SortColumn = ""
+	// SortHostname sorts servers by hostname
+	SortHostname SortColumn = "hostname"
+	// SortIP sorts servers by ip address
+	SortIP SortColumn = "ip"
+	// SortVendor sorts servers by vendor
+	SortVendor SortColumn = "vendor"
+	// SortOS sorts servers by operating system
+	SortOS SortColumn = "os"
+	// SortLastSeen sorts servers by when they were last seen online
+	SortLastSeen SortColumn = "lastSeen"
+)
+
+// SortColumns all supported sort columns in the order they are cycled
+// through in the terminal ui
+var SortColumns = []SortColumn{
+	SortDefault,
+	SortHostname,
+	SortIP,
+	SortVendor,
+	SortOS,
+	SortLastSeen,
+}
+
+// ServerSort represents how servers are sorted in the terminal ui
+type ServerSort struct {
+	Column     SortColumn `json:"column,omitempty"`
+	Descending bool       `json:"descending,omitempty"`
+}
+
+// Validate returns an error if the sort column is unknown
+func (s ServerSort) Validate() error {
+	for _, column := range SortColumns {
+		if s.Column == column {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown sort column: %s", s.Column)
+}
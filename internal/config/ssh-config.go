@@ -0,0 +1,160 @@
+package config
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kevinburke/ssh_config"
+)
+
+// SSHConfigSettings represents the settings read from the user's ssh_config
+// file for a single host
+type SSHConfigSettings struct {
+	User      string
+	Identity  string
+	Port      string
+	ProxyJump string
+}
+
+// cached parsed ssh_config file - reparsed when the file is modified
+type sshConfigFile struct {
+	path    string
+	modTime time.Time
+	conf    *ssh_config.Config
+}
+
+var sshConfigCache = struct {
+	file *sshConfigFile
+	mux  sync.Mutex
+}{}
+
+// returns the path to the user's ssh_config file
+func sshConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".ssh", "config"), nil
+}
+
+// returns the parsed ssh_config file for the current user, or nil if the
+// file does not exist or cannot be parsed. Files referenced with Include are
+// parsed along with it.
+func loadSSHConfig() *ssh_config.Config {
+	path, err := sshConfigPath()
+
+	if err != nil {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+
+	if err != nil {
+		return nil
+	}
+
+	sshConfigCache.mux.Lock()
+	defer sshConfigCache.mux.Unlock()
+
+	cached := sshConfigCache.file
+
+	if cached != nil && cached.path == path && cached.modTime.Equal(info.ModTime()) {
+		return cached.conf
+	}
+
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil
+	}
+
+	defer file.Close()
+
+	conf, err := ssh_config.Decode(file)
+
+	if err != nil {
+		return nil
+	}
+
+	sshConfigCache.file = &sshConfigFile{
+		path:    path,
+		modTime: info.ModTime(),
+		conf:    conf,
+	}
+
+	return conf
+}
+
+// LookupSSHConfig returns the user, identity file, port, and proxy jump set in
+// ~/.ssh/config, including any files it Includes, for the first of the
+// given aliases that matches a Host pattern setting each value. Empty
+// aliases are ignored.
+func LookupSSHConfig(aliases ...string) SSHConfigSettings {
+	settings := SSHConfigSettings{}
+
+	conf := loadSSHConfig()
+
+	if conf == nil {
+		return settings
+	}
+
+	get := func(key string) (string, string) {
+		for _, alias := range aliases {
+			if alias == "" {
+				continue
+			}
+
+			if val, err := conf.Get(alias, key); err == nil && val != "" {
+				return val, alias
+			}
+		}
+
+		return "", ""
+	}
+
+	settings.User, _ = get("User")
+	settings.Port, _ = get("Port")
+	settings.ProxyJump, _ = get("ProxyJump")
+
+	if identity, alias := get("IdentityFile"); identity != "" {
+		settings.Identity = expandSSHConfigPath(identity, alias, settings.User)
+	}
+
+	return settings
+}
+
+// expands the leading ~ and the %d, %h, %r, %u, and %% tokens supported by
+// ssh in IdentityFile paths
+func expandSSHConfigPath(path, alias, remoteUser string) string {
+	home, _ := os.UserHomeDir()
+
+	if path == "~" {
+		return home
+	}
+
+	if strings.HasPrefix(path, "~/") {
+		path = filepath.Join(home, path[2:])
+	}
+
+	localUser := ""
+
+	if u, err := user.Current(); err == nil {
+		localUser = u.Username
+	}
+
+	replacer := strings.NewReplacer(
+		"%%", "%",
+		"%d", home,
+		"%h", alias,
+		"%r", remoteUser,
+		"%u", localUser,
+	)
+
+	return replacer.Replace(path)
+}
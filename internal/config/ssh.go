@@ -0,0 +1,338 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path"
+	"slices"
+	"strings"
+)
+
+// OverrideKind represents how an ssh override target is matched to a host
+type OverrideKind string
+
+const (
+	// OverrideMAC matches a host's mac address
+	OverrideMAC OverrideKind = "mac"
+	// OverrideIP matches a host's ip address
+	OverrideIP OverrideKind = "ip"
+	// OverrideCIDR matches hosts with an ip in the cidr range
+	OverrideCIDR OverrideKind = "cidr"
+	// OverrideHostname matches hostnames using a case-insensitive glob
+	// pattern e.g. web-*
+	OverrideHostname OverrideKind = "hostname"
+	// OverrideVendor matches hosts whose vendor contains the text after the
+	// "vendor:" prefix, ignoring case
+	OverrideVendor OverrideKind = "vendor"
+)
+
+// vendorPrefix prefix used for override targets that match vendors
+const vendorPrefix = "vendor:"
+
+// proxyJumpNone proxy jump value used to connect directly
+const proxyJumpNone = "none"
+
+// JumpHost represents a single host in a proxy jump chain. User and Port
+// are empty when not specified.
+type JumpHost struct {
+	User string
+	Host string
+	Port string
+}
+
+// SSHTarget represents what is known about a host when resolving the ssh
+// credentials used to connect to it
+type SSHTarget struct {
+	IP       string
+	MAC      string
+	Hostname string
+	Vendor   string
+}
+
+// SSHCredentials represents the ssh settings used to connect to a single
+// target after applying overrides
+type SSHCredentials struct {
+	User     string
+	Identity string
+	Port     string
+	// ProxyJump comma separated jump hosts to connect through - empty when
+	// connecting directly
+	ProxyJump string
+	// Overrides targets of the overrides that were applied, most specific
+	// first
+	Overrides []string
+	// FromSSHConfig true if any setting was read from ~/.ssh/config
+	FromSSHConfig bool
+}
+
+// Kind returns how the override's target is matched to hosts
+func (o SSHOverride) Kind() OverrideKind {
+	if strings.HasPrefix(strings.ToLower(o.Target), vendorPrefix) {
+		return OverrideVendor
+	}
+
+	if _, err := net.ParseMAC(o.Target); err == nil {
+		return OverrideMAC
+	}
+
+	if net.ParseIP(o.Target) != nil {
+		return OverrideIP
+	}
+
+	if _, _, err := net.ParseCIDR(o.Target); err == nil {
+		return OverrideCIDR
+	}
+
+	return OverrideHostname
+}
+
+// Validate returns an error if the override's target can never match
+func (o SSHOverride) Validate() error {
+	if o.Target == "" {
+		return errors.New("ssh override target cannot be empty")
+	}
+
+	switch o.Kind() {
+	case OverrideVendor:
+		if strings.TrimSpace(o.Target[len(vendorPrefix):]) == "" {
+			return fmt.Errorf("invalid ssh override vendor: %s", o.Target)
+		}
+	case OverrideHostname:
+		if strings.Contains(o.Target, "/") {
+			return fmt.Errorf("invalid ssh override cidr: %s", o.Target)
+		}
+
+		if _, err := path.Match(o.Target, ""); err != nil {
+			return fmt.Errorf("invalid ssh override hostname pattern: %s", o.Target)
+		}
+	}
+
+	return nil
+}
+
+// Matches returns true if the override applies to the given host
+func (o SSHOverride) Matches(target SSHTarget) bool {
+	switch o.Kind() {
+	case OverrideMAC:
+		mac, _ := net.ParseMAC(o.Target)
+		targetMAC, err := net.ParseMAC(target.MAC)
+		return err == nil && mac.String() == targetMAC.String()
+	case OverrideIP:
+		ip := net.ParseIP(target.IP)
+		return ip != nil && ip.Equal(net.ParseIP(o.Target))
+	case OverrideCIDR:
+		_, ipNet, _ := net.ParseCIDR(o.Target)
+		ip := net.ParseIP(target.IP)
+		return ip != nil && ipNet.Contains(ip)
+	case OverrideVendor:
+		vendor := strings.TrimSpace(o.Target[len(vendorPrefix):])
+		return target.Vendor != "" &&
+			strings.Contains(strings.ToLower(target.Vendor), strings.ToLower(vendor))
+	default:
+		if target.Hostname == "" || target.Hostname == "Unknown" {
+			return false
+		}
+
+		matched, _ := path.Match(
+			strings.ToLower(o.Target),
+			strings.ToLower(target.Hostname),
+		)
+
+		return matched
+	}
+}
+
+// returns how specific the override is - higher values take precedence
+func (o SSHOverride) specificity() int {
+	switch o.Kind() {
+	case OverrideMAC:
+		return 300
+	case OverrideIP:
+		return 200
+	case OverrideCIDR:
+		// longer prefixes are more specific - max 128 for ipv6
+		_, ipNet, _ := net.ParseCIDR(o.Target)
+		ones, _ := ipNet.Mask.Size()
+		return 50 + ones
+	case OverrideHostname:
+		return 20
+	default:
+		return 10
+	}
+}
+
+// MatchingOverrides returns all overrides that apply to the given host
+// ordered by precedence - mac, ip, cidr (longest prefix first), hostname
+// pattern, and then vendor. Overrides of equal precedence are kept in the
+// order they are configured.
+func (c Config) MatchingOverrides(target SSHTarget) []SSHOverride {
+	matches := []SSHOverride{}
+
+	for _, o := range c.SSH.Overrides {
+		if o.Matches(target) {
+			matches = append(matches, o)
+		}
+	}
+
+	slices.SortStableFunc(matches, func(o1, o2 SSHOverride) int {
+		return o2.specificity() - o1.specificity()
+	})
+
+	return matches
+}
+
+// SSHCredentials returns the ssh user, identity, and port to use for the
+// given host. Each setting is taken from the highest precedence matching
+// override that sets it, then from ~/.ssh/config if enabled, falling back
+// to the default ssh settings.
+func (c Config) SSHCredentials(target SSHTarget) SSHCredentials {
+	creds := SSHCredentials{}
+
+	for _, o := range c.MatchingOverrides(target) {
+		applied := false
+
+		if creds.User == "" && o.User != "" {
+			creds.User = o.User
+			applied = true
+		}
+
+		if creds.Identity == "" && o.Identity != "" {
+			creds.Identity = o.Identity
+			applied = true
+		}
+
+		if creds.Port == "" && o.Port != "" {
+			creds.Port = o.Port
+			applied = true
+		}
+
+		if creds.ProxyJump == "" && o.ProxyJump != "" {
+			creds.ProxyJump = o.ProxyJump
+			applied = true
+		}
+
+		if applied {
+			creds.Overrides = append(creds.Overrides, o.Target)
+		}
+	}
+
+	if c.SSH.UseSSHConfig {
+		// hostnames are checked first as they are what Host patterns
+		// usually match
+		settings := LookupSSHConfig(target.Hostname, target.IP)
+
+		if creds.User == "" && settings.User != "" {
+			creds.User = settings.User
+			creds.FromSSHConfig = true
+		}
+
+		if creds.Identity == "" && settings.Identity != "" {
+			creds.Identity = settings.Identity
+			creds.FromSSHConfig = true
+		}
+
+		if creds.Port == "" && settings.Port != "" {
+			creds.Port = settings.Port
+			creds.FromSSHConfig = true
+		}
+
+		if creds.ProxyJump == "" && settings.ProxyJump != "" {
+			creds.ProxyJump = settings.ProxyJump
+			creds.FromSSHConfig = true
+		}
+	}
+
+	if creds.User == "" {
+		creds.User = c.SSH.User
+	}
+
+	if creds.Identity == "" {
+		creds.Identity = c.SSH.Identity
+	}
+
+	if creds.Port == "" {
+		creds.Port = c.SSH.Port
+	}
+
+	if creds.ProxyJump == "" {
+		creds.ProxyJump = c.SSH.ProxyJump
+	}
+
+	// "none" stops lower precedence jump hosts from being used
+	if strings.EqualFold(creds.ProxyJump, proxyJumpNone) {
+		creds.ProxyJump = ""
+	}
+
+	return creds
+}
+
+// Args returns the arguments passed to the ssh command to connect to the
+// given ip with these credentials
+func (c SSHCredentials) Args(ip string) []string {
+	args := []string{"-i", c.Identity, "-p", c.Port, "-l", c.User}
+
+	if c.ProxyJump != "" {
+		args = append(args, "-J", c.ProxyJump)
+	}
+
+	return append(args, ip)
+}
+
+// Jumps returns the parsed jump hosts to connect through
+func (c SSHCredentials) Jumps() ([]JumpHost, error) {
+	return ParseProxyJump(c.ProxyJump)
+}
+
+// ParseProxyJump parses a comma separated chain of [user@]host[:port] jump
+// hosts. An empty chain or "none" returns no jump hosts.
+func ParseProxyJump(spec string) ([]JumpHost, error) {
+	jumps := []JumpHost{}
+
+	if spec == "" || strings.EqualFold(spec, proxyJumpNone) {
+		return jumps, nil
+	}
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		jump := JumpHost{}
+
+		if user, host, ok := strings.Cut(part, "@"); ok {
+			jump.User = user
+			part = host
+		}
+
+		jump.Host = part
+
+		// only split ports from bracketed or single colon addresses so
+		// bare ipv6 addresses are left intact
+		if strings.HasPrefix(part, "[") || strings.Count(part, ":") == 1 {
+			host, port, err := net.SplitHostPort(part)
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid ssh proxy jump: %s", spec)
+			}
+
+			jump.Host = host
+			jump.Port = port
+		}
+
+		if jump.Host == "" || strings.ContainsAny(jump.Host, " /") {
+			return nil, fmt.Errorf("invalid ssh proxy jump: %s", spec)
+		}
+
+		if port, err := parsePort(jump.Port); err != nil || (jump.Port != "" && port == 0) {
+			return nil, fmt.Errorf("invalid ssh proxy jump port: %s", spec)
+		}
+
+		jumps = append(jumps, jump)
+	}
+
+	return jumps, nil
+}
+
+// ResolveAlias returns the target for the given alias if one exists
+func (c Config) ResolveAlias(alias string) (string, bool) {
+	target, ok := c.Aliases[alias]
+	return target, ok
+}
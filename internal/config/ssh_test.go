@@ -0,0 +1,242 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robgonnella/ops/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSSHCredentials(t *testing.T) {
+	conf := config.Config{
+		SSH: config.SSHConfig{
+			User:     "user",
+			Identity: "identity",
+			Port:     "22",
+			Overrides: []config.SSHOverride{
+				{Target: "192.168.1.2", User: "root", Port: "2222"},
+			},
+		},
+		Aliases: map[string]string{"db": "192.168.1.2"},
+	}
+
+	t.Run("returns default credentials", func(st *testing.T) {
+		creds := conf.SSHCredentials(config.SSHTarget{IP: "192.168.1.3"})
+
+		assert.Equal(st, config.SSHCredentials{User: "user", Identity: "identity", Port: "22"}, creds)
+		assert.Equal(
+			st,
+			[]string{"-i", "identity", "-p", "22", "-l", "user", "192.168.1.3"},
+			creds.Args("192.168.1.3"),
+		)
+	})
+
+	t.Run("applies overrides", func(st *testing.T) {
+		creds := conf.SSHCredentials(config.SSHTarget{IP: "192.168.1.2"})
+
+		assert.Equal(st, config.SSHCredentials{
+			User:      "root",
+			Identity:  "identity",
+			Port:      "2222",
+			Overrides: []string{"192.168.1.2"},
+		}, creds)
+	})
+
+	t.Run("matches overrides by kind with precedence", func(st *testing.T) {
+		conf := config.Config{
+			SSH: config.SSHConfig{
+				User:     "user",
+				Identity: "identity",
+				Port:     "22",
+				Overrides: []config.SSHOverride{
+					{Target: "vendor:raspberry", User: "pi", Identity: "pi_key"},
+					{Target: "WEB-*", User: "deploy"},
+					{Target: "10.0.0.0/8", Port: "2200"},
+					{Target: "10.1.0.0/16", Port: "2201"},
+					{Target: "AA-BB-CC-DD-EE-FF", User: "admin"},
+				},
+			},
+		}
+
+		assert.Equal(st, config.OverrideVendor, conf.SSH.Overrides[0].Kind())
+		assert.Equal(st, config.OverrideHostname, conf.SSH.Overrides[1].Kind())
+		assert.Equal(st, config.OverrideCIDR, conf.SSH.Overrides[2].Kind())
+		assert.Equal(st, config.OverrideMAC, conf.SSH.Overrides[4].Kind())
+
+		creds := conf.SSHCredentials(config.SSHTarget{
+			IP:       "10.1.2.3",
+			MAC:      "aa:bb:cc:dd:ee:ff",
+			Hostname: "web-01",
+			Vendor:   "Raspberry Pi Trading Ltd",
+		})
+
+		assert.Equal(st, config.SSHCredentials{
+			User:      "admin",
+			Identity:  "pi_key",
+			Port:      "2201",
+			Overrides: []string{"AA-BB-CC-DD-EE-FF", "10.1.0.0/16", "vendor:raspberry"},
+		}, creds)
+
+		creds = conf.SSHCredentials(config.SSHTarget{
+			IP:       "10.2.0.1",
+			Hostname: "Web-02",
+		})
+
+		assert.Equal(st, config.SSHCredentials{
+			User:      "deploy",
+			Identity:  "identity",
+			Port:      "2200",
+			Overrides: []string{"10.0.0.0/8", "WEB-*"},
+		}, creds)
+
+		creds = conf.SSHCredentials(config.SSHTarget{IP: "192.168.1.2", Hostname: "Unknown"})
+
+		assert.Equal(st, config.SSHCredentials{User: "user", Identity: "identity", Port: "22"}, creds)
+	})
+
+	t.Run("applies proxy jump chains", func(st *testing.T) {
+		conf := config.Config{
+			SSH: config.SSHConfig{
+				User:      "user",
+				Identity:  "identity",
+				Port:      "22",
+				ProxyJump: "bastion",
+				Overrides: []config.SSHOverride{
+					{Target: "10.0.0.0/8", ProxyJump: "admin@bastion:2222,10.0.0.1"},
+					{Target: "192.168.1.0/24", ProxyJump: "none"},
+				},
+			},
+		}
+
+		creds := conf.SSHCredentials(config.SSHTarget{IP: "10.1.2.3"})
+
+		assert.Equal(st, "admin@bastion:2222,10.0.0.1", creds.ProxyJump)
+		assert.Equal(
+			st,
+			[]string{"-i", "identity", "-p", "22", "-l", "user", "-J", "admin@bastion:2222,10.0.0.1", "10.1.2.3"},
+			creds.Args("10.1.2.3"),
+		)
+
+		jumps, err := creds.Jumps()
+
+		assert.NoError(st, err)
+		assert.Equal(st, []config.JumpHost{
+			{User: "admin", Host: "bastion", Port: "2222"},
+			{Host: "10.0.0.1"},
+		}, jumps)
+
+		creds = conf.SSHCredentials(config.SSHTarget{IP: "172.16.0.1"})
+
+		assert.Equal(st, "bastion", creds.ProxyJump)
+
+		creds = conf.SSHCredentials(config.SSHTarget{IP: "192.168.1.2"})
+
+		assert.Equal(st, "", creds.ProxyJump)
+		assert.Equal(st, []string{"192.168.1.0/24"}, creds.Overrides)
+	})
+
+	t.Run("parses proxy jump hosts", func(st *testing.T) {
+		jumps, err := config.ParseProxyJump("[fe80::1]:2222, root@fe80::2")
+
+		assert.NoError(st, err)
+		assert.Equal(st, []config.JumpHost{
+			{Host: "fe80::1", Port: "2222"},
+			{User: "root", Host: "fe80::2"},
+		}, jumps)
+
+		jumps, err = config.ParseProxyJump("none")
+
+		assert.NoError(st, err)
+		assert.Empty(st, jumps)
+
+		_, err = config.ParseProxyJump("bastion:0")
+
+		assert.Error(st, err)
+	})
+
+	t.Run("resolves aliases", func(st *testing.T) {
+		target, ok := conf.ResolveAlias("db")
+
+		assert.True(st, ok)
+		assert.Equal(st, "192.168.1.2", target)
+
+		_, ok = conf.ResolveAlias("web")
+
+		assert.False(st, ok)
+	})
+}
+
+func TestSSHCredentialsFromSSHConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	sshDir := filepath.Join(home, ".ssh")
+	included := filepath.Join(sshDir, "config.d", "racks")
+
+	assert.NoError(t, os.MkdirAll(filepath.Dir(included), 0700))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(sshDir, "config"), []byte(
+		"Include "+filepath.Join(sshDir, "config.d", "*")+"\n\n"+
+			"Host web-*\n"+
+			"  User deploy\n"+
+			"  IdentityFile ~/.ssh/web_%r\n",
+	), 0600))
+
+	assert.NoError(t, os.WriteFile(included, []byte(
+		"Host 10.0.1.*\n"+
+			"  User rack\n"+
+			"  Port 2200\n",
+	), 0600))
+
+	conf := config.Config{
+		SSH: config.SSHConfig{
+			User:         "user",
+			Identity:     "identity",
+			Port:         "22",
+			UseSSHConfig: true,
+			Overrides: []config.SSHOverride{
+				{Target: "10.0.1.5", User: "root"},
+			},
+		},
+	}
+
+	t.Run("reads settings by hostname and ip", func(st *testing.T) {
+		creds := conf.SSHCredentials(config.SSHTarget{IP: "10.0.1.2", Hostname: "web-01"})
+
+		assert.Equal(st, config.SSHCredentials{
+			User:          "deploy",
+			Identity:      filepath.Join(sshDir, "web_deploy"),
+			Port:          "2200",
+			FromSSHConfig: true,
+		}, creds)
+	})
+
+	t.Run("layers overrides on top", func(st *testing.T) {
+		creds := conf.SSHCredentials(config.SSHTarget{IP: "10.0.1.5"})
+
+		assert.Equal(st, config.SSHCredentials{
+			User:          "root",
+			Identity:      "identity",
+			Port:          "2200",
+			Overrides:     []string{"10.0.1.5"},
+			FromSSHConfig: true,
+		}, creds)
+	})
+
+	t.Run("falls back to defaults", func(st *testing.T) {
+		creds := conf.SSHCredentials(config.SSHTarget{IP: "10.0.2.1"})
+
+		assert.Equal(st, config.SSHCredentials{User: "user", Identity: "identity", Port: "22"}, creds)
+	})
+
+	t.Run("ignores ssh config when disabled", func(st *testing.T) {
+		disabled := conf
+		disabled.SSH.UseSSHConfig = false
+
+		creds := disabled.SSHCredentials(config.SSHTarget{IP: "10.0.1.2", Hostname: "web-01"})
+
+		assert.Equal(st, config.SSHCredentials{User: "user", Identity: "identity", Port: "22"}, creds)
+	})
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Validate returns an error if any of the config settings are invalid
+func (c Config) Validate() error {
+	if c.Name == "" {
+		return errors.New("config name cannot be empty")
+	}
+
+	if c.Interface == "" {
+		return errors.New("config interface cannot be empty")
+	}
+
+	if err := c.SSH.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Session.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Sort.Validate(); err != nil {
+		return err
+	}
+
+	keys := []string{}
+
+	for _, a := range c.Actions {
+		// ignored rather than rejecting configs that bound a key before it
+		// was reserved
+		if a.Reserved() {
+			continue
+		}
+
+		if err := a.Validate(); err != nil {
+			return err
+		}
+
+		if slices.Contains(keys, a.Key) {
+			return fmt.Errorf("duplicate action key: %q", a.Key)
+		}
+
+		keys = append(keys, a.Key)
+	}
+
+	return c.Scan.Validate()
+}
+
+// Validate returns an error if any of the ssh settings are invalid
+func (c SSHConfig) Validate() error {
+	if port, err := parsePort(c.Port); err != nil || port == 0 {
+		return fmt.Errorf("invalid ssh port: %s", c.Port)
+	}
+
+	if _, err := ParseProxyJump(c.ProxyJump); err != nil {
+		return err
+	}
+
+	targets := []string{}
+
+	for _, o := range c.Overrides {
+		if err := o.Validate(); err != nil {
+			return err
+		}
+
+		if slices.Contains(targets, o.Target) {
+			return fmt.Errorf("duplicate ssh override target: %s", o.Target)
+		}
+
+		if _, err := parsePort(o.Port); err != nil {
+			return fmt.Errorf("invalid ssh override port: %s", o.Port)
+		}
+
+		if _, err := ParseProxyJump(o.ProxyJump); err != nil {
+			return err
+		}
+
+		targets = append(targets, o.Target)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/robgonnella/ops/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateConfig(t *testing.T) {
+	valid := config.Config{
+		Name: "default",
+		SSH: config.SSHConfig{
+			User:     "user",
+			Identity: "identity",
+			Port:     "22",
+			Overrides: []config.SSHOverride{
+				{Target: "192.168.1.2", Port: "2222"},
+				{Target: "192.168.1.3"},
+			},
+		},
+		Interface: "en0",
+	}
+
+	t.Run("returns nil for valid config", func(st *testing.T) {
+		assert.NoError(st, valid.Validate())
+	})
+
+	t.Run("returns error for invalid values", func(st *testing.T) {
+		noName := valid
+		noName.Name = ""
+		assert.Error(st, noName.Validate())
+
+		noInterface := valid
+		noInterface.Interface = ""
+		assert.Error(st, noInterface.Validate())
+
+		badPort := valid
+		badPort.SSH.Port = "ssh"
+		assert.Error(st, badPort.Validate())
+
+		badScan := valid
+		badScan.Scan.Interval = "often"
+		assert.Error(st, badScan.Validate())
+
+		badProxyJump := valid
+		badProxyJump.SSH.ProxyJump = "bastion:ssh"
+		assert.Error(st, badProxyJump.Validate())
+	})
+
+	t.Run("returns error for invalid overrides", func(st *testing.T) {
+		ssh := valid.SSH
+
+		ssh.Overrides = []config.SSHOverride{{Port: "22"}}
+		assert.Error(st, ssh.Validate())
+
+		ssh.Overrides = []config.SSHOverride{{Target: "192.168.1.2", Port: "99999"}}
+		assert.Error(st, ssh.Validate())
+
+		ssh.Overrides = []config.SSHOverride{
+			{Target: "192.168.1.2"},
+			{Target: "192.168.1.2"},
+		}
+		assert.Error(st, ssh.Validate())
+
+		ssh.Overrides = []config.SSHOverride{{Target: "192.168.1.0/33"}}
+		assert.Error(st, ssh.Validate())
+
+		ssh.Overrides = []config.SSHOverride{{Target: "web-[a"}}
+		assert.Error(st, ssh.Validate())
+
+		ssh.Overrides = []config.SSHOverride{{Target: "vendor:"}}
+		assert.Error(st, ssh.Validate())
+
+		ssh.Overrides = []config.SSHOverride{{Target: "192.168.1.2", ProxyJump: "bastion,"}}
+		assert.Error(st, ssh.Validate())
+	})
+
+	t.Run("validates session launcher", func(st *testing.T) {
+		session := config.SessionConfig{}
+		assert.NoError(st, session.Validate())
+		assert.Equal(st, config.LauncherInline, session.Kind())
+
+		session = config.SessionConfig{Launcher: config.LauncherTmuxPane}
+		assert.NoError(st, session.Validate())
+
+		session = config.SessionConfig{Launcher: "xterm"}
+		assert.Error(st, session.Validate())
+
+		session = config.SessionConfig{Launcher: config.LauncherTemplate}
+		assert.Error(st, session.Validate())
+
+		session.Template = "kitty @ launch {{.Command"
+		assert.Error(st, session.Validate())
+
+		session.Template = "kitty @ launch --type=tab {{.Command}}"
+		assert.NoError(st, session.Validate())
+
+		badSession := valid
+		badSession.Session.Launcher = "xterm"
+		assert.Error(st, badSession.Validate())
+
+		badSort := valid
+		badSort.Sort.Column = "uptime"
+		assert.Error(st, badSort.Validate())
+
+		sorted := valid
+		sorted.Sort = config.ServerSort{Column: config.SortLastSeen, Descending: true}
+		assert.NoError(st, sorted.Validate())
+	})
+
+	t.Run("returns error for invalid or duplicate actions", func(st *testing.T) {
+		action := config.Action{Key: "v", Label: "vnc", Command: "vncviewer {{.IP}}"}
+
+		withActions := valid
+		withActions.Actions = []config.Action{action}
+		assert.NoError(st, withActions.Validate())
+
+		withActions.Actions = []config.Action{action, action}
+		assert.Error(st, withActions.Validate())
+
+		withActions.Actions = []config.Action{{Key: "v"}}
+		assert.Error(st, withActions.Validate())
+	})
+
+	t.Run("accepts all override target kinds", func(st *testing.T) {
+		ssh := valid.SSH
+
+		ssh.Overrides = []config.SSHOverride{
+			{Target: "192.168.1.2"},
+			{Target: "192.168.1.0/24"},
+			{Target: "aa:bb:cc:dd:ee:ff"},
+			{Target: "web-*"},
+			{Target: "vendor:Raspberry Pi"},
+		}
+
+		assert.NoError(st, ssh.Validate())
+	})
+}
@@ -1,27 +1,39 @@
 package core
 
 import (
+	"context"
 	"errors"
+	"sync"
 
 	"github.com/robgonnella/go-lanscan/pkg/network"
 	"github.com/robgonnella/ops/internal/config"
 	"github.com/robgonnella/ops/internal/discovery"
 	"github.com/robgonnella/ops/internal/event"
+	"github.com/robgonnella/ops/internal/inventory"
 	"github.com/robgonnella/ops/internal/logger"
 )
 
 // ScannerFactory is a function that returns a new instance of a Scanner
 type ScannerFactory func(netInfo network.Network, conf config.Config) (discovery.Scanner, error)
 
+// DetailScannerFactory is a function that returns a new instance of a
+// DetailScanner
+type DetailScannerFactory func(conf config.Config) discovery.DetailScanner
+
 // Core represents our core data structure through which the ui can interact
 // with the backend
 type Core struct {
 	conf                *config.Config
+	confMux             sync.RWMutex
 	networkInfo         network.Network
 	configService       config.Service
+	inventory           inventory.Service
 	discovery           discovery.Service
 	eventManager        event.Manager
 	scannerFactory      ScannerFactory
+	detailFactory       DetailScannerFactory
+	commandRunner       CommandRunner
+	fileTransferer      FileTransferer
 	debug               bool
 	registeredListeners []int
 	log                 logger.Logger
@@ -32,9 +44,13 @@ func New(
 	networkInfo network.Network,
 	conf *config.Config,
 	configService config.Service,
+	inventory inventory.Service,
 	discovery discovery.Service,
 	eventManager event.Manager,
 	scannerFactory ScannerFactory,
+	detailFactory DetailScannerFactory,
+	commandRunner CommandRunner,
+	fileTransferer FileTransferer,
 	debug bool,
 ) *Core {
 	log := logger.New()
@@ -43,9 +59,13 @@ func New(
 		networkInfo:         networkInfo,
 		conf:                conf,
 		configService:       configService,
+		inventory:           inventory,
 		discovery:           discovery,
 		eventManager:        eventManager,
 		scannerFactory:      scannerFactory,
+		detailFactory:       detailFactory,
+		commandRunner:       commandRunner,
+		fileTransferer:      fileTransferer,
 		debug:               debug,
 		registeredListeners: []int{},
 		log:                 log,
@@ -57,16 +77,28 @@ func New(
 // instantiated to continue.
 func (c *Core) Stop() error {
 	c.discovery.Stop()
-	return nil
+	return c.inventory.Flush()
 }
 
 // Conf return the currently loaded configuration
 func (c *Core) Conf() config.Config {
+	c.confMux.RLock()
+	defer c.confMux.RUnlock()
 	return *c.conf
 }
 
+// replaces the currently loaded configuration and its network interface
+func (c *Core) setConf(conf *config.Config, netInfo network.Network) {
+	c.confMux.Lock()
+	defer c.confMux.Unlock()
+	c.conf = conf
+	c.networkInfo = netInfo
+}
+
 // NetworkInfo returns the core's network interface
 func (c *Core) NetworkInfo() network.Network {
+	c.confMux.RLock()
+	defer c.confMux.RUnlock()
 	return c.networkInfo
 }
 
@@ -81,6 +113,26 @@ func (c *Core) CreateConfig(conf config.Config) error {
 	return nil
 }
 
+// SetServerSort saves how servers are sorted in the terminal ui for the
+// current config. Unlike UpdateConfig network scanning is not restarted.
+func (c *Core) SetServerSort(sort config.ServerSort) error {
+	c.confMux.Lock()
+	defer c.confMux.Unlock()
+
+	conf := *c.conf
+	conf.Sort = sort
+
+	updated, err := c.configService.Update(&conf)
+
+	if err != nil {
+		return err
+	}
+
+	c.conf = updated
+
+	return nil
+}
+
 // UpdateConfig updates an existing config
 func (c *Core) UpdateConfig(conf config.Config) error {
 	updated, err := c.configService.Update(&conf)
@@ -89,23 +141,26 @@ func (c *Core) UpdateConfig(conf config.Config) error {
 		return err
 	}
 
-	if updated.ID == c.conf.ID {
+	if updated.ID == c.Conf().ID {
 		netInfo, err := network.NewNetworkFromInterfaceName(updated.Interface)
 
 		if err != nil {
 			return err
 		}
 
-		c.conf = updated
-		c.networkInfo = netInfo
+		c.setConf(updated, netInfo)
 
-		newScanner, err := c.scannerFactory(c.networkInfo, c.Conf())
+		newScanner, err := c.scannerFactory(netInfo, *updated)
 
 		if err != nil {
 			return err
 		}
 
-		c.discovery.SetConfigAndScanner(c.Conf(), newScanner)
+		c.discovery.SetConfigAndScanner(
+			*updated,
+			newScanner,
+			c.detailFactory(*updated),
+		)
 	}
 
 	return nil
@@ -113,7 +168,7 @@ func (c *Core) UpdateConfig(conf config.Config) error {
 
 // SetConfig sets the current active configuration
 func (c *Core) SetConfig(id string) error {
-	if id == c.conf.ID {
+	if id == c.Conf().ID {
 		return nil
 	}
 
@@ -123,30 +178,32 @@ func (c *Core) SetConfig(id string) error {
 		return err
 	}
 
-	c.conf = conf
-
-	netInfo, err := network.NewNetworkFromInterfaceName(c.conf.Interface)
+	netInfo, err := network.NewNetworkFromInterfaceName(conf.Interface)
 
 	if err != nil {
 		return err
 	}
 
-	c.networkInfo = netInfo
+	c.setConf(conf, netInfo)
 
-	newScanner, err := c.scannerFactory(c.networkInfo, c.Conf())
+	newScanner, err := c.scannerFactory(netInfo, *conf)
 
 	if err != nil {
 		return err
 	}
 
-	c.discovery.SetConfigAndScanner(c.Conf(), newScanner)
+	c.discovery.SetConfigAndScanner(
+		*conf,
+		newScanner,
+		c.detailFactory(*conf),
+	)
 
 	return nil
 }
 
 // DeleteConfig deletes a configuration
 func (c *Core) DeleteConfig(id string) error {
-	if id == c.conf.ID {
+	if id == c.Conf().ID {
 		return errors.New("cannot delete current active config")
 	}
 
@@ -160,23 +217,116 @@ func (c *Core) GetConfigs() ([]*config.Config, error) {
 	return c.configService.GetAll()
 }
 
+// Host returns a persisted host for the current active configuration
+func (c *Core) Host(id string) (*inventory.Host, error) {
+	return c.inventory.Get(c.Conf().ID, id)
+}
+
+// Scan triggers an immediate network scan
+func (c *Core) Scan() error {
+	return c.discovery.Scan()
+}
+
+// TrustHostKey acknowledges a changed ssh host key for the given host in
+// the current active configuration
+func (c *Core) TrustHostKey(id string) error {
+	_, err := c.inventory.TrustHostKey(c.Conf().ID, id)
+	return err
+}
+
+// Exec runs a command over ssh on the hosts with the given ids in the
+// current active configuration
+func (c *Core) Exec(
+	ctx context.Context,
+	ids []string,
+	command string,
+	opts ExecOptions,
+) ([]ExecResult, error) {
+	targets, err := c.targets(ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return Exec(ctx, c.commandRunner, targets, command, opts), nil
+}
+
+// Transfer copies a file over sftp to or from the hosts with the given ids
+// in the current active configuration
+func (c *Core) Transfer(
+	ctx context.Context,
+	ids []string,
+	req TransferRequest,
+	opts TransferOptions,
+) ([]TransferResult, error) {
+	targets, err := c.targets(ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return Transfer(ctx, c.fileTransferer, targets, req, opts), nil
+}
+
+// returns targets with resolved ssh credentials for the given host ids
+func (c *Core) targets(ids []string) ([]ExecTarget, error) {
+	hosts := []*inventory.Host{}
+
+	for _, id := range ids {
+		host, err := c.inventory.Get(c.Conf().ID, id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		hosts = append(hosts, host)
+	}
+
+	return ExecTargets(c.Conf(), hosts), nil
+}
+
+// Inventory returns all persisted hosts for the current active configuration
+func (c *Core) Inventory() ([]*inventory.Host, error) {
+	return c.inventory.GetAll(c.Conf().ID)
+}
+
 // Monitor starts the processes for monitoring and tracking
 // devices on the configured network
 func (c *Core) Monitor() error {
+	inventoryChan := make(chan event.Event)
+
+	c.registeredListeners = append(c.registeredListeners,
+		c.eventManager.RegisterListener(discovery.ArpUpdateEvent, inventoryChan),
+		c.eventManager.RegisterListener(discovery.SynUpdateEvent, inventoryChan),
+		c.eventManager.RegisterListener(discovery.HostOfflineEvent, inventoryChan),
+		c.eventManager.RegisterListener(discovery.HostReturnedEvent, inventoryChan),
+		c.eventManager.RegisterListener(discovery.HostnameResolvedEvent, inventoryChan),
+		c.eventManager.RegisterListener(discovery.PortUpdateEvent, inventoryChan),
+		c.eventManager.RegisterListener(discovery.ScanCompletedEvent, inventoryChan),
+	)
+
+	defer func() {
+		for _, id := range c.registeredListeners {
+			c.eventManager.RemoveListener(id)
+		}
+		c.registeredListeners = []int{}
+	}()
+
+	go c.recordInventory(inventoryChan)
+
 	evtChan := make(chan event.Event)
 	if c.debug {
 		c.registeredListeners = append(c.registeredListeners,
 			c.eventManager.RegisterListener(discovery.ArpUpdateEvent, evtChan),
 			c.eventManager.RegisterListener(discovery.SynUpdateEvent, evtChan),
+			c.eventManager.RegisterListener(discovery.HostOfflineEvent, evtChan),
+			c.eventManager.RegisterListener(discovery.HostReturnedEvent, evtChan),
+			c.eventManager.RegisterListener(discovery.HostnameResolvedEvent, evtChan),
+			c.eventManager.RegisterListener(discovery.PortUpdateEvent, evtChan),
+			c.eventManager.RegisterListener(discovery.ScanCompletedEvent, evtChan),
 			c.eventManager.RegisterListener(event.FatalErrorEventType, evtChan),
 		)
 
-		defer func() {
-			for _, id := range c.registeredListeners {
-				c.eventManager.RemoveListener(id)
-			}
-		}()
-
 		go func() {
 			for evt := range evtChan {
 				if err, ok := evt.Payload.(error); ok {
@@ -197,6 +347,17 @@ func (c *Core) Monitor() error {
 
 					c.log.Info().Fields(fields).Msg(result.Type)
 				}
+
+				if cycle, ok := evt.Payload.(discovery.ScanCycle); ok {
+					fields := map[string]interface{}{
+						"startedAt":    cycle.StartedAt,
+						"duration":     cycle.Duration.String(),
+						"hostsFound":   cycle.HostsFound,
+						"hostsOffline": cycle.HostsOffline,
+					}
+
+					c.log.Info().Fields(fields).Msg(string(evt.Type))
+				}
 			}
 		}()
 	}
@@ -204,6 +365,47 @@ func (c *Core) Monitor() error {
 	return c.discovery.MonitorNetwork()
 }
 
+// records every discovery result in the persisted inventory for the
+// configuration that produced it and persists the inventory once each
+// scan cycle completes
+func (c *Core) recordInventory(evtChan chan event.Event) {
+	for evt := range evtChan {
+		if _, ok := evt.Payload.(discovery.ScanCycle); ok {
+			if err := c.inventory.Flush(); err != nil {
+				c.log.Error().Err(err).Msg("failed to persist inventory")
+			}
+			continue
+		}
+
+		result, ok := evt.Payload.(discovery.DiscoveryResult)
+
+		if !ok {
+			continue
+		}
+
+		host, err := c.inventory.Record(result.ConfigID, result)
+
+		if err != nil {
+			c.log.Error().Err(err).Str("id", result.ID).Msg("failed to record host")
+			continue
+		}
+
+		if change, ok := host.NewHostKeyChange(); ok && result.HostKey != nil {
+			c.log.Warn().
+				Str("id", change.ID).
+				Str("ip", change.IP).
+				Str("previous", change.Previous.Fingerprint).
+				Str("current", change.Current.Fingerprint).
+				Msg("ssh host key changed")
+
+			c.eventManager.Send(event.Event{
+				Type:    discovery.HostKeyChangedEvent,
+				Payload: change,
+			})
+		}
+	}
+}
+
 // StartDaemon starts the network monitoring processes in a goroutine
 func (c *Core) StartDaemon() {
 	go func() {
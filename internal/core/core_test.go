@@ -2,6 +2,8 @@ package core_test
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net"
 	"sync"
 	"testing"
@@ -12,9 +14,12 @@ import (
 	"github.com/robgonnella/ops/internal/core"
 	"github.com/robgonnella/ops/internal/discovery"
 	"github.com/robgonnella/ops/internal/event"
+	"github.com/robgonnella/ops/internal/inventory"
 	mock_config "github.com/robgonnella/ops/internal/mock/config"
+	mock_core "github.com/robgonnella/ops/internal/mock/core"
 	mock_discovery "github.com/robgonnella/ops/internal/mock/discovery"
 	mock_event "github.com/robgonnella/ops/internal/mock/event"
+	mock_inventory "github.com/robgonnella/ops/internal/mock/inventory"
 	"github.com/robgonnella/ops/internal/test_util"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
@@ -73,13 +78,23 @@ func TestCore(t *testing.T) {
 
 	mockScanner := mock_discovery.NewMockScanner(ctrl)
 	mockDetailsScanner := mock_discovery.NewMockDetailScanner(ctrl)
+	mockResolver := mock_discovery.NewMockHostnameResolver(ctrl)
+	mockGrabber := mock_discovery.NewMockBannerGrabber(ctrl)
+	mockHostKeyScanner := mock_discovery.NewMockHostKeyScanner(ctrl)
 	mockConfig := mock_config.NewMockService(ctrl)
+	mockInventory := mock_inventory.NewMockService(ctrl)
 	mockEventManager := mock_event.NewMockManager(ctrl)
+	mockRunner := mock_core.NewMockCommandRunner(ctrl)
+	mockTransferer := mock_core.NewMockFileTransferer(ctrl)
 
 	mockScannerFactory := func(netInfo network.Network, conf config.Config) (discovery.Scanner, error) {
 		return mockScanner, nil
 	}
 
+	mockDetailScannerFactory := func(conf config.Config) discovery.DetailScanner {
+		return mockDetailsScanner
+	}
+
 	resultChan := make(chan *scanner.ScanResult)
 
 	mockScanner.EXPECT().Results().Return(resultChan).AnyTimes()
@@ -99,6 +114,9 @@ func TestCore(t *testing.T) {
 		conf,
 		mockScanner,
 		mockDetailsScanner,
+		mockResolver,
+		mockGrabber,
+		mockHostKeyScanner,
 		mockEventManager,
 	)
 
@@ -106,9 +124,13 @@ func TestCore(t *testing.T) {
 		mockNet,
 		&conf,
 		mockConfig,
+		mockInventory,
 		discoveryService,
 		mockEventManager,
 		mockScannerFactory,
+		mockDetailScannerFactory,
+		mockRunner,
+		mockTransferer,
 		false,
 	)
 
@@ -140,6 +162,59 @@ func TestCore(t *testing.T) {
 		assert.Equal(st, coreService.Conf(), newConf)
 	})
 
+	t.Run("saves server sort without restarting scanning", func(st *testing.T) {
+		sort := config.ServerSort{Column: config.SortHostname, Descending: true}
+
+		sorted := conf
+		sorted.Sort = sort
+
+		mockConfig.EXPECT().Update(&sorted).Return(&sorted, nil)
+
+		err := coreService.SetServerSort(sort)
+
+		assert.NoError(st, err)
+		assert.Equal(st, sort, coreService.Conf().Sort)
+
+		mockConfig.EXPECT().Update(&conf).Return(&conf, nil)
+
+		err = coreService.SetServerSort(config.ServerSort{})
+
+		assert.NoError(st, err)
+		assert.Equal(st, conf, coreService.Conf())
+	})
+
+	t.Run("saves server sort while config is read concurrently", func(st *testing.T) {
+		sort := config.ServerSort{Column: config.SortIP}
+
+		sorted := conf
+		sorted.Sort = sort
+
+		mockConfig.EXPECT().Update(&sorted).Return(&sorted, nil)
+		mockConfig.EXPECT().Update(&conf).Return(&conf, nil)
+		mockInventory.EXPECT().GetAll(conf.ID).Return(nil, nil).Times(2)
+
+		wg := sync.WaitGroup{}
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			assert.NoError(st, coreService.SetServerSort(sort))
+			assert.NoError(st, coreService.SetServerSort(config.ServerSort{}))
+		}()
+
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 2; i++ {
+				_, err := coreService.Inventory()
+				assert.NoError(st, err)
+			}
+		}()
+
+		wg.Wait()
+
+		assert.Equal(st, conf, coreService.Conf())
+	})
+
 	t.Run("sets config", func(st *testing.T) {
 		defer coreService.SetConfig(conf.ID)
 
@@ -217,6 +292,239 @@ func TestCore(t *testing.T) {
 		}
 	})
 
+	t.Run("gets inventory for current config", func(st *testing.T) {
+		expectedHosts := []*inventory.Host{{
+			ID:       "00:00:00:00:00:00",
+			ConfigID: conf.ID,
+			IP:       "127.0.0.1",
+		}}
+
+		mockInventory.EXPECT().GetAll(conf.ID).Return(expectedHosts, nil)
+
+		hosts, err := coreService.Inventory()
+
+		assert.NoError(st, err)
+		assert.Equal(st, expectedHosts, hosts)
+	})
+
+	t.Run("gets host for current config", func(st *testing.T) {
+		expected := &inventory.Host{
+			ID:       "00:00:00:00:00:00",
+			ConfigID: conf.ID,
+		}
+
+		mockInventory.EXPECT().Get(conf.ID, expected.ID).Return(expected, nil)
+
+		host, err := coreService.Host(expected.ID)
+
+		assert.NoError(st, err)
+		assert.Equal(st, expected, host)
+	})
+
+	t.Run("trusts host key for current config", func(st *testing.T) {
+		id := "00:00:00:00:00:00"
+
+		mockInventory.EXPECT().
+			TrustHostKey(conf.ID, id).
+			Return(&inventory.Host{ID: id, ConfigID: conf.ID}, nil)
+
+		err := coreService.TrustHostKey(id)
+
+		assert.NoError(st, err)
+	})
+
+	t.Run("execs command on hosts for current config", func(st *testing.T) {
+		host := &inventory.Host{
+			ID:       "00:00:00:00:00:00",
+			ConfigID: conf.ID,
+			IP:       "127.0.0.1",
+			Hostname: "hostname",
+		}
+
+		mockInventory.EXPECT().Get(conf.ID, host.ID).Return(host, nil)
+
+		mockRunner.EXPECT().
+			Run(gomock.Any(), core.ExecTarget{
+				ID:       host.ID,
+				IP:       host.IP,
+				Hostname: host.Hostname,
+				Credentials: config.SSHCredentials{
+					User:     "user",
+					Identity: "identity",
+					Port:     "22",
+				},
+			}, "uptime", gomock.Any(), gomock.Any()).
+			DoAndReturn(func(
+				ctx context.Context,
+				target core.ExecTarget,
+				command string,
+				stdout, stderr io.Writer,
+			) (int, error) {
+				io.WriteString(stdout, "line 1\nline 2")
+				io.WriteString(stderr, "warning\n")
+				return 3, nil
+			})
+
+		mux := sync.Mutex{}
+		lines := []string{}
+
+		results, err := coreService.Exec(
+			context.Background(),
+			[]string{host.ID},
+			"uptime",
+			core.ExecOptions{
+				OnOutput: func(o core.ExecOutput) {
+					mux.Lock()
+					defer mux.Unlock()
+					lines = append(lines, string(o.Stream)+":"+o.Line)
+				},
+			},
+		)
+
+		assert.NoError(st, err)
+		assert.Len(st, results, 1)
+		assert.Equal(st, host.ID, results[0].ID)
+		assert.Equal(st, 3, results[0].ExitCode)
+		assert.Equal(st, "line 1\nline 2", results[0].Stdout)
+		assert.Equal(st, "warning\n", results[0].Stderr)
+		assert.True(st, results[0].Failed())
+		assert.ElementsMatch(
+			st,
+			[]string{"stdout:line 1", "stdout:line 2", "stderr:warning"},
+			lines,
+		)
+	})
+
+	t.Run("pins exec hosts to recorded host key", func(st *testing.T) {
+		host := &inventory.Host{
+			ID:       "00:00:00:00:00:00",
+			ConfigID: conf.ID,
+			IP:       "127.0.0.1",
+			Hostname: "hostname",
+			HostKeys: []inventory.HostKeyRecord{
+				{Type: "ssh-ed25519", Fingerprint: "SHA256:previous"},
+				{Type: "ssh-ed25519", Fingerprint: "SHA256:current"},
+			},
+		}
+
+		mockInventory.EXPECT().Get(conf.ID, host.ID).Return(host, nil)
+
+		mockRunner.EXPECT().
+			Run(gomock.Any(), gomock.Any(), "uptime", gomock.Any(), gomock.Any()).
+			DoAndReturn(func(
+				ctx context.Context,
+				target core.ExecTarget,
+				command string,
+				stdout, stderr io.Writer,
+			) (int, error) {
+				assert.Equal(st, "SHA256:current", target.HostKey)
+				assert.False(st, target.HostKeyChanged)
+				return 0, nil
+			})
+
+		results, err := coreService.Exec(
+			context.Background(),
+			[]string{host.ID},
+			"uptime",
+			core.ExecOptions{},
+		)
+
+		assert.NoError(st, err)
+		assert.Len(st, results, 1)
+		assert.False(st, results[0].Failed())
+	})
+
+	t.Run("refuses to exec on hosts with changed host keys", func(st *testing.T) {
+		host := &inventory.Host{
+			ID:             "00:00:00:00:00:00",
+			ConfigID:       conf.ID,
+			IP:             "127.0.0.1",
+			Hostname:       "hostname",
+			HostKeyChanged: true,
+			HostKeys: []inventory.HostKeyRecord{
+				{Type: "ssh-ed25519", Fingerprint: "SHA256:previous"},
+				{Type: "ssh-ed25519", Fingerprint: "SHA256:current"},
+			},
+		}
+
+		mockInventory.EXPECT().Get(conf.ID, host.ID).Return(host, nil)
+
+		results, err := coreService.Exec(
+			context.Background(),
+			[]string{host.ID},
+			"uptime",
+			core.ExecOptions{},
+		)
+
+		assert.NoError(st, err)
+		assert.Len(st, results, 1)
+		assert.Equal(st, -1, results[0].ExitCode)
+		assert.Equal(st, core.ErrHostKeyChanged.Error(), results[0].Error)
+		assert.True(st, results[0].Failed())
+	})
+
+	t.Run("returns error when exec host is not found", func(st *testing.T) {
+		mockInventory.EXPECT().
+			Get(conf.ID, "unknown").
+			Return(nil, errors.New("not found"))
+
+		_, err := coreService.Exec(
+			context.Background(),
+			[]string{"unknown"},
+			"uptime",
+			core.ExecOptions{},
+		)
+
+		assert.Error(st, err)
+	})
+
+	t.Run("transfers files for hosts in current config", func(st *testing.T) {
+		host := &inventory.Host{
+			ID:       "00:00:00:00:00:00",
+			ConfigID: conf.ID,
+			IP:       "127.0.0.1",
+		}
+
+		mockInventory.EXPECT().Get(conf.ID, host.ID).Return(host, nil)
+
+		mockTransferer.EXPECT().
+			Upload(gomock.Any(), gomock.Any(), "script.sh", "/tmp", gomock.Any()).
+			DoAndReturn(func(
+				ctx context.Context,
+				target core.ExecTarget,
+				localPath, remotePath string,
+				progress func(transferred, total int64),
+			) (string, int64, error) {
+				progress(50, 100)
+				progress(100, 100)
+				return "/tmp/script.sh", 100, nil
+			})
+
+		percents := []int{}
+
+		results, err := coreService.Transfer(
+			context.Background(),
+			[]string{host.ID},
+			core.TransferRequest{
+				Direction:  core.TransferUpload,
+				LocalPath:  "script.sh",
+				RemotePath: "/tmp",
+			},
+			core.TransferOptions{
+				OnProgress: func(p core.TransferProgress) {
+					percents = append(percents, p.Percent())
+				},
+			},
+		)
+
+		assert.NoError(st, err)
+		assert.Len(st, results, 1)
+		assert.Equal(st, "/tmp/script.sh", results[0].Destination)
+		assert.Equal(st, int64(100), results[0].Bytes)
+		assert.False(st, results[0].Failed())
+		assert.Equal(st, []int{50, 100}, percents)
+	})
+
 	t.Run("monitors network", func(st *testing.T) {
 		mac, _ := net.ParseMAC("00:00:00:00:00:00")
 
@@ -235,8 +543,9 @@ func TestCore(t *testing.T) {
 		}}
 
 		details := &discovery.Details{
-			Hostname: "hostname",
-			OS:       "os",
+			Hostname:       "hostname",
+			HostnameSource: discovery.HostnameSourceSSH,
+			OS:             "os",
 		}
 
 		wg := sync.WaitGroup{}
@@ -253,11 +562,11 @@ func TestCore(t *testing.T) {
 		})
 
 		mockDetailsScanner.EXPECT().
-			GetServerDetails(gomock.Any(), "127.0.0.1", conf.SSH.Port).
+			GetServerDetails(gomock.Any(), "127.0.0.1", conf.SSHCredentials(config.SSHTarget{})).
 			DoAndReturn(func(
 				ctx context.Context,
 				ip string,
-				port string,
+				creds config.SSHCredentials,
 			) (*discovery.Details, error) {
 				defer wg.Done()
 				return details, nil
@@ -266,25 +575,109 @@ func TestCore(t *testing.T) {
 		expectedEvent := event.Event{
 			Type: discovery.SynUpdateEvent,
 			Payload: discovery.DiscoveryResult{
-				Type:     discovery.SynUpdateEvent,
-				ID:       mac.String(),
-				Hostname: details.Hostname,
-				IP:       "127.0.0.1",
-				OS:       details.OS,
-				Status:   discovery.ServerOnline,
+				Type:           discovery.SynUpdateEvent,
+				ConfigID:       conf.ID,
+				ID:             mac.String(),
+				Hostname:       details.Hostname,
+				HostnameSource: discovery.HostnameSourceSSH,
+				IP:             "127.0.0.1",
+				OS:             details.OS,
+				Status:         discovery.ServerOnline,
 				Port: discovery.Port{
 					ID:     22,
 					Status: discovery.PortOpen,
 				},
+				OpenPorts: []uint16{22},
+				Banners:   []discovery.Banner{},
 			},
 		}
 
+		mockGrabber.EXPECT().
+			Grab(gomock.Any(), "127.0.0.1", uint16(22)).
+			Return(nil, discovery.ErrNoBanner)
+
+		mockHostKeyScanner.EXPECT().
+			ScanHostKey(gomock.Any(), "127.0.0.1", conf.SSH.Port).
+			Return(nil, errors.New("connection refused"))
+
 		mockEventManager.EXPECT().Send(expectedEvent).DoAndReturn(func(evt event.Event) {
 			wg.Done()
 		})
 
+		var inventoryChan chan event.Event
+
+		mockEventManager.EXPECT().
+			RegisterListener(event.EventType(discovery.ArpUpdateEvent), gomock.Any()).
+			DoAndReturn(func(eventType event.EventType, listener chan event.Event) int {
+				inventoryChan = listener
+				return 1
+			})
+
+		mockEventManager.EXPECT().
+			RegisterListener(event.EventType(discovery.SynUpdateEvent), gomock.Any()).
+			Return(2)
+
+		mockEventManager.EXPECT().
+			RegisterListener(event.EventType(discovery.HostOfflineEvent), gomock.Any()).
+			Return(3)
+
+		mockEventManager.EXPECT().
+			RegisterListener(event.EventType(discovery.HostReturnedEvent), gomock.Any()).
+			Return(4)
+
+		mockEventManager.EXPECT().
+			RegisterListener(event.EventType(discovery.HostnameResolvedEvent), gomock.Any()).
+			Return(5)
+
+		mockEventManager.EXPECT().
+			RegisterListener(event.EventType(discovery.PortUpdateEvent), gomock.Any()).
+			Return(6)
+
+		mockEventManager.EXPECT().
+			RegisterListener(event.EventType(discovery.ScanCompletedEvent), gomock.Any()).
+			Return(7)
+
+		mockEventManager.EXPECT().RemoveListener(gomock.Any()).AnyTimes()
+
+		mockEventManager.EXPECT().
+			Send(gomock.Cond(func(x any) bool {
+				evt := x.(event.Event)
+				return evt.Type == discovery.ScanStartedEvent ||
+					evt.Type == discovery.ScanCompletedEvent
+			})).
+			AnyTimes()
+
 		go coreService.Monitor()
 
 		wg.Wait()
+
+		// results are recorded under the config of the scan that produced
+		// them and the inventory is persisted once the scan completes
+		previous := discovery.DiscoveryResult{
+			Type:     discovery.ArpUpdateEvent,
+			ConfigID: "previous",
+			ID:       mac.String(),
+			IP:       "127.0.0.1",
+		}
+
+		recorded := &inventory.Host{ConfigID: previous.ConfigID, ID: previous.ID}
+
+		flushed := make(chan struct{})
+
+		gomock.InOrder(
+			mockInventory.EXPECT().Record(previous.ConfigID, previous).Return(recorded, nil),
+			mockInventory.EXPECT().Flush().DoAndReturn(func() error {
+				close(flushed)
+				return nil
+			}),
+		)
+
+		inventoryChan <- event.Event{Type: discovery.ArpUpdateEvent, Payload: previous}
+		inventoryChan <- event.Event{
+			Type:    discovery.ScanCompletedEvent,
+			Payload: discovery.ScanCycle{ConfigID: conf.ID},
+		}
+
+		<-flushed
 	})
 }
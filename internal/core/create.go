@@ -2,7 +2,8 @@ package core
 
 import (
 	"errors"
-	"slices"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,6 +14,7 @@ import (
 	"github.com/robgonnella/ops/internal/discovery"
 	"github.com/robgonnella/ops/internal/event"
 	"github.com/robgonnella/ops/internal/exception"
+	"github.com/robgonnella/ops/internal/inventory"
 
 	"github.com/goombaio/namegenerator"
 	"github.com/spf13/viper"
@@ -20,13 +22,68 @@ import (
 
 // CreateNewAppCore creates and returns a new instance of *core.Core
 func CreateNewAppCore(networkInfo network.Network, eventManager event.Manager, debug bool) (*Core, error) {
-	configPath := viper.Get("config-path").(string)
+	configService, conf, err := loadConfig(networkInfo)
+
+	if err != nil {
+		return nil, err
+	}
+
+	inventoryService, err := CreateInventoryService()
+
+	if err != nil {
+		return nil, err
+	}
+
+	scannerService, err := createScannerService(networkInfo, *conf, eventManager)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return New(
+		networkInfo,
+		conf,
+		configService,
+		inventoryService,
+		scannerService,
+		eventManager,
+		createScanner,
+		createDetailScanner,
+		CreateCommandRunner(),
+		CreateFileTransferer(),
+		debug,
+	), nil
+}
+
+// CreateScannerService creates and returns a standalone discovery service
+// for the config associated with the given network's interface
+func CreateScannerService(
+	networkInfo network.Network,
+	eventManager event.Manager,
+) (*discovery.ScannerService, *config.Config, error) {
+	_, conf, err := loadConfig(networkInfo)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scannerService, err := createScannerService(networkInfo, *conf, eventManager)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return scannerService, conf, nil
+}
+
+// DefaultConfig returns the default config for the given interface
+func DefaultConfig(ifaceName string) config.Config {
 	user := viper.Get("user").(string)
 	identity := viper.Get("default-ssh-identity").(string)
 	seed := time.Now().UTC().UnixNano()
 	nameGenerator := namegenerator.NewNameGenerator(seed)
 
-	defaultConf := config.Config{
+	return config.Config{
 		ID:   uuid.New().String(),
 		Name: nameGenerator.Generate(),
 		SSH: config.SSHConfig{
@@ -35,53 +92,93 @@ func CreateNewAppCore(networkInfo network.Network, eventManager event.Manager, d
 			Port:      "22",
 			Overrides: []config.SSHOverride{},
 		},
-		Interface: networkInfo.Interface().Name,
+		Scan: config.ScanConfig{
+			Interval:     config.DefaultScanInterval.String(),
+			Timeout:      config.DefaultScanTimeout.String(),
+			ListenPort:   strconv.Itoa(int(config.DefaultScanListenPort)),
+			OfflineAfter: config.DefaultOfflineAfter,
+			ServicePorts: config.DefaultServicePorts,
+		},
+		Interface: ifaceName,
 	}
+}
 
-	configRepo, err := config.NewJSONRepo(configPath, defaultConf)
+// CreateConfigService creates and returns a config service for the config
+// file, creating the file with a default config for the given interface if
+// it does not exist
+func CreateConfigService(ifaceName string) (*config.ConfigService, error) {
+	configPath := viper.Get("config-path").(string)
+
+	configRepo, err := config.NewJSONRepo(configPath, DefaultConfig(ifaceName))
 
 	if err != nil {
 		return nil, err
 	}
 
-	configService := config.NewConfigService(configRepo)
+	return config.NewConfigService(configRepo), nil
+}
+
+// CreateInventoryService creates and returns an inventory service for the
+// inventory file
+func CreateInventoryService() (*inventory.InventoryService, error) {
+	inventoryPath := viper.Get("inventory-path").(string)
 
-	conf, err := configService.GetByInterface(networkInfo.Interface().Name)
+	inventoryRepo, err := inventory.NewJSONRepo(inventoryPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return inventory.NewInventoryService(inventoryRepo), nil
+}
+
+// returns the config service and the config for the given network's
+// interface, creating a default config if one does not exist
+func loadConfig(networkInfo network.Network) (*config.ConfigService, *config.Config, error) {
+	ifaceName := networkInfo.Interface().Name
+
+	configService, err := CreateConfigService(ifaceName)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conf, err := configService.GetByInterface(ifaceName)
 
 	if err != nil {
 		if errors.Is(err, exception.ErrRecordNotFound) {
+			defaultConf := DefaultConfig(ifaceName)
 			conf, err = configService.Create(&defaultConf)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 		} else {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
-	netScanner, err := createScanner(networkInfo, *conf)
+	return configService, conf, nil
+}
+
+func createScannerService(
+	networkInfo network.Network,
+	conf config.Config,
+	eventManager event.Manager,
+) (*discovery.ScannerService, error) {
+	netScanner, err := createScanner(networkInfo, conf)
 
 	if err != nil {
 		return nil, err
 	}
 
-	detailScanner := discovery.NewUnameScanner(*conf)
-
-	scannerService := discovery.NewScannerService(
-		*conf,
-		netScanner,
-		detailScanner,
-		eventManager,
-	)
-
-	return New(
-		networkInfo,
+	return discovery.NewScannerService(
 		conf,
-		configService,
-		scannerService,
+		netScanner,
+		createDetailScanner(conf),
+		hostnameResolver(),
+		discovery.NewBannerGrabber(),
+		discovery.NewHostKeyScanner(discovery.DefaultHostKeyTimeout),
 		eventManager,
-		createScanner,
-		debug,
 	), nil
 }
 
@@ -92,20 +189,57 @@ func createScanner(netInfo network.Network, conf config.Config) (discovery.Scann
 		return nil, err
 	}
 
-	ports := []string{conf.SSH.Port}
-
-	for _, c := range conf.SSH.Overrides {
-		if idx := slices.Index(ports, c.Port); idx == -1 {
-			ports = append(ports, c.Port)
-		}
-	}
-
 	return scanner.NewFullScanner(
 		netInfo,
 		[]string{},
-		ports,
-		54321,
+		conf.ScanPorts(),
+		conf.Scan.GetListenPort(),
 		scanner.WithVendorInfo(vendorRepo),
+		scanner.WithIdleTimeout(conf.Scan.GetTimeout()),
 	), nil
 
 }
+
+func createDetailScanner(conf config.Config) discovery.DetailScanner {
+	mode := discovery.ChainSequential
+
+	if conf.Scan.ConcurrentDetails {
+		mode = discovery.ChainConcurrent
+	}
+
+	chain := discovery.NewDetailChain(mode)
+
+	var sshScanner discovery.DetailScanner = discovery.NewUnameScanner()
+
+	if conf.SSH.Native {
+		sshScanner = discovery.NewNativeScanner(passphrase())
+	}
+
+	chain.Register("ssh", 100, sshScanner)
+	chain.Register("resolver", 0, discovery.NewResolverScanner(hostnameResolver()))
+
+	return chain
+}
+
+// shared so hostnames resolved for arp results are cached for detail scans
+var hostnameResolver = sync.OnceValue(func() *discovery.NetworkResolver {
+	return discovery.NewHostnameResolver()
+})
+
+// CreateCommandRunner creates and returns a runner for executing commands
+// on remote hosts over ssh
+func CreateCommandRunner() CommandRunner {
+	return NewSSHCommandRunner(passphrase())
+}
+
+// CreateFileTransferer creates and returns a transferer for copying files
+// to and from remote hosts over sftp
+func CreateFileTransferer() FileTransferer {
+	return NewSSHFileTransferer(passphrase())
+}
+
+// returns the passphrase used to decrypt encrypted identity files
+func passphrase() string {
+	passphrase, _ := viper.Get("ssh-key-passphrase").(string)
+	return passphrase
+}
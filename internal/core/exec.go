@@ -0,0 +1,343 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robgonnella/ops/internal/config"
+	"github.com/robgonnella/ops/internal/inventory"
+	"github.com/robgonnella/ops/internal/sshclient"
+)
+
+// DefaultExecWorkers default number of hosts a command is run on at once
+const DefaultExecWorkers = 10
+
+// ErrHostKeyChanged returned for hosts whose ssh host key changed and has
+// not been trusted yet
+var ErrHostKeyChanged = errors.New(
+	"host key changed - trust the new key before connecting",
+)
+
+// ExecStream identifies the stream a line of output was written to
+type ExecStream string
+
+const (
+	// ExecStdout output written to stdout
+	ExecStdout ExecStream = "stdout"
+	// ExecStderr output written to stderr
+	ExecStderr ExecStream = "stderr"
+)
+
+// ExecTarget represents a host a command is run on or files are
+// transferred to and from
+type ExecTarget struct {
+	ID          string
+	IP          string
+	Hostname    string
+	Credentials config.SSHCredentials
+	// HostKey fingerprint of the host key recorded in the inventory which
+	// the host must present when connecting
+	HostKey string
+	// HostKeyChanged true if the recorded host key changed and has not
+	// been trusted - connecting to the host is refused
+	HostKeyChanged bool
+}
+
+// ExecOutput represents a single line of output from a host
+type ExecOutput struct {
+	Target ExecTarget
+	Stream ExecStream
+	Line   string
+}
+
+// ExecResult represents the result of running a command on a single host
+type ExecResult struct {
+	ID        string        `json:"id"`
+	IP        string        `json:"ip"`
+	Hostname  string        `json:"hostname"`
+	ExitCode  int           `json:"exitCode"`
+	Error     string        `json:"error,omitempty"`
+	Stdout    string        `json:"stdout"`
+	Stderr    string        `json:"stderr"`
+	StartedAt time.Time     `json:"startedAt"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// Failed returns true if the command could not be run or exited non-zero
+func (r ExecResult) Failed() bool {
+	return r.Error != "" || r.ExitCode != 0
+}
+
+// ExecOptions represents the options used when running a command on
+// many hosts
+type ExecOptions struct {
+	// Workers maximum number of hosts the command is run on at once
+	Workers int
+	// Timeout maximum time allowed for each host including connecting
+	Timeout time.Duration
+	// OnOutput called with each line of output as it is produced
+	OnOutput func(ExecOutput)
+}
+
+// ExecTargets returns targets for the given hosts using the config's ssh
+// settings for each host
+func ExecTargets(conf config.Config, hosts []*inventory.Host) []ExecTarget {
+	targets := make([]ExecTarget, 0, len(hosts))
+
+	for _, h := range hosts {
+		target := ExecTarget{
+			ID:             h.ID,
+			IP:             h.IP,
+			Hostname:       h.Hostname,
+			Credentials:    conf.SSHCredentials(h.Result().SSHTarget()),
+			HostKeyChanged: h.HostKeyChanged,
+		}
+
+		if count := len(h.HostKeys); count > 0 {
+			target.HostKey = h.HostKeys[count-1].Fingerprint
+		}
+
+		targets = append(targets, target)
+	}
+
+	return targets
+}
+
+// Exec runs a command on all targets concurrently limited to the number of
+// workers in options and returns the results in the same order as targets
+func Exec(
+	ctx context.Context,
+	runner CommandRunner,
+	targets []ExecTarget,
+	command string,
+	opts ExecOptions,
+) []ExecResult {
+	workers := opts.Workers
+
+	if workers <= 0 {
+		workers = DefaultExecWorkers
+	}
+
+	results := make([]ExecResult, len(targets))
+	sem := make(chan struct{}, workers)
+	wg := sync.WaitGroup{}
+
+	for i, target := range targets {
+		i, target := i, target
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i] = ExecResult{
+					ID:       target.ID,
+					IP:       target.IP,
+					Hostname: target.Hostname,
+					ExitCode: -1,
+					Error:    ctx.Err().Error(),
+				}
+				return
+			}
+
+			results[i] = execTarget(ctx, runner, target, command, opts)
+		}()
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// runs the command on a single target
+func execTarget(
+	ctx context.Context,
+	runner CommandRunner,
+	target ExecTarget,
+	command string,
+	opts ExecOptions,
+) ExecResult {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	if target.HostKeyChanged {
+		return ExecResult{
+			ID:       target.ID,
+			IP:       target.IP,
+			Hostname: target.Hostname,
+			ExitCode: -1,
+			Error:    ErrHostKeyChanged.Error(),
+		}
+	}
+
+	stdout := newLineWriter(target, ExecStdout, opts.OnOutput)
+	stderr := newLineWriter(target, ExecStderr, opts.OnOutput)
+
+	startedAt := time.Now()
+
+	code, err := runner.Run(ctx, target, command, stdout, stderr)
+
+	stdout.flush()
+	stderr.flush()
+
+	result := ExecResult{
+		ID:        target.ID,
+		IP:        target.IP,
+		Hostname:  target.Hostname,
+		ExitCode:  code,
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+		StartedAt: startedAt,
+		Duration:  time.Since(startedAt),
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// lineWriter buffers all output and reports each complete line
+type lineWriter struct {
+	target   ExecTarget
+	stream   ExecStream
+	onOutput func(ExecOutput)
+	all      bytes.Buffer
+	partial  bytes.Buffer
+	mux      sync.Mutex
+}
+
+// returns a new instance of lineWriter
+func newLineWriter(target ExecTarget, stream ExecStream, onOutput func(ExecOutput)) *lineWriter {
+	return &lineWriter{target: target, stream: stream, onOutput: onOutput}
+}
+
+// Write implements io.Writer
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	w.all.Write(p)
+	w.partial.Write(p)
+
+	for {
+		line, err := w.partial.ReadString('\n')
+
+		if err != nil {
+			// incomplete line - keep it until more output arrives
+			w.partial.Reset()
+			w.partial.WriteString(line)
+			break
+		}
+
+		w.emit(strings.TrimSuffix(line, "\n"))
+	}
+
+	return len(p), nil
+}
+
+// String returns all output written so far
+func (w *lineWriter) String() string {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	return w.all.String()
+}
+
+// reports any remaining output that did not end in a newline
+func (w *lineWriter) flush() {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	if w.partial.Len() > 0 {
+		w.emit(w.partial.String())
+		w.partial.Reset()
+	}
+}
+
+func (w *lineWriter) emit(line string) {
+	if w.onOutput == nil {
+		return
+	}
+
+	w.onOutput(ExecOutput{Target: w.target, Stream: w.stream, Line: line})
+}
+
+// SSHCommandRunner is an implementation of the CommandRunner interface
+// that uses an in-process ssh client
+type SSHCommandRunner struct {
+	passphrase string
+}
+
+// NewSSHCommandRunner returns a new instance of SSHCommandRunner. The
+// passphrase is used to decrypt encrypted identity files not loaded in
+// ssh-agent.
+func NewSSHCommandRunner(passphrase string) *SSHCommandRunner {
+	return &SSHCommandRunner{passphrase: passphrase}
+}
+
+// Run connects to the target and runs the command streaming its output
+func (r *SSHCommandRunner) Run(
+	ctx context.Context,
+	target ExecTarget,
+	command string,
+	stdout, stderr io.Writer,
+) (int, error) {
+	client, err := dialTarget(ctx, target, r.passphrase)
+
+	if err != nil {
+		return -1, err
+	}
+
+	defer client.Close()
+
+	return client.Stream(ctx, command, stdout, stderr)
+}
+
+// connects to the target using its resolved credentials, tunneling through
+// any jump hosts. The target must present its recorded host key.
+func dialTarget(ctx context.Context, target ExecTarget, passphrase string) (*sshclient.Client, error) {
+	if target.HostKeyChanged {
+		return nil, ErrHostKeyChanged
+	}
+
+	jumps, err := target.Credentials.Jumps()
+
+	if err != nil {
+		return nil, err
+	}
+
+	opts := sshclient.Options{
+		User:       target.Credentials.User,
+		Identity:   target.Credentials.Identity,
+		Port:       target.Credentials.Port,
+		Passphrase: passphrase,
+		HostKey:    target.HostKey,
+	}
+
+	for _, j := range jumps {
+		opts.Jumps = append(opts.Jumps, sshclient.Jump(j))
+	}
+
+	// allow time for each jump host to connect
+	dialCtx, cancel := context.WithTimeout(
+		ctx,
+		sshclient.DefaultConnectTimeout*time.Duration(len(jumps)+1),
+	)
+
+	defer cancel()
+
+	return sshclient.Dial(dialCtx, target.IP, opts)
+}
@@ -0,0 +1,105 @@
+package core_test
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/robgonnella/ops/internal/core"
+	mock_core "github.com/robgonnella/ops/internal/mock/core"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestExec(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	targets := []core.ExecTarget{
+		{ID: "1", IP: "10.0.0.1"},
+		{ID: "2", IP: "10.0.0.2"},
+		{ID: "3", IP: "10.0.0.3"},
+		{ID: "4", IP: "10.0.0.4"},
+	}
+
+	t.Run("limits workers and preserves target order", func(st *testing.T) {
+		mockRunner := mock_core.NewMockCommandRunner(ctrl)
+
+		running := atomic.Int32{}
+		maxRunning := atomic.Int32{}
+
+		mockRunner.EXPECT().
+			Run(gomock.Any(), gomock.Any(), "hostname", gomock.Any(), gomock.Any()).
+			DoAndReturn(func(
+				ctx context.Context,
+				target core.ExecTarget,
+				command string,
+				stdout, stderr io.Writer,
+			) (int, error) {
+				current := running.Add(1)
+				defer running.Add(-1)
+
+				for {
+					max := maxRunning.Load()
+					if current <= max || maxRunning.CompareAndSwap(max, current) {
+						break
+					}
+				}
+
+				time.Sleep(10 * time.Millisecond)
+				io.WriteString(stdout, target.IP+"\n")
+
+				return 0, nil
+			}).
+			Times(len(targets))
+
+		results := core.Exec(
+			context.Background(),
+			mockRunner,
+			targets,
+			"hostname",
+			core.ExecOptions{Workers: 2},
+		)
+
+		assert.Len(st, results, len(targets))
+		assert.LessOrEqual(st, maxRunning.Load(), int32(2))
+
+		for i, r := range results {
+			assert.Equal(st, targets[i].ID, r.ID)
+			assert.Equal(st, targets[i].IP+"\n", r.Stdout)
+			assert.False(st, r.Failed())
+		}
+	})
+
+	t.Run("reports timeouts as errors", func(st *testing.T) {
+		mockRunner := mock_core.NewMockCommandRunner(ctrl)
+
+		mockRunner.EXPECT().
+			Run(gomock.Any(), gomock.Any(), "sleep 10", gomock.Any(), gomock.Any()).
+			DoAndReturn(func(
+				ctx context.Context,
+				target core.ExecTarget,
+				command string,
+				stdout, stderr io.Writer,
+			) (int, error) {
+				<-ctx.Done()
+				return -1, ctx.Err()
+			})
+
+		results := core.Exec(
+			context.Background(),
+			mockRunner,
+			targets[:1],
+			"sleep 10",
+			core.ExecOptions{Timeout: 10 * time.Millisecond},
+		)
+
+		assert.Len(st, results, 1)
+		assert.Equal(st, -1, results[0].ExitCode)
+		assert.Equal(st, context.DeadlineExceeded.Error(), results[0].Error)
+		assert.True(st, results[0].Failed())
+	})
+}
@@ -0,0 +1,21 @@
+package core
+
+import (
+	"context"
+	"io"
+)
+
+//go:generate mockgen -destination=../mock/core/mock_core.go -package=mock_core . CommandRunner,FileTransferer
+
+// CommandRunner interface for running a command on a single remote host
+type CommandRunner interface {
+	Run(ctx context.Context, target ExecTarget, command string, stdout, stderr io.Writer) (int, error)
+}
+
+// FileTransferer interface for copying files to and from a single remote
+// host. Both methods return the destination path and number of bytes
+// copied.
+type FileTransferer interface {
+	Upload(ctx context.Context, target ExecTarget, localPath, remotePath string, progress func(transferred, total int64)) (string, int64, error)
+	Download(ctx context.Context, target ExecTarget, remotePath, localPath string, progress func(transferred, total int64)) (string, int64, error)
+}
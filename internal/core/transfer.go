@@ -0,0 +1,252 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// TransferDirection represents the direction files are copied
+type TransferDirection string
+
+const (
+	// TransferUpload copies a local file to remote hosts
+	TransferUpload TransferDirection = "upload"
+	// TransferDownload copies a file from remote hosts to the local machine
+	TransferDownload TransferDirection = "download"
+)
+
+// TransferRequest represents a file to copy to or from many hosts
+type TransferRequest struct {
+	Direction  TransferDirection
+	LocalPath  string
+	RemotePath string
+}
+
+// TransferProgress represents the progress of a transfer for a single host
+type TransferProgress struct {
+	Target      ExecTarget
+	Transferred int64
+	Total       int64
+}
+
+// Percent returns the percentage of the file transferred
+func (p TransferProgress) Percent() int {
+	if p.Total <= 0 {
+		return 100
+	}
+
+	return int(p.Transferred * 100 / p.Total)
+}
+
+// TransferResult represents the result of copying a file for a single host
+type TransferResult struct {
+	ID          string        `json:"id"`
+	IP          string        `json:"ip"`
+	Hostname    string        `json:"hostname"`
+	Destination string        `json:"destination,omitempty"`
+	Bytes       int64         `json:"bytes"`
+	Error       string        `json:"error,omitempty"`
+	StartedAt   time.Time     `json:"startedAt"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// Failed returns true if the file could not be copied
+func (r TransferResult) Failed() bool {
+	return r.Error != ""
+}
+
+// TransferOptions represents the options used when copying files to or
+// from many hosts
+type TransferOptions struct {
+	// Workers maximum number of hosts files are copied for at once
+	Workers int
+	// Timeout maximum time allowed for each host including connecting
+	Timeout time.Duration
+	// OnProgress called each time a host's transfer progresses by at least
+	// one percent
+	OnProgress func(TransferProgress)
+}
+
+// Transfer copies a file to or from all targets concurrently limited to the
+// number of workers in options and returns the results in the same order
+// as targets. When downloading from more than one host each file is saved
+// in a directory named after the host's ip under the local path.
+func Transfer(
+	ctx context.Context,
+	transferer FileTransferer,
+	targets []ExecTarget,
+	req TransferRequest,
+	opts TransferOptions,
+) []TransferResult {
+	workers := opts.Workers
+
+	if workers <= 0 {
+		workers = DefaultExecWorkers
+	}
+
+	results := make([]TransferResult, len(targets))
+	sem := make(chan struct{}, workers)
+	wg := sync.WaitGroup{}
+
+	for i, target := range targets {
+		i, target := i, target
+
+		targetReq := req
+
+		if req.Direction == TransferDownload && len(targets) > 1 {
+			targetReq.LocalPath = filepath.Join(req.LocalPath, target.IP) +
+				string(os.PathSeparator)
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i] = TransferResult{
+					ID:       target.ID,
+					IP:       target.IP,
+					Hostname: target.Hostname,
+					Error:    ctx.Err().Error(),
+				}
+				return
+			}
+
+			results[i] = transferTarget(ctx, transferer, target, targetReq, opts)
+		}()
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// copies the file for a single target
+func transferTarget(
+	ctx context.Context,
+	transferer FileTransferer,
+	target ExecTarget,
+	req TransferRequest,
+	opts TransferOptions,
+) TransferResult {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	if target.HostKeyChanged {
+		return TransferResult{
+			ID:       target.ID,
+			IP:       target.IP,
+			Hostname: target.Hostname,
+			Error:    ErrHostKeyChanged.Error(),
+		}
+	}
+
+	lastPercent := -1
+
+	progress := func(transferred, total int64) {
+		if opts.OnProgress == nil {
+			return
+		}
+
+		p := TransferProgress{
+			Target:      target,
+			Transferred: transferred,
+			Total:       total,
+		}
+
+		// only report meaningful changes to avoid flooding callers
+		if percent := p.Percent(); percent > lastPercent {
+			lastPercent = percent
+			opts.OnProgress(p)
+		}
+	}
+
+	startedAt := time.Now()
+
+	var dest string
+	var n int64
+	var err error
+
+	if req.Direction == TransferDownload {
+		dest, n, err = transferer.Download(ctx, target, req.RemotePath, req.LocalPath, progress)
+	} else {
+		dest, n, err = transferer.Upload(ctx, target, req.LocalPath, req.RemotePath, progress)
+	}
+
+	result := TransferResult{
+		ID:          target.ID,
+		IP:          target.IP,
+		Hostname:    target.Hostname,
+		Destination: dest,
+		Bytes:       n,
+		StartedAt:   startedAt,
+		Duration:    time.Since(startedAt),
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// SSHFileTransferer is an implementation of the FileTransferer interface
+// that uses sftp over an in-process ssh client
+type SSHFileTransferer struct {
+	passphrase string
+}
+
+// NewSSHFileTransferer returns a new instance of SSHFileTransferer. The
+// passphrase is used to decrypt encrypted identity files not loaded in
+// ssh-agent.
+func NewSSHFileTransferer(passphrase string) *SSHFileTransferer {
+	return &SSHFileTransferer{passphrase: passphrase}
+}
+
+// Upload connects to the target and copies the local file to it
+func (t *SSHFileTransferer) Upload(
+	ctx context.Context,
+	target ExecTarget,
+	localPath,
+	remotePath string,
+	progress func(transferred, total int64),
+) (string, int64, error) {
+	client, err := dialTarget(ctx, target, t.passphrase)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	defer client.Close()
+
+	return client.Upload(ctx, localPath, remotePath, progress)
+}
+
+// Download connects to the target and copies the remote file from it
+func (t *SSHFileTransferer) Download(
+	ctx context.Context,
+	target ExecTarget,
+	remotePath,
+	localPath string,
+	progress func(transferred, total int64),
+) (string, int64, error) {
+	client, err := dialTarget(ctx, target, t.passphrase)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	defer client.Close()
+
+	return client.Download(ctx, remotePath, localPath, progress)
+}
@@ -0,0 +1,92 @@
+package core_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robgonnella/ops/internal/core"
+	mock_core "github.com/robgonnella/ops/internal/mock/core"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTransfer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	targets := []core.ExecTarget{
+		{ID: "1", IP: "10.0.0.1"},
+		{ID: "2", IP: "10.0.0.2"},
+	}
+
+	t.Run("downloads from many hosts into per host directories", func(st *testing.T) {
+		mockTransferer := mock_core.NewMockFileTransferer(ctrl)
+
+		for _, target := range targets {
+			mockTransferer.EXPECT().
+				Download(
+					gomock.Any(),
+					target,
+					"/var/log/syslog",
+					filepath.Join("logs", target.IP)+string(os.PathSeparator),
+					gomock.Any(),
+				).
+				Return(filepath.Join("logs", target.IP, "syslog"), int64(10), nil)
+		}
+
+		results := core.Transfer(
+			context.Background(),
+			mockTransferer,
+			targets,
+			core.TransferRequest{
+				Direction:  core.TransferDownload,
+				LocalPath:  "logs",
+				RemotePath: "/var/log/syslog",
+			},
+			core.TransferOptions{},
+		)
+
+		assert.Len(st, results, 2)
+
+		for i, r := range results {
+			assert.Equal(st, filepath.Join("logs", targets[i].IP, "syslog"), r.Destination)
+			assert.False(st, r.Failed())
+		}
+	})
+
+	t.Run("refuses hosts with changed host keys", func(st *testing.T) {
+		mockTransferer := mock_core.NewMockFileTransferer(ctrl)
+
+		changed := core.ExecTarget{ID: "3", IP: "10.0.0.3", HostKeyChanged: true}
+
+		mockTransferer.EXPECT().
+			Upload(gomock.Any(), targets[0], "app.conf", "/etc/app.conf", gomock.Any()).
+			Return("/etc/app.conf", int64(10), nil)
+
+		results := core.Transfer(
+			context.Background(),
+			mockTransferer,
+			[]core.ExecTarget{targets[0], changed},
+			core.TransferRequest{
+				Direction:  core.TransferUpload,
+				LocalPath:  "app.conf",
+				RemotePath: "/etc/app.conf",
+			},
+			core.TransferOptions{},
+		)
+
+		assert.Len(st, results, 2)
+		assert.False(st, results[0].Failed())
+		assert.Equal(st, changed.ID, results[1].ID)
+		assert.Equal(st, core.ErrHostKeyChanged.Error(), results[1].Error)
+		assert.True(st, results[1].Failed())
+	})
+
+	t.Run("reports percent progress", func(st *testing.T) {
+		assert.Equal(st, 50, core.TransferProgress{Transferred: 5, Total: 10}.Percent())
+		assert.Equal(st, 100, core.TransferProgress{}.Percent())
+	})
+}
@@ -0,0 +1,411 @@
+package discovery
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// BannerProtocolSSH banner read from an ssh identification string
+	BannerProtocolSSH = "ssh"
+	// BannerProtocolHTTP banner read from a plain http response
+	BannerProtocolHTTP = "http"
+	// BannerProtocolHTTPS banner read from an http response over tls
+	BannerProtocolHTTPS = "https"
+	// BannerProtocolTLS banner containing only tls certificate info
+	BannerProtocolTLS = "tls"
+	// BannerProtocolRaw banner read from the first line sent by a service
+	BannerProtocolRaw = "raw"
+)
+
+const (
+	// DefaultBannerTimeout maximum time to spend grabbing a single banner
+	DefaultBannerTimeout = time.Second * 3
+	// DefaultBannerWait time to wait for a service to send a greeting
+	DefaultBannerWait = time.Millisecond * 500
+	// maximum number of bytes read from any service
+	maxBannerRead = 64 * 1024
+)
+
+// DefaultTLSPorts ports on which a tls handshake is attempted before
+// reading banners
+var DefaultTLSPorts = []uint16{443, 465, 636, 993, 995, 8443}
+
+// DefaultHTTPPorts ports probed with an http request. Other ports are only
+// identified by a greeting so nothing is written to unknown services.
+var DefaultHTTPPorts = []uint16{80, 81, 591, 3000, 5000, 8000, 8008, 8080, 8081, 8088, 8888}
+
+// DefaultPrinterPorts ports that are never connected to since printers may
+// print anything written to them or print a blank page for empty jobs -
+// jetdirect, lpd, and ipp
+var DefaultPrinterPorts = []uint16{515, 631, 9100}
+
+// ErrNoBanner returned when a service did not identify itself
+var ErrNoBanner = errors.New("no banner found")
+
+var titleRegexp = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// Banner represents identifying information reported by a service
+type Banner struct {
+	Port       uint16 `json:"port"`
+	Protocol   string `json:"protocol"`
+	Product    string `json:"product"`
+	Title      string `json:"title,omitempty"`
+	TLSSubject string `json:"tlsSubject,omitempty"`
+}
+
+// NetworkBannerGrabber is an implementation of the BannerGrabber interface
+// that connects directly to services to read their banners
+type NetworkBannerGrabber struct {
+	timeout      time.Duration
+	wait         time.Duration
+	tlsPorts     []uint16
+	httpPorts    []uint16
+	printerPorts []uint16
+}
+
+// BannerOption allows customizing a NetworkBannerGrabber
+type BannerOption func(g *NetworkBannerGrabber)
+
+// WithBannerTimeout sets the maximum time to spend grabbing a single banner
+func WithBannerTimeout(timeout time.Duration) BannerOption {
+	return func(g *NetworkBannerGrabber) {
+		g.timeout = timeout
+	}
+}
+
+// WithBannerWait sets how long to wait for a service greeting
+func WithBannerWait(wait time.Duration) BannerOption {
+	return func(g *NetworkBannerGrabber) {
+		g.wait = wait
+	}
+}
+
+// WithTLSPorts sets the ports on which a tls handshake is attempted
+func WithTLSPorts(ports ...uint16) BannerOption {
+	return func(g *NetworkBannerGrabber) {
+		g.tlsPorts = ports
+	}
+}
+
+// WithHTTPPorts sets the ports probed with an http request
+func WithHTTPPorts(ports ...uint16) BannerOption {
+	return func(g *NetworkBannerGrabber) {
+		g.httpPorts = ports
+	}
+}
+
+// WithPrinterPorts sets the ports that are never connected to
+func WithPrinterPorts(ports ...uint16) BannerOption {
+	return func(g *NetworkBannerGrabber) {
+		g.printerPorts = ports
+	}
+}
+
+// NewBannerGrabber returns a new instance of NetworkBannerGrabber
+func NewBannerGrabber(opts ...BannerOption) *NetworkBannerGrabber {
+	g := &NetworkBannerGrabber{
+		timeout:      DefaultBannerTimeout,
+		wait:         DefaultBannerWait,
+		tlsPorts:     DefaultTLSPorts,
+		httpPorts:    DefaultHTTPPorts,
+		printerPorts: DefaultPrinterPorts,
+	}
+
+	for _, o := range opts {
+		o(g)
+	}
+
+	return g
+}
+
+// Grab connects to the given port and returns the banner reported by the
+// service. Known http ports are probed with an http request, other services
+// are only identified if they greet clients (ssh, ftp, smtp). Printer ports
+// are never connected to.
+func (g *NetworkBannerGrabber) Grab(ctx context.Context, ip string, port uint16) (*Banner, error) {
+	if slices.Contains(g.printerPorts, port) {
+		return nil, ErrNoBanner
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(ip, strconv.Itoa(int(port)))
+
+	banner := &Banner{Port: port}
+
+	var err error
+
+	switch {
+	case slices.Contains(g.tlsPorts, port):
+		err = g.grabTLS(ctx, addr, banner)
+	case slices.Contains(g.httpPorts, port):
+		err = g.grabHTTP(ctx, addr, banner)
+	default:
+		err = g.grabPlain(ctx, addr, banner)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if banner.Product == "" && banner.Title == "" && banner.TLSSubject == "" {
+		return nil, ErrNoBanner
+	}
+
+	return banner, nil
+}
+
+// reads a greeting from the service without writing anything to it
+func (g *NetworkBannerGrabber) grabPlain(ctx context.Context, addr string, banner *Banner) error {
+	dialer := net.Dialer{}
+
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+
+	if err := conn.SetReadDeadline(earliest(deadline, time.Now().Add(g.wait))); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(io.LimitReader(conn, maxBannerRead))
+
+	line, _ := reader.ReadString('\n')
+	line = strings.TrimSpace(line)
+
+	if line == "" {
+		// service closed the connection or waited for the client
+		return ErrNoBanner
+	}
+
+	banner.Protocol = BannerProtocolRaw
+
+	if strings.HasPrefix(line, "SSH-") {
+		banner.Protocol = BannerProtocolSSH
+	}
+
+	banner.Product = line
+
+	return nil
+}
+
+// probes the service with an http request
+func (g *NetworkBannerGrabber) grabHTTP(ctx context.Context, addr string, banner *Banner) error {
+	dialer := net.Dialer{}
+
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	banner.Protocol = BannerProtocolHTTP
+
+	reader := bufio.NewReader(io.LimitReader(conn, maxBannerRead))
+
+	return readHTTPBanner(conn, reader, addr, banner)
+}
+
+// performs a tls handshake recording the certificate subject and then
+// probes with an http request
+func (g *NetworkBannerGrabber) grabTLS(ctx context.Context, addr string, banner *Banner) error {
+	dialer := tls.Dialer{
+		Config: &tls.Config{
+			// we only want to know who the service claims to be
+			InsecureSkipVerify: true,
+		},
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	banner.Protocol = BannerProtocolTLS
+
+	state := conn.(*tls.Conn).ConnectionState()
+
+	if len(state.PeerCertificates) > 0 {
+		banner.TLSSubject = state.PeerCertificates[0].Subject.String()
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	reader := bufio.NewReader(io.LimitReader(conn, maxBannerRead))
+
+	if err := readHTTPBanner(conn, reader, addr, banner); err == nil {
+		banner.Protocol = BannerProtocolHTTPS
+	}
+
+	return nil
+}
+
+// sends an http request and records the server header and page title
+func readHTTPBanner(conn net.Conn, reader *bufio.Reader, addr string, banner *Banner) error {
+	host, _, _ := net.SplitHostPort(addr)
+
+	request := "GET / HTTP/1.0\r\n" +
+		"Host: " + host + "\r\n" +
+		"User-Agent: ops\r\n" +
+		"Connection: close\r\n\r\n"
+
+	if _, err := conn.Write([]byte(request)); err != nil {
+		return err
+	}
+
+	res, err := http.ReadResponse(reader, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	banner.Product = res.Header.Get("Server")
+
+	body, _ := io.ReadAll(res.Body)
+
+	if match := titleRegexp.FindSubmatch(body); match != nil {
+		banner.Title = strings.Join(strings.Fields(string(match[1])), " ")
+	}
+
+	return nil
+}
+
+// returns the earliest non-zero time
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+
+	return a
+}
+
+// bannerStore keeps the banner for each open port of each host so banners
+// are only grabbed once per port rather than every scan. Ports that did not
+// report a banner are remembered as nil so they aren't grabbed again either.
+// Banners are grabbed concurrently so access is synchronized.
+type bannerStore struct {
+	banners map[string]map[uint16]*Banner
+	mux     sync.Mutex
+}
+
+// returns a new instance of bannerStore
+func newBannerStore() *bannerStore {
+	return &bannerStore{
+		banners: map[string]map[uint16]*Banner{},
+	}
+}
+
+// reset removes all known banners
+func (s *bannerStore) reset() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.banners = map[string]map[uint16]*Banner{}
+}
+
+// claim returns true if the banner for a host's port has not been grabbed
+// yet, marking it so concurrent results for the same port don't grab again
+func (s *bannerStore) claim(id string, port uint16) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	banners, ok := s.banners[id]
+
+	if !ok {
+		banners = map[uint16]*Banner{}
+		s.banners[id] = banners
+	}
+
+	if _, ok := banners[port]; ok {
+		return false
+	}
+
+	banners[port] = nil
+
+	return true
+}
+
+// set records the banner grabbed for a host's port, which may be nil if the
+// service did not report one, and returns all known banners for the host
+func (s *bannerStore) set(id string, port uint16, banner *Banner) []Banner {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if banners, ok := s.banners[id]; ok {
+		banners[port] = banner
+	}
+
+	return s.list(id)
+}
+
+// remove forgets the banner for a host's closed port so it is grabbed again
+// if the port reopens, and returns all known banners for the host
+func (s *bannerStore) remove(id string, port uint16) []Banner {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	delete(s.banners[id], port)
+
+	return s.list(id)
+}
+
+// get returns all known banners for a host ordered by port
+func (s *bannerStore) get(id string) []Banner {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.list(id)
+}
+
+// returns all known banners for a host ordered by port - must be called
+// while holding the lock
+func (s *bannerStore) list(id string) []Banner {
+	result := []Banner{}
+
+	for _, b := range s.banners[id] {
+		if b != nil {
+			result = append(result, *b)
+		}
+	}
+
+	slices.SortFunc(result, func(a, b Banner) int {
+		return int(a.Port) - int(b.Port)
+	})
+
+	return result
+}
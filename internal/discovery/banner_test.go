@@ -0,0 +1,239 @@
+package discovery_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/robgonnella/ops/internal/discovery"
+	"github.com/stretchr/testify/assert"
+)
+
+// starts a tcp server that writes the given greeting to every connection
+// and returns its port
+func startGreetingServer(t *testing.T, greeting string) uint16 {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		listener.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+
+			if err != nil {
+				return
+			}
+
+			if greeting != "" {
+				conn.Write([]byte(greeting))
+			}
+
+			conn.Close()
+		}
+	}()
+
+	return uint16(listener.Addr().(*net.TCPAddr).Port)
+}
+
+// starts a silent tcp server that records everything written to it and
+// returns its port along with a function returning the number of accepted
+// connections and received bytes
+func startRecordingServer(t *testing.T) (uint16, func() (int, []byte)) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		listener.Close()
+	})
+
+	mux := sync.Mutex{}
+	accepted := 0
+	received := []byte{}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+
+			if err != nil {
+				return
+			}
+
+			mux.Lock()
+			accepted++
+			mux.Unlock()
+
+			go func() {
+				defer conn.Close()
+
+				buf := make([]byte, 1024)
+
+				for {
+					n, err := conn.Read(buf)
+
+					mux.Lock()
+					received = append(received, buf[:n]...)
+					mux.Unlock()
+
+					if err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	return uint16(listener.Addr().(*net.TCPAddr).Port), func() (int, []byte) {
+		mux.Lock()
+		defer mux.Unlock()
+		return accepted, slices.Clone(received)
+	}
+}
+
+// returns the port of the given test server
+func testServerPort(t *testing.T, server *httptest.Server) uint16 {
+	t.Helper()
+
+	_, portStr, err := net.SplitHostPort(server.Listener.Addr().String())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port, err := strconv.ParseUint(portStr, 10, 16)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return uint16(port)
+}
+
+func TestBannerGrabber(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "nginx/1.25.3")
+		w.Write([]byte("<html><head><title>\n  Router Admin\n</title></head></html>"))
+	})
+
+	t.Run("reads ssh identification string", func(st *testing.T) {
+		port := startGreetingServer(st, "SSH-2.0-OpenSSH_9.6\r\n")
+
+		grabber := discovery.NewBannerGrabber()
+
+		banner, err := grabber.Grab(context.Background(), "127.0.0.1", port)
+
+		assert.NoError(st, err)
+		assert.Equal(st, &discovery.Banner{
+			Port:     port,
+			Protocol: discovery.BannerProtocolSSH,
+			Product:  "SSH-2.0-OpenSSH_9.6",
+		}, banner)
+	})
+
+	t.Run("reads http server header and title", func(st *testing.T) {
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		port := testServerPort(st, server)
+
+		grabber := discovery.NewBannerGrabber(discovery.WithHTTPPorts(port))
+
+		banner, err := grabber.Grab(context.Background(), "127.0.0.1", port)
+
+		assert.NoError(st, err)
+		assert.Equal(st, &discovery.Banner{
+			Port:     port,
+			Protocol: discovery.BannerProtocolHTTP,
+			Product:  "nginx/1.25.3",
+			Title:    "Router Admin",
+		}, banner)
+	})
+
+	t.Run("reads tls certificate subject", func(st *testing.T) {
+		server := httptest.NewTLSServer(handler)
+		defer server.Close()
+
+		port := testServerPort(st, server)
+
+		grabber := discovery.NewBannerGrabber(discovery.WithTLSPorts(port))
+
+		banner, err := grabber.Grab(context.Background(), "127.0.0.1", port)
+
+		assert.NoError(st, err)
+		assert.Equal(st, &discovery.Banner{
+			Port:       port,
+			Protocol:   discovery.BannerProtocolHTTPS,
+			Product:    "nginx/1.25.3",
+			Title:      "Router Admin",
+			TLSSubject: server.Certificate().Subject.String(),
+		}, banner)
+	})
+
+	t.Run("returns error when service is silent", func(st *testing.T) {
+		port := startGreetingServer(st, "")
+
+		grabber := discovery.NewBannerGrabber(
+			discovery.WithBannerWait(time.Millisecond * 50),
+		)
+
+		_, err := grabber.Grab(context.Background(), "127.0.0.1", port)
+
+		assert.ErrorIs(st, err, discovery.ErrNoBanner)
+	})
+
+	t.Run("does not write to services that are not http", func(st *testing.T) {
+		port, stats := startRecordingServer(st)
+
+		grabber := discovery.NewBannerGrabber(
+			discovery.WithBannerWait(time.Millisecond * 50),
+		)
+
+		_, err := grabber.Grab(context.Background(), "127.0.0.1", port)
+
+		assert.ErrorIs(st, err, discovery.ErrNoBanner)
+
+		accepted, received := stats()
+
+		assert.Equal(st, 1, accepted)
+		assert.Empty(st, received)
+	})
+
+	t.Run("never connects to printer ports", func(st *testing.T) {
+		port, stats := startRecordingServer(st)
+
+		grabber := discovery.NewBannerGrabber(
+			discovery.WithHTTPPorts(port),
+			discovery.WithPrinterPorts(port),
+		)
+
+		_, err := grabber.Grab(context.Background(), "127.0.0.1", port)
+
+		assert.ErrorIs(st, err, discovery.ErrNoBanner)
+
+		accepted, received := stats()
+
+		assert.Zero(st, accepted)
+		assert.Empty(st, received)
+		assert.Contains(st, discovery.DefaultPrinterPorts, uint16(9100))
+		assert.Contains(st, discovery.DefaultPrinterPorts, uint16(515))
+		assert.Contains(st, discovery.DefaultPrinterPorts, uint16(631))
+	})
+}
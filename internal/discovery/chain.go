@@ -0,0 +1,213 @@
+package discovery
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/robgonnella/ops/internal/config"
+)
+
+// ChainMode determines how detail providers in a DetailChain are run
+type ChainMode int
+
+const (
+	// ChainSequential runs providers one at a time in priority order and
+	// stops once all details have been filled in
+	ChainSequential ChainMode = iota
+	// ChainConcurrent runs all providers at the same time
+	ChainConcurrent
+)
+
+// detailProvider represents a DetailScanner registered with a DetailChain
+type detailProvider struct {
+	name     string
+	priority int
+	scanner  DetailScanner
+}
+
+// providerResult represents the outcome of running a single provider
+type providerResult struct {
+	details *Details
+	err     error
+}
+
+// DetailChain is an implementation of the DetailScanner interface that runs
+// multiple registered detail providers and merges their results. When more
+// than one provider returns the same detail, the provider with the highest
+// priority wins.
+type DetailChain struct {
+	mode      ChainMode
+	providers []detailProvider
+	mux       sync.RWMutex
+}
+
+// NewDetailChain returns a new instance of DetailChain
+func NewDetailChain(mode ChainMode) *DetailChain {
+	return &DetailChain{
+		mode:      mode,
+		providers: []detailProvider{},
+	}
+}
+
+// Register adds a detail provider to the chain. Providers with a higher
+// priority are run first and take precedence when merging results.
+// Registering a provider with an existing name replaces that provider.
+func (c *DetailChain) Register(name string, priority int, scanner DetailScanner) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.providers = slices.DeleteFunc(c.providers, func(p detailProvider) bool {
+		return p.name == name
+	})
+
+	c.providers = append(c.providers, detailProvider{
+		name:     name,
+		priority: priority,
+		scanner:  scanner,
+	})
+
+	// stable so providers with equal priority run in registration order
+	slices.SortStableFunc(c.providers, func(p1, p2 detailProvider) int {
+		return p2.priority - p1.priority
+	})
+}
+
+// Providers returns the names of all registered providers in priority order
+func (c *DetailChain) Providers() []string {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	names := []string{}
+
+	for _, p := range c.providers {
+		names = append(names, p.name)
+	}
+
+	return names
+}
+
+// GetServerDetails runs all registered providers and merges their results.
+// An error is only returned if every provider fails, in which case the error
+// from the highest priority provider is returned. Otherwise errors from
+// failed providers are included in the merged details.
+func (c *DetailChain) GetServerDetails(ctx context.Context, ip string, creds config.SSHCredentials) (*Details, error) {
+	c.mux.RLock()
+	providers := slices.Clone(c.providers)
+	c.mux.RUnlock()
+
+	var results []providerResult
+
+	if c.mode == ChainConcurrent {
+		results = runConcurrent(ctx, providers, ip, creds)
+	} else {
+		results = runSequential(ctx, providers, ip, creds)
+	}
+
+	merged := &Details{}
+	succeeded := false
+	errs := []error{}
+
+	// results are in priority order so only fill in missing details
+	for _, r := range results {
+		if r.err != nil {
+			errs = append(errs, r.err)
+			continue
+		}
+
+		succeeded = true
+		mergeDetails(merged, r.details)
+	}
+
+	if !succeeded && len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	if len(errs) > 0 {
+		merged.Errors = errs
+	}
+
+	return merged, nil
+}
+
+// runs providers one at a time stopping once all details are filled in
+func runSequential(
+	ctx context.Context,
+	providers []detailProvider,
+	ip string,
+	creds config.SSHCredentials,
+) []providerResult {
+	results := []providerResult{}
+	merged := &Details{}
+
+	for _, p := range providers {
+		if ctx.Err() != nil {
+			results = append(results, providerResult{err: ctx.Err()})
+			break
+		}
+
+		details, err := p.scanner.GetServerDetails(ctx, ip, creds)
+
+		results = append(results, providerResult{details: details, err: err})
+
+		if err == nil {
+			mergeDetails(merged, details)
+		}
+
+		if isComplete(merged) {
+			break
+		}
+	}
+
+	return results
+}
+
+// runs all providers at the same time returning results in priority order
+func runConcurrent(
+	ctx context.Context,
+	providers []detailProvider,
+	ip string,
+	creds config.SSHCredentials,
+) []providerResult {
+	results := make([]providerResult, len(providers))
+	wg := sync.WaitGroup{}
+
+	for i, p := range providers {
+		wg.Add(1)
+
+		go func(i int, p detailProvider) {
+			defer wg.Done()
+			details, err := p.scanner.GetServerDetails(ctx, ip, creds)
+			results[i] = providerResult{details: details, err: err}
+		}(i, p)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// fills in any details missing from dest using src
+func mergeDetails(dest, src *Details) {
+	if src == nil {
+		return
+	}
+
+	if dest.Hostname == "" {
+		dest.Hostname = src.Hostname
+		dest.HostnameSource = src.HostnameSource
+	}
+
+	if dest.OS == "" {
+		dest.OS = src.OS
+	}
+
+	if dest.Facts == nil {
+		dest.Facts = src.Facts
+	}
+}
+
+// returns true if every detail has been filled in
+func isComplete(details *Details) bool {
+	return details.Hostname != "" && details.OS != "" && details.Facts != nil
+}
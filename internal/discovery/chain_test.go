@@ -0,0 +1,226 @@
+package discovery_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/robgonnella/ops/internal/config"
+	"github.com/robgonnella/ops/internal/discovery"
+	mock_discovery "github.com/robgonnella/ops/internal/mock/discovery"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDetailChain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	facts := &discovery.Facts{Kernel: "6.1.0", CPUCount: 2}
+
+	t.Run("orders providers by priority", func(st *testing.T) {
+		chain := discovery.NewDetailChain(discovery.ChainSequential)
+
+		chain.Register("low", 1, mock_discovery.NewMockDetailScanner(ctrl))
+		chain.Register("high", 10, mock_discovery.NewMockDetailScanner(ctrl))
+		chain.Register("medium", 5, mock_discovery.NewMockDetailScanner(ctrl))
+
+		assert.Equal(st, []string{"high", "medium", "low"}, chain.Providers())
+	})
+
+	t.Run("replaces provider with same name", func(st *testing.T) {
+		chain := discovery.NewDetailChain(discovery.ChainSequential)
+
+		chain.Register("ssh", 1, mock_discovery.NewMockDetailScanner(ctrl))
+		chain.Register("dns", 5, mock_discovery.NewMockDetailScanner(ctrl))
+		chain.Register("ssh", 10, mock_discovery.NewMockDetailScanner(ctrl))
+
+		assert.Equal(st, []string{"ssh", "dns"}, chain.Providers())
+	})
+
+	creds := config.SSHCredentials{User: "user", Identity: "identity", Port: "22"}
+
+	modes := map[string]discovery.ChainMode{
+		"sequential": discovery.ChainSequential,
+		"concurrent": discovery.ChainConcurrent,
+	}
+
+	for name, mode := range modes {
+		t.Run(name+" merges details by priority", func(st *testing.T) {
+			high := mock_discovery.NewMockDetailScanner(ctrl)
+			low := mock_discovery.NewMockDetailScanner(ctrl)
+
+			chain := discovery.NewDetailChain(mode)
+			chain.Register("low", 1, low)
+			chain.Register("high", 10, high)
+
+			high.EXPECT().
+				GetServerDetails(gomock.Any(), "192.168.1.2", creds).
+				Return(&discovery.Details{OS: "Linux", Facts: facts}, nil)
+
+			low.EXPECT().
+				GetServerDetails(gomock.Any(), "192.168.1.2", creds).
+				Return(&discovery.Details{Hostname: "low-host", OS: "Other"}, nil)
+
+			details, err := chain.GetServerDetails(context.Background(), "192.168.1.2", creds)
+
+			assert.NoError(st, err)
+			assert.Equal(st, &discovery.Details{
+				Hostname: "low-host",
+				OS:       "Linux",
+				Facts:    facts,
+			}, details)
+		})
+
+		t.Run(name+" succeeds if any provider succeeds", func(st *testing.T) {
+			high := mock_discovery.NewMockDetailScanner(ctrl)
+			low := mock_discovery.NewMockDetailScanner(ctrl)
+
+			chain := discovery.NewDetailChain(mode)
+			chain.Register("low", 1, low)
+			chain.Register("high", 10, high)
+
+			highErr := errors.New("high error")
+
+			high.EXPECT().
+				GetServerDetails(gomock.Any(), "192.168.1.2", creds).
+				Return(nil, highErr)
+
+			low.EXPECT().
+				GetServerDetails(gomock.Any(), "192.168.1.2", creds).
+				Return(&discovery.Details{Hostname: "low-host"}, nil)
+
+			details, err := chain.GetServerDetails(context.Background(), "192.168.1.2", creds)
+
+			assert.NoError(st, err)
+			assert.Equal(st, "low-host", details.Hostname)
+			assert.Equal(st, []error{highErr}, details.Errors)
+		})
+
+		t.Run(name+" returns highest priority error if all fail", func(st *testing.T) {
+			high := mock_discovery.NewMockDetailScanner(ctrl)
+			low := mock_discovery.NewMockDetailScanner(ctrl)
+
+			chain := discovery.NewDetailChain(mode)
+			chain.Register("low", 1, low)
+			chain.Register("high", 10, high)
+
+			highErr := errors.New("high error")
+
+			high.EXPECT().
+				GetServerDetails(gomock.Any(), "192.168.1.2", creds).
+				Return(nil, highErr)
+
+			low.EXPECT().
+				GetServerDetails(gomock.Any(), "192.168.1.2", creds).
+				Return(nil, errors.New("low error"))
+
+			_, err := chain.GetServerDetails(context.Background(), "192.168.1.2", creds)
+
+			assert.ErrorIs(st, err, highErr)
+		})
+	}
+
+	t.Run("sequential stops once details are complete", func(st *testing.T) {
+		high := mock_discovery.NewMockDetailScanner(ctrl)
+		low := mock_discovery.NewMockDetailScanner(ctrl)
+
+		chain := discovery.NewDetailChain(discovery.ChainSequential)
+		chain.Register("low", 1, low)
+		chain.Register("high", 10, high)
+
+		high.EXPECT().
+			GetServerDetails(gomock.Any(), "192.168.1.2", creds).
+			Return(&discovery.Details{
+				Hostname: "high-host",
+				OS:       "Linux",
+				Facts:    facts,
+			}, nil)
+
+		details, err := chain.GetServerDetails(context.Background(), "192.168.1.2", creds)
+
+		assert.NoError(st, err)
+		assert.Equal(st, "high-host", details.Hostname)
+	})
+
+	t.Run("falls back to resolved hostname when ssh fails", func(st *testing.T) {
+		ssh := mock_discovery.NewMockDetailScanner(ctrl)
+		resolver := mock_discovery.NewMockHostnameResolver(ctrl)
+
+		chain := discovery.NewDetailChain(discovery.ChainSequential)
+		chain.Register("ssh", 100, ssh)
+		chain.Register("resolver", 0, discovery.NewResolverScanner(resolver))
+
+		sshErr := errors.New("ssh error")
+
+		ssh.EXPECT().
+			GetServerDetails(gomock.Any(), "192.168.1.2", creds).
+			Return(nil, sshErr)
+
+		resolver.EXPECT().
+			Resolve(gomock.Any(), "192.168.1.2").
+			Return("printer.lan", discovery.HostnameSourcePTR, nil)
+
+		details, err := chain.GetServerDetails(context.Background(), "192.168.1.2", creds)
+
+		assert.NoError(st, err)
+		assert.Equal(st, &discovery.Details{
+			Hostname:       "printer.lan",
+			HostnameSource: discovery.HostnameSourcePTR,
+			Errors:         []error{sshErr},
+		}, details)
+	})
+
+	t.Run("prefers ssh hostname over resolved hostname", func(st *testing.T) {
+		ssh := mock_discovery.NewMockDetailScanner(ctrl)
+		resolver := mock_discovery.NewMockHostnameResolver(ctrl)
+
+		chain := discovery.NewDetailChain(discovery.ChainConcurrent)
+		chain.Register("ssh", 100, ssh)
+		chain.Register("resolver", 0, discovery.NewResolverScanner(resolver))
+
+		ssh.EXPECT().
+			GetServerDetails(gomock.Any(), "192.168.1.2", creds).
+			Return(&discovery.Details{
+				Hostname:       "server",
+				HostnameSource: discovery.HostnameSourceSSH,
+				OS:             "Linux",
+			}, nil)
+
+		resolver.EXPECT().
+			Resolve(gomock.Any(), "192.168.1.2").
+			Return("server.lan", discovery.HostnameSourcePTR, nil)
+
+		details, err := chain.GetServerDetails(context.Background(), "192.168.1.2", creds)
+
+		assert.NoError(st, err)
+		assert.Equal(st, "server", details.Hostname)
+		assert.Equal(st, discovery.HostnameSourceSSH, details.HostnameSource)
+	})
+
+	t.Run("keeps ssh error when no hostname is resolved", func(st *testing.T) {
+		ssh := mock_discovery.NewMockDetailScanner(ctrl)
+		resolver := mock_discovery.NewMockHostnameResolver(ctrl)
+
+		chain := discovery.NewDetailChain(discovery.ChainSequential)
+		chain.Register("ssh", 100, ssh)
+		chain.Register("resolver", 0, discovery.NewResolverScanner(resolver))
+
+		sshErr := errors.New("ssh error")
+
+		ssh.EXPECT().
+			GetServerDetails(gomock.Any(), "192.168.1.2", creds).
+			Return(nil, sshErr)
+
+		resolver.EXPECT().
+			Resolve(gomock.Any(), "192.168.1.2").
+			Return("", discovery.HostnameSource(""), discovery.ErrHostnameNotFound)
+
+		details, err := chain.GetServerDetails(context.Background(), "192.168.1.2", creds)
+
+		assert.NoError(st, err)
+		assert.Equal(st, "", details.Hostname)
+		assert.Equal(st, []error{sshErr}, details.Errors)
+	})
+}
@@ -0,0 +1,50 @@
+package discovery
+
+import (
+	"strings"
+)
+
+// gathers server details using the given command runner and the probe
+// registered for the server's kernel
+func gatherDetails(run CommandRunner) (*Details, error) {
+	unameOutput, err := run("uname -a")
+
+	if err != nil {
+		return nil, err
+	}
+
+	info := strings.Split(unameOutput, " ")
+	kernelName := info[0]
+	hostname := ""
+
+	if len(info) > 1 {
+		hostname = info[1]
+	}
+
+	details := &Details{
+		Hostname: hostname,
+		OS:       kernelName,
+	}
+
+	if hostname != "" {
+		details.HostnameSource = HostnameSourceSSH
+	}
+
+	probe, ok := getOSProbe(kernelName)
+
+	if !ok {
+		return details, nil
+	}
+
+	details.OS, err = probe.OS(run)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// facts are best effort - failing to gather them should not prevent
+	// reporting hostname and os
+	details.Facts, _ = probe.Facts(run)
+
+	return details, nil
+}
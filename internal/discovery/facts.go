@@ -0,0 +1,245 @@
+package discovery
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// marks the start of each section of output in the facts scripts
+const factsSectionPrefix = "--- "
+
+var (
+	bootTimeRegexp = regexp.MustCompile(`sec\s*=\s*(\d+)`)
+	pageSizeRegexp = regexp.MustCompile(`page size of (\d+) bytes`)
+)
+
+// builds a single shell command that echoes a section marker before the
+// output of each command
+func factsScript(sections [][2]string) string {
+	cmds := []string{}
+
+	for _, s := range sections {
+		cmds = append(
+			cmds,
+			"echo '"+factsSectionPrefix+s[0]+"'",
+			"{ "+s[1]+"; }",
+		)
+	}
+
+	return strings.Join(cmds, "; ")
+}
+
+// runs a facts script and returns its output split into sections
+func runFactsScript(run CommandRunner, script string) (map[string]string, error) {
+	output, err := run(script)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return splitSections(output), nil
+}
+
+// returns facts common to all supported operating systems
+func commonFacts(sections map[string]string) *Facts {
+	facts := &Facts{
+		Kernel: firstLine(sections["kernel"]),
+		Arch:   firstLine(sections["arch"]),
+	}
+
+	facts.CPUCount, _ = strconv.Atoi(firstLine(sections["cpus"]))
+	facts.DiskTotal, facts.DiskUsed = parseDF(sections["disk"])
+	facts.LoadAverage = parseLoadAverage(sections["loadavg"])
+
+	return facts
+}
+
+// parses an unsigned integer from the first line of the given text
+func parseUint(text string) uint64 {
+	value, _ := strconv.ParseUint(firstLine(text), 10, 64)
+	return value
+}
+
+// splits script output into sections keyed by section name
+func splitSections(output string) map[string]string {
+	sections := map[string]string{}
+	current := ""
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, factsSectionPrefix) {
+			current = strings.TrimSpace(strings.TrimPrefix(line, factsSectionPrefix))
+			continue
+		}
+
+		if current == "" {
+			continue
+		}
+
+		sections[current] += line + "\n"
+	}
+
+	return sections
+}
+
+// returns the first trimmed line of the given text
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return strings.TrimSpace(line)
+}
+
+// parses total and used bytes for the root filesystem from "df -Pk /"
+func parseDF(output string) (uint64, uint64) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+
+	if len(lines) < 2 {
+		return 0, 0
+	}
+
+	fields := strings.Fields(lines[len(lines)-1])
+
+	if len(fields) < 3 {
+		return 0, 0
+	}
+
+	total, _ := strconv.ParseUint(fields[1], 10, 64)
+	used, _ := strconv.ParseUint(fields[2], 10, 64)
+
+	return total * 1024, used * 1024
+}
+
+// parses 1, 5, and 15 minute load averages from either /proc/loadavg
+// or "sysctl -n vm.loadavg" which wraps values in braces
+func parseLoadAverage(output string) [3]float64 {
+	loads := [3]float64{}
+
+	fields := strings.Fields(
+		strings.NewReplacer("{", "", "}", "").Replace(firstLine(output)),
+	)
+
+	for i := 0; i < len(fields) && i < len(loads); i++ {
+		loads[i], _ = strconv.ParseFloat(fields[i], 64)
+	}
+
+	return loads
+}
+
+// parses total and used bytes from /proc/meminfo
+func parseMeminfo(output string) (uint64, uint64) {
+	values := map[string]uint64{}
+
+	for _, line := range strings.Split(output, "\n") {
+		name, value, found := strings.Cut(line, ":")
+
+		if !found {
+			continue
+		}
+
+		fields := strings.Fields(value)
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		kb, err := strconv.ParseUint(fields[0], 10, 64)
+
+		if err != nil {
+			continue
+		}
+
+		values[name] = kb * 1024
+	}
+
+	total := values["MemTotal"]
+
+	available, ok := values["MemAvailable"]
+
+	if !ok {
+		// older kernels do not report MemAvailable
+		available = values["MemFree"] + values["Buffers"] + values["Cached"]
+	}
+
+	return total, usedMemory(total, available)
+}
+
+// parses used bytes from macOS vm_stat output
+func parseVMStat(total uint64, output string) uint64 {
+	match := pageSizeRegexp.FindStringSubmatch(output)
+
+	if match == nil {
+		return 0
+	}
+
+	pageSize, _ := strconv.ParseUint(match[1], 10, 64)
+
+	available := uint64(0)
+
+	for _, line := range strings.Split(output, "\n") {
+		name, value, found := strings.Cut(line, ":")
+
+		if !found {
+			continue
+		}
+
+		switch strings.TrimSpace(name) {
+		case "Pages free", "Pages inactive", "Pages speculative":
+			pages, err := strconv.ParseUint(
+				strings.TrimSuffix(strings.TrimSpace(value), "."),
+				10,
+				64,
+			)
+
+			if err == nil {
+				available += pages * pageSize
+			}
+		}
+	}
+
+	return usedMemory(total, available)
+}
+
+// parses uptime from /proc/uptime
+func parseProcUptime(output string) time.Duration {
+	fields := strings.Fields(firstLine(output))
+
+	if len(fields) == 0 {
+		return 0
+	}
+
+	seconds, err := strconv.ParseFloat(fields[0], 64)
+
+	if err != nil {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+// calculates uptime from "sysctl -n kern.boottime" and the remote host's
+// current unix time
+func parseBootTime(bootTime, now string) time.Duration {
+	match := bootTimeRegexp.FindStringSubmatch(bootTime)
+
+	if match == nil {
+		return 0
+	}
+
+	boot, _ := strconv.ParseInt(match[1], 10, 64)
+	current, err := strconv.ParseInt(firstLine(now), 10, 64)
+
+	if err != nil || current < boot {
+		return 0
+	}
+
+	return time.Duration(current-boot) * time.Second
+}
+
+// returns used memory guarding against underflow
+func usedMemory(total, available uint64) uint64 {
+	if available > total {
+		return 0
+	}
+
+	return total - available
+}
@@ -0,0 +1,112 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// DefaultHostKeyTimeout maximum time to wait for a server's host key
+const DefaultHostKeyTimeout = time.Second * 5
+
+// HostKey represents a server's ssh host key
+type HostKey struct {
+	Type        string `json:"type"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// HostKeyChange represents the payload for host key changed events
+type HostKeyChange struct {
+	ConfigID string
+	ID       string
+	IP       string
+	Hostname string
+	Previous HostKey
+	Current  HostKey
+}
+
+// Warning returns a message warning the user about the changed host key
+func (c HostKeyChange) Warning() string {
+	return fmt.Sprintf(
+		"WARNING: SSH HOST KEY CHANGED FOR %s (%s)\n\n"+
+			"previous: %s %s\ncurrent: %s %s\n\n"+
+			"The server may have been reinstalled or someone may be "+
+			"impersonating it.",
+		c.Hostname,
+		c.IP,
+		c.Previous.Type,
+		c.Previous.Fingerprint,
+		c.Current.Type,
+		c.Current.Fingerprint,
+	)
+}
+
+// returned from host key callback to abort the handshake once we have
+// the key - we never need to authenticate
+var errHostKeyCaptured = errors.New("host key captured")
+
+// SSHHostKeyScanner is an implementation of the HostKeyScanner interface
+// that performs a partial ssh handshake to read the server's host key
+type SSHHostKeyScanner struct {
+	timeout time.Duration
+}
+
+// NewHostKeyScanner returns a new instance of SSHHostKeyScanner
+func NewHostKeyScanner(timeout time.Duration) *SSHHostKeyScanner {
+	if timeout <= 0 {
+		timeout = DefaultHostKeyTimeout
+	}
+
+	return &SSHHostKeyScanner{timeout: timeout}
+}
+
+// ScanHostKey returns the host key presented by the ssh server at the
+// given ip and port
+func (s *SSHHostKeyScanner) ScanHostKey(ctx context.Context, ip, port string) (*HostKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(ip, port)
+
+	dialer := net.Dialer{}
+
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	var hostKey *HostKey
+
+	conf := &ssh.ClientConfig{
+		User: "ops",
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			hostKey = &HostKey{
+				Type:        key.Type(),
+				Fingerprint: ssh.FingerprintSHA256(key),
+			}
+
+			return errHostKeyCaptured
+		},
+	}
+
+	_, _, _, err = ssh.NewClientConn(conn, addr, conf)
+
+	if hostKey != nil {
+		return hostKey, nil
+	}
+
+	return nil, err
+}
@@ -0,0 +1,39 @@
+package discovery_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/robgonnella/ops/internal/discovery"
+	"github.com/robgonnella/ops/internal/test_util"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestHostKeyScanner(t *testing.T) {
+	t.Run("returns server host key", func(st *testing.T) {
+		server := test_util.NewSSHServer(st, "user", nil)
+
+		scanner := discovery.NewHostKeyScanner(time.Second)
+
+		key, err := scanner.ScanHostKey(context.Background(), server.Host, server.Port)
+
+		assert.NoError(st, err)
+		assert.Equal(st, &discovery.HostKey{
+			Type:        server.HostKey.PublicKey().Type(),
+			Fingerprint: ssh.FingerprintSHA256(server.HostKey.PublicKey()),
+		}, key)
+	})
+
+	t.Run("returns error when nothing is listening", func(st *testing.T) {
+		server := test_util.NewSSHServer(st, "user", nil)
+		server.Close()
+
+		scanner := discovery.NewHostKeyScanner(time.Second)
+
+		_, err := scanner.ScanHostKey(context.Background(), server.Host, server.Port)
+
+		assert.Error(st, err)
+	})
+}
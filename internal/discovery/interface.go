@@ -7,11 +7,28 @@ import (
 	"github.com/robgonnella/ops/internal/config"
 )
 
-//go:generate mockgen -destination=../mock/discovery/mock_discovery.go -package=mock_discovery . DetailScanner,Scanner
+//go:generate mockgen -destination=../mock/discovery/mock_discovery.go -package=mock_discovery . BannerGrabber,DetailScanner,HostKeyScanner,HostnameResolver,Scanner
 
-// DetailScanner interface for gathering more details about a device
+// BannerGrabber interface for identifying services running on open ports
+type BannerGrabber interface {
+	Grab(ctx context.Context, ip string, port uint16) (*Banner, error)
+}
+
+// DetailScanner interface for gathering more details about a device using
+// the ssh credentials resolved for it
 type DetailScanner interface {
-	GetServerDetails(ctx context.Context, ip, sshPort string) (*Details, error)
+	GetServerDetails(ctx context.Context, ip string, creds config.SSHCredentials) (*Details, error)
+}
+
+// HostKeyScanner interface for reading a server's ssh host key
+type HostKeyScanner interface {
+	ScanHostKey(ctx context.Context, ip, port string) (*HostKey, error)
+}
+
+// HostnameResolver interface for finding hostnames of devices that we
+// cannot gather details from using ssh
+type HostnameResolver interface {
+	Resolve(ctx context.Context, ip string) (string, HostnameSource, error)
 }
 
 // Scanner interface for scanning a network for devices
@@ -24,6 +41,7 @@ type Scanner interface {
 // Service interface for monitoring a network
 type Service interface {
 	MonitorNetwork() error
-	SetConfigAndScanner(conf config.Config, netScanner Scanner)
+	Scan() error
+	SetConfigAndScanner(conf config.Config, netScanner Scanner, detailScanner DetailScanner)
 	Stop()
 }
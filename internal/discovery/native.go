@@ -0,0 +1,70 @@
+package discovery
+
+import (
+	"context"
+	"time"
+
+	"github.com/robgonnella/ops/internal/config"
+	"github.com/robgonnella/ops/internal/sshclient"
+)
+
+// nativeDetailsTimeout maximum time allowed to gather details from a
+// single server including connecting
+const nativeDetailsTimeout = time.Second * 30
+
+// NativeScanner is an implementation of the DetailScanner interface that
+// uses an in-process ssh client and a single connection per server
+type NativeScanner struct {
+	passphrase string
+}
+
+// NewNativeScanner returns a new instance of NativeScanner. The passphrase
+// is used to decrypt encrypted identity files not loaded in ssh-agent.
+func NewNativeScanner(passphrase string) *NativeScanner {
+	return &NativeScanner{passphrase: passphrase}
+}
+
+// GetServerDetails returns server details using an in-process ssh client,
+// tunneling through any configured jump hosts
+func (s NativeScanner) GetServerDetails(ctx context.Context, ip string, creds config.SSHCredentials) (*Details, error) {
+	ctx, cancel := context.WithTimeout(ctx, nativeDetailsTimeout)
+
+	defer cancel()
+
+	jumps, err := creds.Jumps()
+
+	if err != nil {
+		return nil, err
+	}
+
+	opts := sshclient.Options{
+		User:       creds.User,
+		Identity:   creds.Identity,
+		Port:       creds.Port,
+		Passphrase: s.passphrase,
+	}
+
+	for _, j := range jumps {
+		opts.Jumps = append(opts.Jumps, sshclient.Jump(j))
+	}
+
+	// allow time for each jump host to connect
+	dialCtx, cancelDial := context.WithTimeout(
+		ctx,
+		sshclient.DefaultConnectTimeout*time.Duration(len(jumps)+1),
+	)
+
+	defer cancelDial()
+
+	client, err := sshclient.Dial(dialCtx, ip, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer client.Close()
+
+	return gatherDetails(func(cmd string) (string, error) {
+		return client.Run(ctx, cmd)
+	})
+}
@@ -0,0 +1,217 @@
+package discovery_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robgonnella/ops/internal/config"
+	"github.com/robgonnella/ops/internal/discovery"
+	"github.com/robgonnella/ops/internal/sshclient"
+	"github.com/robgonnella/ops/internal/test_util"
+	"github.com/stretchr/testify/assert"
+)
+
+const linuxFactsOutput = `--- kernel
+6.1.0-18-amd64
+--- arch
+x86_64
+--- cpus
+4
+--- meminfo
+MemTotal:        8192000 kB
+MemFree:         1024000 kB
+MemAvailable:    6144000 kB
+Buffers:          100000 kB
+--- disk
+Filesystem     1024-blocks  Used Available Capacity Mounted on
+/dev/sda1           100000 25000     75000      25% /
+--- uptime
+180007.52 700000.10
+--- loadavg
+0.15 0.10 0.05 1/250 12345
+`
+
+func TestNativeScanner(t *testing.T) {
+	// no known_hosts file in temporary home directory
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	server := test_util.NewSSHServer(t, "user", func(cmd string) (string, uint32) {
+		switch cmd {
+		case "uname -a":
+			return "Linux test-host 6.1.0 x86_64 GNU/Linux\n", 0
+		case "cat /etc/os-release":
+			return "NAME=\"Debian\"\nPRETTY_NAME=\"Debian GNU/Linux 12\"\n", 0
+		default:
+			if strings.Contains(cmd, "/proc/meminfo") {
+				return linuxFactsOutput, 0
+			}
+			return "", 127
+		}
+	})
+
+	t.Run("gets server details", func(st *testing.T) {
+		conf := config.Config{
+			SSH: config.SSHConfig{
+				User:     server.User,
+				Identity: server.IdentityFile,
+				Port:     server.Port,
+			},
+		}
+
+		scanner := discovery.NewNativeScanner("")
+
+		details, err := scanner.GetServerDetails(
+			context.Background(),
+			server.Host,
+			conf.SSHCredentials(config.SSHTarget{IP: server.Host}),
+		)
+
+		assert.NoError(st, err)
+		assert.Equal(st, "test-host", details.Hostname)
+		assert.Equal(st, "\"Debian GNU/Linux 12\"", details.OS)
+		assert.Equal(st, &discovery.Facts{
+			Kernel:      "6.1.0-18-amd64",
+			Arch:        "x86_64",
+			CPUCount:    4,
+			MemoryTotal: 8192000 * 1024,
+			MemoryUsed:  (8192000 - 6144000) * 1024,
+			DiskTotal:   100000 * 1024,
+			DiskUsed:    25000 * 1024,
+			Uptime:      time.Hour*50 + time.Second*7,
+			LoadAverage: [3]float64{0.15, 0.1, 0.05},
+		}, details.Facts)
+	})
+
+	t.Run("uses override credentials", func(st *testing.T) {
+		_, otherIdentity := test_util.GenerateIdentityFile(st)
+
+		conf := config.Config{
+			SSH: config.SSHConfig{
+				User:     server.User,
+				Identity: otherIdentity,
+				Port:     server.Port,
+				Overrides: []config.SSHOverride{
+					{
+						Target:   server.Host,
+						Identity: server.IdentityFile,
+					},
+				},
+			},
+		}
+
+		scanner := discovery.NewNativeScanner("")
+
+		details, err := scanner.GetServerDetails(
+			context.Background(),
+			server.Host,
+			conf.SSHCredentials(config.SSHTarget{IP: server.Host}),
+		)
+
+		assert.NoError(st, err)
+		assert.Equal(st, "test-host", details.Hostname)
+	})
+
+	t.Run("tunnels through jump hosts", func(st *testing.T) {
+		bastion := test_util.NewSSHServer(st, "admin", func(cmd string) (string, uint32) {
+			return "", 127
+		})
+
+		server.AuthorizeIdentityFile(st, bastion.IdentityFile)
+
+		conf := config.Config{
+			SSH: config.SSHConfig{
+				User:      server.User,
+				Identity:  bastion.IdentityFile,
+				Port:      server.Port,
+				ProxyJump: bastion.User + "@" + net.JoinHostPort(bastion.Host, bastion.Port),
+			},
+		}
+
+		scanner := discovery.NewNativeScanner("")
+
+		details, err := scanner.GetServerDetails(
+			context.Background(),
+			server.Host,
+			conf.SSHCredentials(config.SSHTarget{IP: server.Host}),
+		)
+
+		assert.NoError(st, err)
+		assert.Equal(st, "test-host", details.Hostname)
+	})
+
+	t.Run("returns auth failed error", func(st *testing.T) {
+		_, otherIdentity := test_util.GenerateIdentityFile(st)
+
+		conf := config.Config{
+			SSH: config.SSHConfig{
+				User:     server.User,
+				Identity: otherIdentity,
+				Port:     server.Port,
+			},
+		}
+
+		scanner := discovery.NewNativeScanner("")
+
+		_, err := scanner.GetServerDetails(
+			context.Background(),
+			server.Host,
+			conf.SSHCredentials(config.SSHTarget{IP: server.Host}),
+		)
+
+		assert.ErrorIs(st, err, sshclient.ErrAuthFailed)
+	})
+}
+
+// plan9Probe test probe for an operating system not supported by default
+type plan9Probe struct{}
+
+func (plan9Probe) OS(run discovery.CommandRunner) (string, error) {
+	return run("cat /dev/osversion")
+}
+
+func (plan9Probe) Facts(run discovery.CommandRunner) (*discovery.Facts, error) {
+	return &discovery.Facts{Kernel: "4"}, nil
+}
+
+func TestNativeScannerRegisteredProbe(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	discovery.RegisterOSProbe("Plan9", plan9Probe{})
+
+	server := test_util.NewSSHServer(t, "user", func(cmd string) (string, uint32) {
+		switch cmd {
+		case "uname -a":
+			return "Plan9 glenda 4 386\n", 0
+		case "cat /dev/osversion":
+			return "Plan 9", 0
+		default:
+			return "", 127
+		}
+	})
+
+	conf := config.Config{
+		SSH: config.SSHConfig{
+			User:     server.User,
+			Identity: server.IdentityFile,
+			Port:     server.Port,
+		},
+	}
+
+	scanner := discovery.NewNativeScanner("")
+
+	details, err := scanner.GetServerDetails(
+		context.Background(),
+		server.Host,
+		conf.SSHCredentials(config.SSHTarget{IP: server.Host}),
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "glenda", details.Hostname)
+	assert.Equal(t, "Plan 9", details.OS)
+	assert.Equal(t, &discovery.Facts{Kernel: "4"}, details.Facts)
+}
@@ -0,0 +1,39 @@
+package discovery
+
+// well known service names for commonly scanned ports
+var serviceNames = map[uint16]string{
+	21:    "ftp",
+	22:    "ssh",
+	23:    "telnet",
+	25:    "smtp",
+	53:    "dns",
+	80:    "http",
+	110:   "pop3",
+	139:   "netbios",
+	143:   "imap",
+	443:   "https",
+	445:   "smb",
+	548:   "afp",
+	623:   "ipmi",
+	631:   "ipp",
+	1883:  "mqtt",
+	3306:  "mysql",
+	3389:  "rdp",
+	5432:  "postgres",
+	5900:  "vnc",
+	6379:  "redis",
+	8080:  "http-alt",
+	8443:  "https-alt",
+	9100:  "jetdirect",
+	27017: "mongodb",
+}
+
+// ServiceName returns the well known service name for the given port or
+// "unknown" if the port is not recognized
+func ServiceName(port uint16) string {
+	if name, ok := serviceNames[port]; ok {
+		return name
+	}
+
+	return "unknown"
+}
@@ -0,0 +1,169 @@
+package discovery
+
+import (
+	"regexp"
+	"sync"
+)
+
+var osReleaseRegexp = regexp.MustCompile(`PRETTY_NAME=(?P<os>.+)`)
+
+// CommandRunner runs a command on a remote server and returns its output
+type CommandRunner func(cmd string) (string, error)
+
+// OSProbe gathers operating system specific details from a server
+type OSProbe interface {
+	// OS returns the human readable operating system name
+	OS(run CommandRunner) (string, error)
+	// Facts returns resource and system information
+	Facts(run CommandRunner) (*Facts, error)
+}
+
+var (
+	osProbes = map[string]OSProbe{
+		"Linux":   linuxProbe{},
+		"Darwin":  darwinProbe{},
+		"FreeBSD": freebsdProbe{},
+	}
+	osProbesMux sync.RWMutex
+)
+
+// RegisterOSProbe registers a probe for the given kernel name as reported
+// by "uname -s", replacing any existing probe for that kernel
+func RegisterOSProbe(kernelName string, probe OSProbe) {
+	osProbesMux.Lock()
+	defer osProbesMux.Unlock()
+	osProbes[kernelName] = probe
+}
+
+// returns the registered probe for the given kernel name
+func getOSProbe(kernelName string) (OSProbe, bool) {
+	osProbesMux.RLock()
+	defer osProbesMux.RUnlock()
+	probe, ok := osProbes[kernelName]
+	return probe, ok
+}
+
+// linuxProbe implements the OSProbe interface for Linux servers
+type linuxProbe struct{}
+
+var linuxFactsScript = factsScript([][2]string{
+	{"kernel", "uname -r"},
+	{"arch", "uname -m"},
+	{"cpus", "nproc 2>/dev/null || grep -c ^processor /proc/cpuinfo"},
+	{"meminfo", "cat /proc/meminfo"},
+	{"disk", "df -Pk /"},
+	{"uptime", "cat /proc/uptime"},
+	{"loadavg", "cat /proc/loadavg"},
+})
+
+// OS returns the distribution name from /etc/os-release
+func (linuxProbe) OS(run CommandRunner) (string, error) {
+	osReleaseOutput, err := run("cat /etc/os-release")
+
+	if err != nil {
+		return "", err
+	}
+
+	match := osReleaseRegexp.FindStringSubmatch(osReleaseOutput)
+
+	for i, name := range osReleaseRegexp.SubexpNames() {
+		if name == "os" && match != nil {
+			return match[i], nil
+		}
+	}
+
+	return "Linux", nil
+}
+
+// Facts returns facts gathered from procfs
+func (linuxProbe) Facts(run CommandRunner) (*Facts, error) {
+	sections, err := runFactsScript(run, linuxFactsScript)
+
+	if err != nil {
+		return nil, err
+	}
+
+	facts := commonFacts(sections)
+	facts.MemoryTotal, facts.MemoryUsed = parseMeminfo(sections["meminfo"])
+	facts.Uptime = parseProcUptime(sections["uptime"])
+
+	return facts, nil
+}
+
+// darwinProbe implements the OSProbe interface for macOS servers
+type darwinProbe struct{}
+
+var darwinFactsScript = factsScript([][2]string{
+	{"kernel", "uname -r"},
+	{"arch", "uname -m"},
+	{"cpus", "sysctl -n hw.ncpu"},
+	{"memtotal", "sysctl -n hw.memsize"},
+	{"vmstat", "vm_stat"},
+	{"disk", "df -Pk /"},
+	{"boottime", "sysctl -n kern.boottime"},
+	{"now", "date +%s"},
+	{"loadavg", "sysctl -n vm.loadavg"},
+})
+
+// OS returns MacOS
+func (darwinProbe) OS(run CommandRunner) (string, error) {
+	return "MacOS", nil
+}
+
+// Facts returns facts gathered from sysctl and vm_stat
+func (darwinProbe) Facts(run CommandRunner) (*Facts, error) {
+	sections, err := runFactsScript(run, darwinFactsScript)
+
+	if err != nil {
+		return nil, err
+	}
+
+	facts := commonFacts(sections)
+	facts.MemoryTotal = parseUint(sections["memtotal"])
+	facts.MemoryUsed = parseVMStat(facts.MemoryTotal, sections["vmstat"])
+	facts.Uptime = parseBootTime(sections["boottime"], sections["now"])
+
+	return facts, nil
+}
+
+// freebsdProbe implements the OSProbe interface for FreeBSD servers
+type freebsdProbe struct{}
+
+var freebsdFactsScript = factsScript([][2]string{
+	{"kernel", "uname -r"},
+	{"arch", "uname -m"},
+	{"cpus", "sysctl -n hw.ncpu"},
+	{"memtotal", "sysctl -n hw.physmem"},
+	{"pagesize", "sysctl -n hw.pagesize"},
+	{"freepages", "sysctl -n vm.stats.vm.v_free_count"},
+	{"inactivepages", "sysctl -n vm.stats.vm.v_inactive_count"},
+	{"disk", "df -Pk /"},
+	{"boottime", "sysctl -n kern.boottime"},
+	{"now", "date +%s"},
+	{"loadavg", "sysctl -n vm.loadavg"},
+})
+
+// OS returns FreeBSD
+func (freebsdProbe) OS(run CommandRunner) (string, error) {
+	return "FreeBSD", nil
+}
+
+// Facts returns facts gathered from sysctl
+func (freebsdProbe) Facts(run CommandRunner) (*Facts, error) {
+	sections, err := runFactsScript(run, freebsdFactsScript)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize := parseUint(sections["pagesize"])
+	available := (parseUint(sections["freepages"]) +
+		parseUint(sections["inactivepages"])) * pageSize
+
+	facts := commonFacts(sections)
+	facts.MemoryTotal = parseUint(sections["memtotal"])
+	facts.MemoryUsed = usedMemory(facts.MemoryTotal, available)
+	facts.Uptime = parseBootTime(sections["boottime"], sections["now"])
+
+	return facts, nil
+}
@@ -0,0 +1,38 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+
+	"github.com/robgonnella/ops/internal/config"
+)
+
+// ResolverScanner is an implementation of the DetailScanner interface that
+// looks up hostnames using a HostnameResolver. It is meant to be registered
+// in a DetailChain below the ssh providers so hostnames are still found for
+// servers we cannot log in to.
+type ResolverScanner struct {
+	resolver HostnameResolver
+}
+
+// NewResolverScanner returns a new instance of ResolverScanner
+func NewResolverScanner(resolver HostnameResolver) *ResolverScanner {
+	return &ResolverScanner{resolver: resolver}
+}
+
+// GetServerDetails returns the resolved hostname for the given ip. Not
+// finding a hostname is not an error so it doesn't hide the reason higher
+// priority providers failed.
+func (s ResolverScanner) GetServerDetails(ctx context.Context, ip string, creds config.SSHCredentials) (*Details, error) {
+	hostname, source, err := s.resolver.Resolve(ctx, ip)
+
+	if errors.Is(err, ErrHostnameNotFound) {
+		return &Details{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Details{Hostname: hostname, HostnameSource: source}, nil
+}
@@ -0,0 +1,405 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// HostnameSource represents where a hostname was discovered
+type HostnameSource string
+
+const (
+	// HostnameSourceSSH hostname reported by the server over ssh
+	HostnameSourceSSH HostnameSource = "ssh"
+	// HostnameSourcePTR hostname found using a reverse DNS lookup
+	HostnameSourcePTR HostnameSource = "ptr"
+	// HostnameSourceMDNS hostname found using a multicast DNS query
+	HostnameSourceMDNS HostnameSource = "mdns"
+	// HostnameSourceNetBIOS hostname found using a NetBIOS name query
+	HostnameSourceNetBIOS HostnameSource = "netbios"
+)
+
+const (
+	// DefaultResolveTimeout maximum time to wait for each resolution method
+	DefaultResolveTimeout = time.Second
+	// DefaultResolveCacheTTL how long resolved hostnames are cached
+	DefaultResolveCacheTTL = time.Minute * 10
+	// default port for multicast DNS
+	mdnsPort = 5353
+	// default port for the NetBIOS name service
+	netbiosPort = 137
+)
+
+// ErrHostnameNotFound returned when no resolution method found a hostname
+var ErrHostnameNotFound = errors.New("hostname not found")
+
+// resolution represents a cached, possibly in-flight, hostname resolution
+type resolution struct {
+	done    chan struct{}
+	name    string
+	source  HostnameSource
+	err     error
+	expires time.Time
+}
+
+// NetworkResolver is an implementation of the HostnameResolver interface
+// that tries reverse DNS, multicast DNS, and NetBIOS in that order
+type NetworkResolver struct {
+	ptrResolver *net.Resolver
+	mdnsPort    int
+	netbiosPort int
+	timeout     time.Duration
+	ttl         time.Duration
+	cache       map[string]*resolution
+	mux         sync.Mutex
+}
+
+// ResolverOption allows customizing a NetworkResolver
+type ResolverOption func(r *NetworkResolver)
+
+// WithPTRResolver sets the resolver used for reverse DNS lookups
+func WithPTRResolver(resolver *net.Resolver) ResolverOption {
+	return func(r *NetworkResolver) {
+		r.ptrResolver = resolver
+	}
+}
+
+// WithMDNSPort sets the port multicast DNS queries are sent to
+func WithMDNSPort(port int) ResolverOption {
+	return func(r *NetworkResolver) {
+		r.mdnsPort = port
+	}
+}
+
+// WithNetBIOSPort sets the port NetBIOS name queries are sent to
+func WithNetBIOSPort(port int) ResolverOption {
+	return func(r *NetworkResolver) {
+		r.netbiosPort = port
+	}
+}
+
+// WithResolveTimeout sets the maximum time to wait for each method
+func WithResolveTimeout(timeout time.Duration) ResolverOption {
+	return func(r *NetworkResolver) {
+		r.timeout = timeout
+	}
+}
+
+// NewHostnameResolver returns a new instance of NetworkResolver
+func NewHostnameResolver(opts ...ResolverOption) *NetworkResolver {
+	r := &NetworkResolver{
+		ptrResolver: net.DefaultResolver,
+		mdnsPort:    mdnsPort,
+		netbiosPort: netbiosPort,
+		timeout:     DefaultResolveTimeout,
+		ttl:         DefaultResolveCacheTTL,
+		cache:       map[string]*resolution{},
+	}
+
+	for _, o := range opts {
+		o(r)
+	}
+
+	return r
+}
+
+// Resolve returns the hostname for the given ip and the method that found
+// it. Results, including failures, are cached so repeated scans don't
+// flood the network with queries. Concurrent calls for the same ip share
+// a single resolution.
+func (r *NetworkResolver) Resolve(ctx context.Context, ip string) (string, HostnameSource, error) {
+	r.mux.Lock()
+
+	res, ok := r.cache[ip]
+
+	if ok {
+		select {
+		case <-res.done:
+			if time.Now().After(res.expires) {
+				ok = false
+			}
+		default:
+		}
+	}
+
+	if !ok {
+		res = &resolution{done: make(chan struct{})}
+		r.cache[ip] = res
+		r.mux.Unlock()
+
+		res.name, res.source, res.err = r.resolve(ctx, ip)
+		res.expires = time.Now().Add(r.ttl)
+
+		if ctx.Err() != nil {
+			// don't cache results cut short by cancellation
+			res.expires = time.Time{}
+		}
+
+		close(res.done)
+	} else {
+		r.mux.Unlock()
+	}
+
+	select {
+	case <-res.done:
+		return res.name, res.source, res.err
+	case <-ctx.Done():
+		return "", "", ctx.Err()
+	}
+}
+
+// tries each resolution method in order
+func (r *NetworkResolver) resolve(ctx context.Context, ip string) (string, HostnameSource, error) {
+	methods := []struct {
+		source HostnameSource
+		lookup func(ctx context.Context, ip string) (string, error)
+	}{
+		{HostnameSourcePTR, r.lookupPTR},
+		{HostnameSourceMDNS, r.lookupMDNS},
+		{HostnameSourceNetBIOS, r.lookupNetBIOS},
+	}
+
+	for _, m := range methods {
+		if ctx.Err() != nil {
+			return "", "", ctx.Err()
+		}
+
+		methodCtx, cancel := context.WithTimeout(ctx, r.timeout)
+		name, err := m.lookup(methodCtx, ip)
+		cancel()
+
+		if err == nil && name != "" {
+			return name, m.source, nil
+		}
+	}
+
+	return "", "", ErrHostnameNotFound
+}
+
+// performs a reverse DNS lookup
+func (r *NetworkResolver) lookupPTR(ctx context.Context, ip string) (string, error) {
+	names, err := r.ptrResolver.LookupAddr(ctx, ip)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(names) == 0 {
+		return "", ErrHostnameNotFound
+	}
+
+	return strings.TrimSuffix(names[0], "."), nil
+}
+
+// sends a unicast multicast-DNS reverse lookup query directly to the host
+func (r *NetworkResolver) lookupMDNS(ctx context.Context, ip string) (string, error) {
+	arpa, err := reverseAddr(ip)
+
+	if err != nil {
+		return "", err
+	}
+
+	name, err := dnsmessage.NewName(arpa)
+
+	if err != nil {
+		return "", err
+	}
+
+	id := uint16(rand.Intn(1 << 16))
+
+	query := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: id},
+		Questions: []dnsmessage.Question{
+			{
+				Name:  name,
+				Type:  dnsmessage.TypePTR,
+				Class: dnsmessage.ClassINET,
+			},
+		},
+	}
+
+	packet, err := query.Pack()
+
+	if err != nil {
+		return "", err
+	}
+
+	response, err := exchangeUDP(ctx, ip, r.mdnsPort, packet)
+
+	if err != nil {
+		return "", err
+	}
+
+	msg := dnsmessage.Message{}
+
+	if err := msg.Unpack(response); err != nil {
+		return "", err
+	}
+
+	for _, answer := range msg.Answers {
+		if ptr, ok := answer.Body.(*dnsmessage.PTRResource); ok {
+			return strings.TrimSuffix(ptr.PTR.String(), "."), nil
+		}
+	}
+
+	return "", ErrHostnameNotFound
+}
+
+// sends a NetBIOS node status request and returns the workstation name
+func (r *NetworkResolver) lookupNetBIOS(ctx context.Context, ip string) (string, error) {
+	response, err := exchangeUDP(ctx, ip, r.netbiosPort, netbiosStatusRequest())
+
+	if err != nil {
+		return "", err
+	}
+
+	return parseNetBIOSStatus(response)
+}
+
+// sends a single udp packet and waits for a single response
+func exchangeUDP(ctx context.Context, ip string, port int, packet []byte) ([]byte, error) {
+	dialer := net.Dialer{}
+
+	conn, err := dialer.DialContext(
+		ctx,
+		"udp",
+		net.JoinHostPort(ip, strconv.Itoa(port)),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := conn.Write(packet); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 1500)
+
+	n, err := conn.Read(buf)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return buf[:n], nil
+}
+
+// returns the in-addr.arpa name for an ipv4 address
+func reverseAddr(ip string) (string, error) {
+	parsed := net.ParseIP(ip).To4()
+
+	if parsed == nil {
+		return "", errors.New("invalid ipv4 address: " + ip)
+	}
+
+	return strconv.Itoa(int(parsed[3])) + "." +
+		strconv.Itoa(int(parsed[2])) + "." +
+		strconv.Itoa(int(parsed[1])) + "." +
+		strconv.Itoa(int(parsed[0])) + ".in-addr.arpa.", nil
+}
+
+// builds a NetBIOS node status (NBSTAT) request for the wildcard name
+func netbiosStatusRequest() []byte {
+	packet := []byte{
+		0x13, 0x37, // transaction id
+		0x00, 0x00, // flags
+		0x00, 0x01, // questions
+		0x00, 0x00, // answers
+		0x00, 0x00, // authority
+		0x00, 0x00, // additional
+		0x20, // encoded name length
+	}
+
+	// wildcard name "*" padded with nulls and first-level encoded
+	name := append([]byte{'*'}, make([]byte, 15)...)
+
+	for _, b := range name {
+		packet = append(packet, 'A'+(b>>4), 'A'+(b&0x0f))
+	}
+
+	return append(
+		packet,
+		0x00,       // name terminator
+		0x00, 0x21, // type NBSTAT
+		0x00, 0x01, // class IN
+	)
+}
+
+// parses a NetBIOS node status response returning the first unique
+// workstation name
+func parseNetBIOSStatus(response []byte) (string, error) {
+	errMalformed := errors.New("malformed netbios response")
+
+	// header
+	offset := 12
+
+	if len(response) < offset {
+		return "", errMalformed
+	}
+
+	// skip name which is either compressed or a sequence of labels
+	for offset < len(response) {
+		length := int(response[offset])
+
+		if length&0xc0 == 0xc0 {
+			offset += 2
+			break
+		}
+
+		offset++
+
+		if length == 0 {
+			break
+		}
+
+		offset += length
+	}
+
+	// type, class, ttl, rdlength
+	offset += 10
+
+	if offset >= len(response) {
+		return "", errMalformed
+	}
+
+	count := int(response[offset])
+	offset++
+
+	for i := 0; i < count; i++ {
+		if offset+18 > len(response) {
+			return "", errMalformed
+		}
+
+		entry := response[offset : offset+18]
+		offset += 18
+
+		suffix := entry[15]
+		flags := binary.BigEndian.Uint16(entry[16:18])
+		isGroup := flags&0x8000 != 0
+
+		if suffix == 0x00 && !isGroup {
+			return string(bytes.TrimRight(entry[:15], " \x00")), nil
+		}
+	}
+
+	return "", ErrHostnameNotFound
+}
@@ -0,0 +1,267 @@
+package discovery_test
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/robgonnella/ops/internal/discovery"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// loopback address that is not listed in /etc/hosts so lookups go to
+// our test servers
+const testResolveIP = "127.0.0.2"
+
+// starts a udp server on localhost that replies using the given handler.
+// Returns the server's port and a counter of received requests.
+func startUDPServer(t *testing.T, handler func(req []byte) []byte) (int, *atomic.Int32) {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", net.JoinHostPort(testResolveIP, "0"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	count := &atomic.Int32{}
+
+	go func() {
+		buf := make([]byte, 1500)
+
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+
+			if err != nil {
+				return
+			}
+
+			count.Add(1)
+
+			if res := handler(buf[:n]); res != nil {
+				conn.WriteTo(res, addr)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().(*net.UDPAddr).Port, count
+}
+
+// returns a handler that answers PTR queries with the given name or with
+// NXDOMAIN if name is empty
+func ptrHandler(t *testing.T, name string) func(req []byte) []byte {
+	return func(req []byte) []byte {
+		query := dnsmessage.Message{}
+
+		if err := query.Unpack(req); err != nil {
+			t.Error(err)
+			return nil
+		}
+
+		res := dnsmessage.Message{
+			Header: dnsmessage.Header{
+				ID:            query.ID,
+				Response:      true,
+				Authoritative: true,
+				RCode:         dnsmessage.RCodeNameError,
+			},
+			Questions: query.Questions,
+		}
+
+		if name != "" {
+			res.RCode = dnsmessage.RCodeSuccess
+			res.Answers = []dnsmessage.Resource{
+				{
+					Header: dnsmessage.ResourceHeader{
+						Name:  query.Questions[0].Name,
+						Type:  dnsmessage.TypePTR,
+						Class: dnsmessage.ClassINET,
+						TTL:   60,
+					},
+					Body: &dnsmessage.PTRResource{
+						PTR: dnsmessage.MustNewName(name),
+					},
+				},
+			}
+		}
+
+		packet, err := res.Pack()
+
+		if err != nil {
+			t.Error(err)
+			return nil
+		}
+
+		return packet
+	}
+}
+
+// returns a handler that answers NetBIOS node status requests
+func netbiosHandler(names ...[]byte) func(req []byte) []byte {
+	return func(req []byte) []byte {
+		res := append([]byte{}, req[:2]...)
+		res = append(res,
+			0x84, 0x00, // flags
+			0x00, 0x00, // questions
+			0x00, 0x01, // answers
+			0x00, 0x00, // authority
+			0x00, 0x00, // additional
+		)
+
+		// echo question name
+		res = append(res, req[12:46]...)
+		res = append(res, 0x00, 0x21, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00)
+
+		rdata := []byte{byte(len(names))}
+
+		for _, n := range names {
+			rdata = append(rdata, n...)
+		}
+
+		rdlength := make([]byte, 2)
+		binary.BigEndian.PutUint16(rdlength, uint16(len(rdata)))
+
+		res = append(res, rdlength...)
+
+		return append(res, rdata...)
+	}
+}
+
+// builds a NetBIOS name table entry
+func netbiosName(name string, suffix byte, group bool) []byte {
+	entry := []byte(name)
+
+	for len(entry) < 15 {
+		entry = append(entry, ' ')
+	}
+
+	entry = append(entry, suffix)
+
+	if group {
+		return append(entry, 0x84, 0x00)
+	}
+
+	return append(entry, 0x04, 0x00)
+}
+
+// returns a resolver that sends all DNS queries to the given port
+func testPTRResolver(port int) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			d := net.Dialer{}
+			return d.DialContext(ctx, "udp", net.JoinHostPort(testResolveIP, strconv.Itoa(port)))
+		},
+	}
+}
+
+// returns a port with nothing listening on it
+func closedPort(t *testing.T) int {
+	conn, err := net.ListenPacket("udp", net.JoinHostPort(testResolveIP, "0"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+
+	conn.Close()
+
+	return port
+}
+
+func TestHostnameResolver(t *testing.T) {
+	t.Run("resolves using reverse dns", func(st *testing.T) {
+		dnsPort, _ := startUDPServer(st, ptrHandler(st, "server.lan."))
+
+		resolver := discovery.NewHostnameResolver(
+			discovery.WithPTRResolver(testPTRResolver(dnsPort)),
+			discovery.WithMDNSPort(closedPort(st)),
+			discovery.WithNetBIOSPort(closedPort(st)),
+		)
+
+		name, source, err := resolver.Resolve(context.Background(), testResolveIP)
+
+		assert.NoError(st, err)
+		assert.Equal(st, "server.lan", name)
+		assert.Equal(st, discovery.HostnameSourcePTR, source)
+	})
+
+	t.Run("resolves using multicast dns", func(st *testing.T) {
+		dnsPort, _ := startUDPServer(st, ptrHandler(st, ""))
+		mdnsPort, _ := startUDPServer(st, ptrHandler(st, "printer.local."))
+
+		resolver := discovery.NewHostnameResolver(
+			discovery.WithPTRResolver(testPTRResolver(dnsPort)),
+			discovery.WithMDNSPort(mdnsPort),
+			discovery.WithNetBIOSPort(closedPort(st)),
+		)
+
+		name, source, err := resolver.Resolve(context.Background(), testResolveIP)
+
+		assert.NoError(st, err)
+		assert.Equal(st, "printer.local", name)
+		assert.Equal(st, discovery.HostnameSourceMDNS, source)
+	})
+
+	t.Run("resolves using netbios", func(st *testing.T) {
+		dnsPort, _ := startUDPServer(st, ptrHandler(st, ""))
+		netbiosPort, _ := startUDPServer(st, netbiosHandler(
+			netbiosName("WORKGROUP", 0x00, true),
+			netbiosName("DESKTOP-1", 0x20, false),
+			netbiosName("DESKTOP-1", 0x00, false),
+		))
+
+		resolver := discovery.NewHostnameResolver(
+			discovery.WithPTRResolver(testPTRResolver(dnsPort)),
+			discovery.WithMDNSPort(closedPort(st)),
+			discovery.WithNetBIOSPort(netbiosPort),
+		)
+
+		name, source, err := resolver.Resolve(context.Background(), testResolveIP)
+
+		assert.NoError(st, err)
+		assert.Equal(st, "DESKTOP-1", name)
+		assert.Equal(st, discovery.HostnameSourceNetBIOS, source)
+	})
+
+	t.Run("returns error when hostname not found", func(st *testing.T) {
+		dnsPort, _ := startUDPServer(st, ptrHandler(st, ""))
+
+		resolver := discovery.NewHostnameResolver(
+			discovery.WithPTRResolver(testPTRResolver(dnsPort)),
+			discovery.WithMDNSPort(closedPort(st)),
+			discovery.WithNetBIOSPort(closedPort(st)),
+			discovery.WithResolveTimeout(time.Millisecond*200),
+		)
+
+		_, _, err := resolver.Resolve(context.Background(), testResolveIP)
+
+		assert.ErrorIs(st, err, discovery.ErrHostnameNotFound)
+	})
+
+	t.Run("caches results", func(st *testing.T) {
+		dnsPort, count := startUDPServer(st, ptrHandler(st, "server.lan."))
+
+		resolver := discovery.NewHostnameResolver(
+			discovery.WithPTRResolver(testPTRResolver(dnsPort)),
+		)
+
+		for i := 0; i < 3; i++ {
+			name, _, err := resolver.Resolve(context.Background(), testResolveIP)
+			assert.NoError(st, err)
+			assert.Equal(st, "server.lan", name)
+		}
+
+		assert.Equal(st, int32(1), count.Load())
+	})
+}
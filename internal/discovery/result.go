@@ -1,5 +1,12 @@
 package discovery
 
+import (
+	"slices"
+	"time"
+
+	"github.com/robgonnella/ops/internal/config"
+)
+
 // ServerStatus represents possible server statuses
 type ServerStatus string
 
@@ -22,25 +29,104 @@ const (
 
 // Port data structure representing a server port
 type Port struct {
-	ID     uint16
-	Status PortStatus
+	ID     uint16     `json:"id"`
+	Status PortStatus `json:"status"`
 }
 
 // nolint:revive
-// DiscoveryResult represents our discovered device on the network
+// DiscoveryResult represents our discovered device on the network. ConfigID
+// is the id of the config used by the scan that found the device.
 type DiscoveryResult struct {
-	Type     string
-	ID       string
-	Hostname string
-	IP       string
-	OS       string
-	Vendor   string
-	Status   ServerStatus
-	Port     Port
+	Type           string
+	ConfigID       string
+	ID             string
+	Hostname       string
+	HostnameSource HostnameSource
+	IP             string
+	OS             string
+	Vendor         string
+	Status         ServerStatus
+	Port           Port
+	OpenPorts      []uint16
+	Banners        []Banner
+	HostKey        *HostKey
+	DetailError    string
+	Facts          *Facts
+}
+
+// SSHTarget returns what is known about the result's host for matching
+// ssh overrides
+func (r DiscoveryResult) SSHTarget() config.SSHTarget {
+	hostname := r.Hostname
+
+	if hostname == "Unknown" {
+		hostname = ""
+	}
+
+	return config.SSHTarget{
+		IP:       r.IP,
+		MAC:      r.ID,
+		Hostname: hostname,
+		Vendor:   r.Vendor,
+	}
+}
+
+// ActionTarget returns what is known about the result's host for checking
+// custom action conditions. The ssh port is included in the open ports when
+// ssh is enabled.
+func (r DiscoveryResult) ActionTarget() config.ActionTarget {
+	target := r.SSHTarget()
+
+	openPorts := slices.Clone(r.OpenPorts)
+	ssh := r.Port.Status == PortOpen
+
+	if ssh && !slices.Contains(openPorts, r.Port.ID) {
+		openPorts = append(openPorts, r.Port.ID)
+	}
+
+	return config.ActionTarget{
+		IP:        target.IP,
+		MAC:       target.MAC,
+		Hostname:  target.Hostname,
+		Vendor:    target.Vendor,
+		OS:        r.OS,
+		Online:    r.Status == ServerOnline,
+		SSH:       ssh,
+		OpenPorts: openPorts,
+	}
 }
 
 // Details represents the details returned by DetailScanner
 type Details struct {
-	Hostname string
-	OS       string
+	Hostname       string
+	HostnameSource HostnameSource
+	OS             string
+	Facts          *Facts
+	// Errors returned by providers in a DetailChain that failed while
+	// others succeeded, in priority order
+	Errors []error
+}
+
+// Facts represents resource and system information gathered from a server.
+// Memory and disk values are in bytes.
+type Facts struct {
+	Kernel      string        `json:"kernel"`
+	Arch        string        `json:"arch"`
+	CPUCount    int           `json:"cpuCount"`
+	MemoryTotal uint64        `json:"memoryTotal"`
+	MemoryUsed  uint64        `json:"memoryUsed"`
+	DiskTotal   uint64        `json:"diskTotal"`
+	DiskUsed    uint64        `json:"diskUsed"`
+	Uptime      time.Duration `json:"uptime"`
+	LoadAverage [3]float64    `json:"loadAverage"`
+}
+
+// ScanCycle represents the payload for scan started and completed events
+// for the scan using the config with id ConfigID
+type ScanCycle struct {
+	ConfigID     string
+	StartedAt    time.Time
+	Duration     time.Duration
+	HostsFound   int
+	HostsOffline int
 }
@@ -2,13 +2,17 @@ package discovery
 
 import (
 	"context"
+	"errors"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/robgonnella/go-lanscan/pkg/scanner"
 	"github.com/robgonnella/ops/internal/config"
 	"github.com/robgonnella/ops/internal/event"
 	"github.com/robgonnella/ops/internal/logger"
+	"github.com/robgonnella/ops/internal/sshclient"
 )
 
 const (
@@ -16,20 +20,74 @@ const (
 	ArpUpdateEvent = "DISCOVERY_ARP_UPDATE"
 	// SynUpdateEvent represents an SYN update event
 	SynUpdateEvent = "DISCOVERY_SYN_UPDATE"
+	// HostOfflineEvent represents a host that has stopped answering ARP
+	HostOfflineEvent = "DISCOVERY_HOST_OFFLINE"
+	// HostReturnedEvent represents a previously offline host answering again
+	HostReturnedEvent = "DISCOVERY_HOST_RETURNED"
+	// ScanStartedEvent represents the start of a network scan
+	ScanStartedEvent = "SCAN_STARTED"
+	// ScanCompletedEvent represents the completion of a network scan
+	ScanCompletedEvent = "SCAN_COMPLETED"
+	// HostnameResolvedEvent represents a hostname found without using ssh
+	HostnameResolvedEvent = "DISCOVERY_HOSTNAME_RESOLVED"
+	// PortUpdateEvent represents a status update for a non-ssh service port
+	PortUpdateEvent = "DISCOVERY_PORT_UPDATE"
+	// HostKeyChangedEvent represents a server presenting a different ssh
+	// host key than the one previously recorded
+	HostKeyChangedEvent = "HOST_KEY_CHANGED"
 )
 
-// ScannerService implements the Service interface for monitoring a network
+// ErrNotMonitoring returned when requesting a scan while the network is
+// not being monitored
+var ErrNotMonitoring = errors.New("network monitoring is not running")
+
+// ErrMonitoring returned when requesting a single scan while the network
+// is already being monitored
+var ErrMonitoring = errors.New("network monitoring is already running")
+
+// ErrScanInProgress returned when requesting a scan while another scan is
+// still running
+var ErrScanInProgress = errors.New("network scan is already running")
+
+// ScannerService implements the Service interface for monitoring a network.
+// The config, scanners, and tracker may be replaced while result handlers
+// are still running so they are guarded by mux.
 type ScannerService struct {
-	ctx           context.Context
-	cancel        context.CancelFunc
-	conf          config.Config
-	scanner       Scanner
-	detailScanner DetailScanner
-	pauseChan     chan struct{}
-	eventManager  event.Manager
-	errorChan     chan error
-	monitoring    bool
-	log           logger.Logger
+	ctx              context.Context
+	cancel           context.CancelFunc
+	mux              sync.RWMutex
+	conf             config.Config
+	scanner          Scanner
+	detailScanner    DetailScanner
+	resolver         HostnameResolver
+	bannerGrabber    BannerGrabber
+	hostKeyScanner   HostKeyScanner
+	tracker          *hostTracker
+	banners          *bannerStore
+	targets          *sshTargetStore
+	pauseChan        chan struct{}
+	eventManager     event.Manager
+	errorChan        chan error
+	scanCompleteChan chan time.Time
+	scanNowChan      chan struct{}
+	monitoring       atomic.Bool
+	scanning         atomic.Bool
+	handlers         sync.WaitGroup
+	collector        atomic.Pointer[resultCollector]
+	log              logger.Logger
+}
+
+// resultCollector records every result sent during a single scan
+type resultCollector struct {
+	results []DiscoveryResult
+	mux     sync.Mutex
+}
+
+// records a sent result
+func (c *resultCollector) add(result DiscoveryResult) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.results = append(c.results, result)
 }
 
 // NewScannerService returns a new instance of ScannerService
@@ -37,6 +95,9 @@ func NewScannerService(
 	conf config.Config,
 	scanner Scanner,
 	detailScanner DetailScanner,
+	resolver HostnameResolver,
+	bannerGrabber BannerGrabber,
+	hostKeyScanner HostKeyScanner,
 	eventManager event.Manager,
 ) *ScannerService {
 	log := logger.New()
@@ -45,16 +106,23 @@ func NewScannerService(
 	ctxWithCancel, cancel := context.WithCancel(context.Background())
 
 	return &ScannerService{
-		ctx:           ctxWithCancel,
-		cancel:        cancel,
-		conf:          conf,
-		scanner:       scanner,
-		detailScanner: detailScanner,
-		eventManager:  eventManager,
-		errorChan:     make(chan error),
-		pauseChan:     make(chan struct{}),
-		monitoring:    false,
-		log:           log,
+		ctx:              ctxWithCancel,
+		cancel:           cancel,
+		conf:             conf,
+		scanner:          scanner,
+		detailScanner:    detailScanner,
+		resolver:         resolver,
+		bannerGrabber:    bannerGrabber,
+		hostKeyScanner:   hostKeyScanner,
+		tracker:          newHostTracker(),
+		banners:          newBannerStore(),
+		targets:          newSSHTargetStore(),
+		eventManager:     eventManager,
+		errorChan:        make(chan error),
+		scanCompleteChan: make(chan time.Time),
+		scanNowChan:      make(chan struct{}),
+		pauseChan:        make(chan struct{}),
+		log:              log,
 	}
 }
 
@@ -64,49 +132,154 @@ func (s *ScannerService) MonitorNetwork() error {
 	return s.pollNetwork()
 }
 
+// Scan triggers an immediate network scan outside of the regular interval
+func (s *ScannerService) Scan() error {
+	if !s.monitoring.Load() {
+		return ErrNotMonitoring
+	}
+
+	if !s.scanning.CompareAndSwap(false, true) {
+		return ErrScanInProgress
+	}
+
+	select {
+	case s.scanNowChan <- struct{}{}:
+		return nil
+	case <-s.ctx.Done():
+		s.scanning.Store(false)
+		return s.ctx.Err()
+	}
+}
+
+// ScanOnce performs a single network scan without monitoring and returns
+// every result sent during the scan in the order they were sent. It blocks
+// until the scan and all detail gathering for discovered hosts completes.
+// ScanOnce may be called repeatedly to track hosts across scans but must
+// not be called while monitoring the network.
+func (s *ScannerService) ScanOnce() ([]DiscoveryResult, error) {
+	if s.monitoring.Load() {
+		return nil, ErrMonitoring
+	}
+
+	if !s.scanning.CompareAndSwap(false, true) {
+		return nil, ErrScanInProgress
+	}
+
+	collector := &resultCollector{results: []DiscoveryResult{}}
+
+	s.collector.Store(collector)
+	defer s.collector.Store(nil)
+
+	go s.scan()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return nil, s.ctx.Err()
+		case r := <-s.netScanner().Results():
+			s.handleScanResult(r)
+		case err := <-s.errorChan:
+			s.scanning.Store(false)
+			s.handlers.Wait()
+			return nil, err
+		case startedAt := <-s.scanCompleteChan:
+			s.scanning.Store(false)
+			cycle := s.completeCycle(startedAt)
+
+			// wait for details and offline hosts before completing
+			s.handlers.Wait()
+			s.sendScanEvent(ScanCompletedEvent, cycle)
+
+			collector.mux.Lock()
+			defer collector.mux.Unlock()
+
+			return collector.results, nil
+		}
+	}
+}
+
 // Stop stop network discover. Once called this service will be useless.
 // A new one must be instantiated to continue
 func (s *ScannerService) Stop() {
 	s.cancel()
-	s.scanner.Stop()
+	s.netScanner().Stop()
 }
 
-// SetConfigAndScanner sets the config and scanner to use when performing network discovery
-func (s *ScannerService) SetConfigAndScanner(conf config.Config, netScanner Scanner) {
-	if s.monitoring {
+// SetConfigAndScanner sets the config and scanners to use when performing network discovery
+func (s *ScannerService) SetConfigAndScanner(
+	conf config.Config,
+	netScanner Scanner,
+	detailScanner DetailScanner,
+) {
+	if s.monitoring.Load() {
 		s.pause()
 		defer func() {
 			go func() {
-				if err := s.pollNetwork(); err != nil {
+				// stopping the service is not an error
+				if err := s.pollNetwork(); err != nil && s.ctx.Err() == nil {
 					s.eventManager.ReportFatalError(err)
 				}
 			}()
 		}()
 	}
+
+	s.mux.Lock()
+	previous := s.scanner
 	s.conf = conf
 	s.scanner = netScanner
+	s.detailScanner = detailScanner
+	s.tracker = newHostTracker()
+	s.mux.Unlock()
+
+	s.banners.reset()
+
+	if previous != netScanner {
+		previous.Stop()
+	}
+}
+
+// returns the config currently in use
+func (s *ScannerService) config() config.Config {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.conf
+}
+
+// returns the network scanner currently in use
+func (s *ScannerService) netScanner() Scanner {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.scanner
+}
+
+// returns the server detail scanner currently in use
+func (s *ScannerService) serverDetailScanner() DetailScanner {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.detailScanner
+}
+
+// returns the host tracker for the config currently in use
+func (s *ScannerService) hostTracker() *hostTracker {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.tracker
 }
 
 // private
 // make polling calls to scanner.Scan()
 func (s *ScannerService) pollNetwork() error {
-	ticker := time.NewTicker(time.Second * 30)
+	ticker := time.NewTicker(s.config().Scan.GetInterval())
 
 	defer func() {
 		ticker.Stop()
-		s.monitoring = false
+		s.monitoring.Store(false)
 	}()
 
 	// start first scan
-	// always scan in goroutine to prevent blocking result channel
-	go func() {
-		s.log.Info().Msg("starting network scan")
-		if err := s.scanner.Scan(); err != nil {
-			s.errorChan <- err
-		}
-	}()
+	s.startScan()
 
-	s.monitoring = true
+	s.monitoring.Store(true)
 
 	for {
 		select {
@@ -117,75 +290,192 @@ func (s *ScannerService) pollNetwork() error {
 		case <-s.pauseChan:
 			s.pauseChan <- struct{}{}
 			return nil
-		case r := <-s.scanner.Results():
-			switch r.Type {
-			case scanner.ARPResult:
-				res := r.Payload.(*scanner.ArpScanResult)
-				dr := &DiscoveryResult{
-					Type:     ArpUpdateEvent,
-					ID:       res.MAC.String(),
-					IP:       res.IP.String(),
-					Hostname: "Unknown",
-					OS:       "Unknown",
-					Vendor:   res.Vendor,
-					Status:   ServerOnline,
-					Port: Port{
-						ID:     22,
-						Status: PortClosed,
-					},
-				}
-				go s.handleArpDiscoveryResult(dr)
-			case scanner.SYNResult:
-				res := r.Payload.(*scanner.SynScanResult)
-				dr := &DiscoveryResult{
-					Type:     SynUpdateEvent,
-					ID:       res.MAC.String(),
-					IP:       res.IP.String(),
-					Hostname: "",
-					OS:       "",
-					Vendor:   "",
-					Status:   ServerStatus(res.Status),
-					Port: Port{
-						ID:     res.Port.ID,
-						Status: PortStatus(res.Port.Status),
-					},
-				}
-				go s.handleSynDiscoveryResult(dr)
-			}
+		case r := <-s.netScanner().Results():
+			s.handleScanResult(r)
 		case err := <-s.errorChan:
+			s.scanning.Store(false)
 			s.log.Error().Err(err).Msg("discovery service encountered an error")
 			s.eventManager.ReportFatalError(err)
 			return err
+		case startedAt := <-s.scanCompleteChan:
+			s.scanning.Store(false)
+			go s.sendScanEvent(ScanCompletedEvent, s.completeCycle(startedAt))
 		case <-ticker.C:
-			// always scan in goroutine to prevent blocking result channel
-			go func() {
-				s.log.Info().Msg("starting network scan")
-				if err := s.scanner.Scan(); err != nil {
-					s.errorChan <- err
-				}
-			}()
+			s.startScan()
+		case <-s.scanNowChan:
+			// already marked as scanning by Scan
+			go s.scan()
 		}
 	}
 }
 
-func (s *ScannerService) getConfiguredSSHPort(result *DiscoveryResult) *string {
-	resultStrPort := strconv.Itoa(int(result.Port.ID))
-	sshPort := s.conf.SSH.Port
+// handles a single result from the network scanner
+func (s *ScannerService) handleScanResult(r *scanner.ScanResult) {
+	conf := s.config()
+	tracker := s.hostTracker()
+
+	switch r.Type {
+	case scanner.ARPResult:
+		res := r.Payload.(*scanner.ArpScanResult)
+		dr := &DiscoveryResult{
+			Type:     ArpUpdateEvent,
+			ConfigID: conf.ID,
+			ID:       res.MAC.String(),
+			IP:       res.IP.String(),
+			Hostname: "Unknown",
+			OS:       "Unknown",
+			Vendor:   res.Vendor,
+			Status:   ServerOnline,
+			Port: Port{
+				ID:     22,
+				Status: PortClosed,
+			},
+		}
+		if tracker.markSeen(*dr) {
+			returned := *dr
+			returned.Type = HostReturnedEvent
+			s.spawn(func() { s.handleHostStatusChange(returned) })
+		}
+		s.spawn(func() { s.handleArpDiscoveryResult(dr) })
+	case scanner.SYNResult:
+		res := r.Payload.(*scanner.SynScanResult)
+		dr := &DiscoveryResult{
+			Type:     SynUpdateEvent,
+			ConfigID: conf.ID,
+			ID:       res.MAC.String(),
+			IP:       res.IP.String(),
+			Hostname: "",
+			OS:       "",
+			Vendor:   "",
+			Status:   ServerStatus(res.Status),
+			Port: Port{
+				ID:     res.Port.ID,
+				Status: PortStatus(res.Port.Status),
+			},
+		}
+		dr.OpenPorts = tracker.updatePort(
+			dr.ID,
+			dr.Port.ID,
+			dr.Port.Status == PortOpen,
+		)
+		s.spawn(func() { s.handleSynDiscoveryResult(dr) })
+	}
+}
 
-	for _, o := range s.conf.SSH.Overrides {
-		if result.IP == o.Target {
-			if o.Port != "" {
-				sshPort = o.Port
-			}
-			break
+// ends the current scan cycle marking hosts that did not answer as offline
+// and returns a summary of the cycle
+func (s *ScannerService) completeCycle(startedAt time.Time) ScanCycle {
+	conf := s.config()
+	tracker := s.hostTracker()
+
+	found, _ := tracker.counts()
+
+	for _, r := range tracker.endCycle(conf.Scan.GetOfflineAfter()) {
+		r := r
+		r.Type = HostOfflineEvent
+		r.Status = ServerOffline
+		s.spawn(func() { s.handleHostStatusChange(r) })
+	}
+
+	_, offline := tracker.counts()
+
+	return ScanCycle{
+		ConfigID:     conf.ID,
+		StartedAt:    startedAt,
+		Duration:     time.Since(startedAt),
+		HostsFound:   found,
+		HostsOffline: offline,
+	}
+}
+
+// runs a result handler in a goroutine tracking it so single scans can
+// wait for all handlers to finish
+func (s *ScannerService) spawn(handler func()) {
+	s.handlers.Add(1)
+
+	go func() {
+		defer s.handlers.Done()
+		handler()
+	}()
+}
+
+// notifies listeners of a discovery result and records it when performing
+// a single scan
+func (s *ScannerService) sendResult(result DiscoveryResult) {
+	if collector := s.collector.Load(); collector != nil {
+		collector.add(result)
+	}
+
+	s.eventManager.Send(
+		event.Event{
+			Type:    event.EventType(result.Type),
+			Payload: result,
+		},
+	)
+}
+
+// starts a scan unless one is already running. Scans always run in a
+// goroutine to prevent blocking the result channel.
+func (s *ScannerService) startScan() {
+	if !s.scanning.CompareAndSwap(false, true) {
+		s.log.Debug().Msg("skipping network scan - previous scan still running")
+		return
+	}
+
+	go s.scan()
+}
+
+// performs a single network scan and signals completion so we can determine
+// which hosts did not answer during this cycle. Must only be called once
+// the service has been marked as scanning - the mark is cleared when the
+// completion or error is received.
+func (s *ScannerService) scan() {
+	s.log.Info().Msg("starting network scan")
+
+	startedAt := time.Now()
+
+	s.sendScanEvent(ScanStartedEvent, ScanCycle{
+		ConfigID:  s.config().ID,
+		StartedAt: startedAt,
+	})
+
+	if err := s.netScanner().Scan(); err != nil {
+		select {
+		case s.errorChan <- err:
+		case <-s.ctx.Done():
 		}
+		return
 	}
 
-	if resultStrPort != sshPort {
-		return nil
+	select {
+	case s.scanCompleteChan <- startedAt:
+	case <-s.ctx.Done():
+	}
+}
+
+// notifies listeners of scan progress
+func (s *ScannerService) sendScanEvent(eventType string, cycle ScanCycle) {
+	if eventType == ScanCompletedEvent {
+		s.log.Info().
+			Dur("duration", cycle.Duration).
+			Int("hostsFound", cycle.HostsFound).
+			Int("hostsOffline", cycle.HostsOffline).
+			Msg("network scan complete")
 	}
 
-	return &sshPort
+	s.eventManager.Send(
+		event.Event{
+			Type:    event.EventType(eventType),
+			Payload: cycle,
+		},
+	)
+}
+
+// returns the ssh credentials resolved for the result's host and whether
+// the result's port is the ssh port configured for that host
+func (s *ScannerService) sshCredentials(result *DiscoveryResult) (config.SSHCredentials, bool) {
+	creds := s.config().SSHCredentials(s.targets.update(*result))
+	return creds, strconv.Itoa(int(result.Port.ID)) == creds.Port
 }
 
 func (s *ScannerService) handleArpDiscoveryResult(result *DiscoveryResult) {
@@ -204,12 +494,30 @@ func (s *ScannerService) handleArpDiscoveryResult(result *DiscoveryResult) {
 
 	s.log.Info().Fields(fields).Msg("found network device")
 
-	s.eventManager.Send(
-		event.Event{
-			Type:    event.EventType(result.Type),
-			Payload: *result,
-		},
-	)
+	s.targets.update(*result)
+
+	s.sendResult(*result)
+
+	hostname, source, err := s.resolver.Resolve(s.ctx, result.IP)
+
+	if err != nil {
+		return
+	}
+
+	resolved := *result
+	resolved.Type = HostnameResolvedEvent
+	resolved.Hostname = hostname
+	resolved.HostnameSource = source
+
+	s.targets.update(resolved)
+
+	s.log.Info().
+		Str("ip", resolved.IP).
+		Str("hostname", hostname).
+		Str("source", string(source)).
+		Msg("resolved hostname")
+
+	s.sendResult(resolved)
 }
 
 // handle results found during polling
@@ -231,24 +539,47 @@ func (s *ScannerService) handleSynDiscoveryResult(result *DiscoveryResult) {
 
 	s.log.Info().Fields(fields).Msg("found network device")
 
-	sshPort := s.getConfiguredSSHPort(result)
+	result.Banners = s.grabBanner(result)
+
+	creds, isSSHPort := s.sshCredentials(result)
 
-	if sshPort == nil {
-		s.log.Info().Fields(fields).Msg("ignoring non-ssh port result")
+	if !isSSHPort {
+		s.handlePortUpdate(result)
 		return
 	}
 
 	if result.Port.Status == PortOpen {
-		details, err := s.detailScanner.GetServerDetails(
+		hostKey, err := s.hostKeyScanner.ScanHostKey(s.ctx, result.IP, creds.Port)
+
+		if err == nil {
+			result.HostKey = hostKey
+		} else {
+			s.log.
+				Error().Err(err).
+				Str("ip", result.IP).
+				Msg("host key scan failed")
+		}
+
+		details, err := s.serverDetailScanner().GetServerDetails(
 			s.ctx,
 			result.IP,
-			*sshPort,
+			creds,
 		)
 
 		if err == nil {
 			result.Hostname = details.Hostname
+			result.HostnameSource = details.HostnameSource
 			result.OS = details.OS
-		} else {
+			result.Facts = details.Facts
+
+			// report why higher priority providers failed
+			if len(details.Errors) > 0 {
+				err = details.Errors[0]
+			}
+		}
+
+		if err != nil {
+			result.DetailError = sshclient.Reason(err)
 			s.log.
 				Error().Err(err).
 				Str("ip", result.IP).
@@ -264,12 +595,63 @@ func (s *ScannerService) handleSynDiscoveryResult(result *DiscoveryResult) {
 		result.OS = "Unknown"
 	}
 
-	s.eventManager.Send(
-		event.Event{
-			Type:    event.EventType(result.Type),
-			Payload: *result,
-		},
-	)
+	s.targets.update(*result)
+
+	s.sendResult(*result)
+}
+
+// grabs the banner for the result's port the first time it is seen open and
+// returns all known banners for the host
+func (s *ScannerService) grabBanner(result *DiscoveryResult) []Banner {
+	if result.Port.Status != PortOpen {
+		return s.banners.remove(result.ID, result.Port.ID)
+	}
+
+	if !s.banners.claim(result.ID, result.Port.ID) {
+		return s.banners.get(result.ID)
+	}
+
+	banner, err := s.bannerGrabber.Grab(s.ctx, result.IP, result.Port.ID)
+
+	if err != nil {
+		s.log.Debug().
+			Err(err).
+			Str("ip", result.IP).
+			Uint16("port", result.Port.ID).
+			Msg("failed to grab banner")
+
+		banner = nil
+	}
+
+	return s.banners.set(result.ID, result.Port.ID, banner)
+}
+
+// notifies listeners of status changes for non-ssh service ports
+func (s *ScannerService) handlePortUpdate(result *DiscoveryResult) {
+	result.Type = PortUpdateEvent
+
+	s.log.Debug().
+		Str("ip", result.IP).
+		Uint16("port", result.Port.ID).
+		Str("status", string(result.Port.Status)).
+		Msg("service port update")
+
+	s.sendResult(*result)
+}
+
+// handle hosts going offline or coming back online
+func (s *ScannerService) handleHostStatusChange(result DiscoveryResult) {
+	fields := map[string]interface{}{
+		"type":     result.Type,
+		"id":       result.ID,
+		"hostname": result.Hostname,
+		"ip":       result.IP,
+		"status":   result.Status,
+	}
+
+	s.log.Info().Fields(fields).Msg("network device status changed")
+
+	s.sendResult(result)
 }
 
 func (s *ScannerService) pause() {
@@ -1,9 +1,13 @@
 package discovery_test
 
 import (
+	"context"
+	"errors"
 	"net"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/robgonnella/go-lanscan/pkg/scanner"
 	"github.com/robgonnella/ops/internal/config"
@@ -16,6 +20,22 @@ import (
 	"go.uber.org/mock/gomock"
 )
 
+// matches scan lifecycle events which are sent for every scan
+func isScanEvent() gomock.Matcher {
+	return gomock.Cond(func(x any) bool {
+		evt := x.(event.Event)
+		return evt.Type == discovery.ScanStartedEvent ||
+			evt.Type == discovery.ScanCompletedEvent
+	})
+}
+
+// matches events of the given type
+func isEventType(eventType string) gomock.Matcher {
+	return gomock.Cond(func(x any) bool {
+		return x.(event.Event).Type == event.EventType(eventType)
+	})
+}
+
 func TestDiscoveryService(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
@@ -39,8 +59,18 @@ func TestDiscoveryService(t *testing.T) {
 	t.Run("monitors network for offline servers", func(st *testing.T) {
 		mockScanner := mock_discovery.NewMockScanner(ctrl)
 		mockDetailScanner := mock_discovery.NewMockDetailScanner(ctrl)
+		mockResolver := mock_discovery.NewMockHostnameResolver(ctrl)
+		mockGrabber := mock_discovery.NewMockBannerGrabber(ctrl)
+		mockHostKeyScanner := mock_discovery.NewMockHostKeyScanner(ctrl)
 		mockEventManager := mock_event.NewMockManager(ctrl)
 
+		mockEventManager.EXPECT().Send(isScanEvent()).AnyTimes()
+
+		mockResolver.EXPECT().
+			Resolve(gomock.Any(), gomock.Any()).
+			Return("", discovery.HostnameSource(""), discovery.ErrHostnameNotFound).
+			AnyTimes()
+
 		resultChan := make(chan *scanner.ScanResult)
 
 		mockScanner.EXPECT().Results().Return(resultChan).AnyTimes()
@@ -49,6 +79,9 @@ func TestDiscoveryService(t *testing.T) {
 			conf,
 			mockScanner,
 			mockDetailScanner,
+			mockResolver,
+			mockGrabber,
+			mockHostKeyScanner,
 			mockEventManager,
 		)
 
@@ -85,6 +118,7 @@ func TestDiscoveryService(t *testing.T) {
 			Type: discovery.SynUpdateEvent,
 			Payload: discovery.DiscoveryResult{
 				Type:     discovery.SynUpdateEvent,
+				ConfigID: conf.ID,
 				ID:       mac.String(),
 				Hostname: "Unknown",
 				IP:       "127.0.0.1",
@@ -94,6 +128,8 @@ func TestDiscoveryService(t *testing.T) {
 					ID:     port.ID,
 					Status: discovery.PortClosed,
 				},
+				OpenPorts: []uint16{},
+				Banners:   []discovery.Banner{},
 			},
 		}
 
@@ -111,8 +147,18 @@ func TestDiscoveryService(t *testing.T) {
 	t.Run("monitors network for online servers", func(st *testing.T) {
 		mockScanner := mock_discovery.NewMockScanner(ctrl)
 		mockDetailScanner := mock_discovery.NewMockDetailScanner(ctrl)
+		mockResolver := mock_discovery.NewMockHostnameResolver(ctrl)
+		mockGrabber := mock_discovery.NewMockBannerGrabber(ctrl)
+		mockHostKeyScanner := mock_discovery.NewMockHostKeyScanner(ctrl)
 		mockEventManager := mock_event.NewMockManager(ctrl)
 
+		mockEventManager.EXPECT().Send(isScanEvent()).AnyTimes()
+
+		mockResolver.EXPECT().
+			Resolve(gomock.Any(), gomock.Any()).
+			Return("", discovery.HostnameSource(""), discovery.ErrHostnameNotFound).
+			AnyTimes()
+
 		resultChan := make(chan *scanner.ScanResult)
 
 		mockScanner.EXPECT().Results().Return(resultChan).AnyTimes()
@@ -123,6 +169,9 @@ func TestDiscoveryService(t *testing.T) {
 			conf,
 			mockScanner,
 			mockDetailScanner,
+			mockResolver,
+			mockGrabber,
+			mockHostKeyScanner,
 			mockEventManager,
 		)
 
@@ -158,6 +207,7 @@ func TestDiscoveryService(t *testing.T) {
 			Type: discovery.SynUpdateEvent,
 			Payload: discovery.DiscoveryResult{
 				Type:     discovery.SynUpdateEvent,
+				ConfigID: conf.ID,
 				ID:       mac.String(),
 				Hostname: "Unknown",
 				IP:       "127.0.0.1",
@@ -167,6 +217,8 @@ func TestDiscoveryService(t *testing.T) {
 					ID:     port.ID,
 					Status: discovery.PortClosed,
 				},
+				OpenPorts: []uint16{},
+				Banners:   []discovery.Banner{},
 			},
 		}
 
@@ -184,8 +236,18 @@ func TestDiscoveryService(t *testing.T) {
 	t.Run("requests extra details when ssh is enabled", func(st *testing.T) {
 		mockScanner := mock_discovery.NewMockScanner(ctrl)
 		mockDetailScanner := mock_discovery.NewMockDetailScanner(ctrl)
+		mockResolver := mock_discovery.NewMockHostnameResolver(ctrl)
+		mockGrabber := mock_discovery.NewMockBannerGrabber(ctrl)
+		mockHostKeyScanner := mock_discovery.NewMockHostKeyScanner(ctrl)
 		mockEventManager := mock_event.NewMockManager(ctrl)
 
+		mockEventManager.EXPECT().Send(isScanEvent()).AnyTimes()
+
+		mockResolver.EXPECT().
+			Resolve(gomock.Any(), gomock.Any()).
+			Return("", discovery.HostnameSource(""), discovery.ErrHostnameNotFound).
+			AnyTimes()
+
 		resultChan := make(chan *scanner.ScanResult)
 
 		mockScanner.EXPECT().Results().Return(resultChan).AnyTimes()
@@ -196,6 +258,9 @@ func TestDiscoveryService(t *testing.T) {
 			conf,
 			mockScanner,
 			mockDetailScanner,
+			mockResolver,
+			mockGrabber,
+			mockHostKeyScanner,
 			mockEventManager,
 		)
 
@@ -218,8 +283,9 @@ func TestDiscoveryService(t *testing.T) {
 		}
 
 		expectedDetails := &discovery.Details{
-			Hostname: "fancy-hostname",
-			OS:       "fancy-os",
+			Hostname:       "fancy-hostname",
+			HostnameSource: discovery.HostnameSourceSSH,
+			OS:             "fancy-os",
 		}
 
 		mockScanner.EXPECT().Scan().DoAndReturn(func() error {
@@ -229,23 +295,47 @@ func TestDiscoveryService(t *testing.T) {
 			return nil
 		})
 
-		mockDetailScanner.EXPECT().GetServerDetails(gomock.Any(), resultPayload.IP.String(), conf.SSH.Port).Return(expectedDetails, nil)
+		mockDetailScanner.EXPECT().GetServerDetails(gomock.Any(), resultPayload.IP.String(), conf.SSHCredentials(config.SSHTarget{})).Return(expectedDetails, nil)
+
+		sshBanner := &discovery.Banner{
+			Port:     22,
+			Protocol: discovery.BannerProtocolSSH,
+			Product:  "SSH-2.0-OpenSSH_9.6",
+		}
+
+		mockGrabber.EXPECT().
+			Grab(gomock.Any(), resultPayload.IP.String(), uint16(22)).
+			Return(sshBanner, nil)
+
+		hostKey := &discovery.HostKey{
+			Type:        "ssh-ed25519",
+			Fingerprint: "SHA256:abc",
+		}
+
+		mockHostKeyScanner.EXPECT().
+			ScanHostKey(gomock.Any(), resultPayload.IP.String(), conf.SSH.Port).
+			Return(hostKey, nil)
 
 		mockScanner.EXPECT().Stop()
 
 		expectedEvt := event.Event{
 			Type: discovery.SynUpdateEvent,
 			Payload: discovery.DiscoveryResult{
-				Type:     discovery.SynUpdateEvent,
-				ID:       mac.String(),
-				Hostname: "fancy-hostname",
-				IP:       "127.0.0.1",
-				OS:       "fancy-os",
-				Status:   discovery.ServerOnline,
+				Type:           discovery.SynUpdateEvent,
+				ConfigID:       conf.ID,
+				ID:             mac.String(),
+				Hostname:       "fancy-hostname",
+				HostnameSource: discovery.HostnameSourceSSH,
+				IP:             "127.0.0.1",
+				OS:             "fancy-os",
+				Status:         discovery.ServerOnline,
 				Port: discovery.Port{
 					ID:     port.ID,
 					Status: discovery.PortOpen,
 				},
+				OpenPorts: []uint16{22},
+				Banners:   []discovery.Banner{*sshBanner},
+				HostKey:   hostKey,
 			},
 		}
 
@@ -263,4 +353,799 @@ func TestDiscoveryService(t *testing.T) {
 		service.Stop()
 	})
 
+	t.Run("uses ssh settings from overrides matching mac address", func(st *testing.T) {
+		mockScanner := mock_discovery.NewMockScanner(ctrl)
+		mockDetailScanner := mock_discovery.NewMockDetailScanner(ctrl)
+		mockResolver := mock_discovery.NewMockHostnameResolver(ctrl)
+		mockGrabber := mock_discovery.NewMockBannerGrabber(ctrl)
+		mockHostKeyScanner := mock_discovery.NewMockHostKeyScanner(ctrl)
+		mockEventManager := mock_event.NewMockManager(ctrl)
+
+		mockEventManager.EXPECT().Send(gomock.Any()).AnyTimes()
+
+		mockGrabber.EXPECT().
+			Grab(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("no banner")).
+			AnyTimes()
+
+		resultChan := make(chan *scanner.ScanResult)
+
+		mockScanner.EXPECT().Results().Return(resultChan).AnyTimes()
+
+		mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:01")
+
+		overrideConf := conf
+		overrideConf.SSH.Overrides = []config.SSHOverride{
+			{Target: "127.0.0.0/8", User: "cidr-user"},
+			{Target: mac.String(), User: "admin", Port: "2222"},
+		}
+
+		service := discovery.NewScannerService(
+			overrideConf,
+			mockScanner,
+			mockDetailScanner,
+			mockResolver,
+			mockGrabber,
+			mockHostKeyScanner,
+			mockEventManager,
+		)
+
+		result := &scanner.ScanResult{
+			Type: scanner.SYNResult,
+			Payload: &scanner.SynScanResult{
+				MAC:    mac,
+				IP:     net.ParseIP("127.0.0.1"),
+				Status: scanner.StatusOnline,
+				Port: scanner.Port{
+					ID:      2222,
+					Service: "ssh",
+					Status:  scanner.PortOpen,
+				},
+			},
+		}
+
+		mockScanner.EXPECT().Scan().DoAndReturn(func() error {
+			go func() {
+				resultChan <- result
+			}()
+			return nil
+		})
+
+		mockHostKeyScanner.EXPECT().
+			ScanHostKey(gomock.Any(), "127.0.0.1", "2222").
+			Return(nil, errors.New("no host key"))
+
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+
+		mockDetailScanner.EXPECT().
+			GetServerDetails(gomock.Any(), "127.0.0.1", config.SSHCredentials{
+				User:      "admin",
+				Identity:  "identity",
+				Port:      "2222",
+				Overrides: []string{mac.String()},
+			}).
+			DoAndReturn(func(
+				ctx context.Context,
+				ip string,
+				creds config.SSHCredentials,
+			) (*discovery.Details, error) {
+				wg.Done()
+				return &discovery.Details{Hostname: "fancy-hostname"}, nil
+			})
+
+		mockScanner.EXPECT().Stop()
+
+		go service.MonitorNetwork()
+
+		wg.Wait()
+
+		service.Stop()
+	})
+
+	t.Run("resolves hostnames for hosts discovered via arp", func(st *testing.T) {
+		mockScanner := mock_discovery.NewMockScanner(ctrl)
+		mockDetailScanner := mock_discovery.NewMockDetailScanner(ctrl)
+		mockResolver := mock_discovery.NewMockHostnameResolver(ctrl)
+		mockGrabber := mock_discovery.NewMockBannerGrabber(ctrl)
+		mockHostKeyScanner := mock_discovery.NewMockHostKeyScanner(ctrl)
+		mockEventManager := mock_event.NewMockManager(ctrl)
+
+		mockEventManager.EXPECT().Send(isScanEvent()).AnyTimes()
+
+		resultChan := make(chan *scanner.ScanResult)
+
+		mockScanner.EXPECT().Results().Return(resultChan).AnyTimes()
+
+		mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+
+		service := discovery.NewScannerService(
+			conf,
+			mockScanner,
+			mockDetailScanner,
+			mockResolver,
+			mockGrabber,
+			mockHostKeyScanner,
+			mockEventManager,
+		)
+
+		result := &scanner.ScanResult{
+			Type: scanner.ARPResult,
+			Payload: &scanner.ArpScanResult{
+				MAC:    mac,
+				IP:     net.ParseIP("127.0.0.1"),
+				Vendor: "vendor",
+			},
+		}
+
+		mockScanner.EXPECT().Scan().DoAndReturn(func() error {
+			go func() {
+				resultChan <- result
+			}()
+			return nil
+		})
+
+		mockScanner.EXPECT().Stop()
+
+		mockResolver.EXPECT().
+			Resolve(gomock.Any(), "127.0.0.1").
+			Return("printer.lan", discovery.HostnameSourcePTR, nil)
+
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+
+		mockEventManager.EXPECT().Send(isEventType(discovery.ArpUpdateEvent))
+
+		mockEventManager.EXPECT().
+			Send(isEventType(discovery.HostnameResolvedEvent)).
+			DoAndReturn(func(evt event.Event) {
+				defer wg.Done()
+				payload := evt.Payload.(discovery.DiscoveryResult)
+				assert.Equal(st, "printer.lan", payload.Hostname)
+				assert.Equal(st, discovery.HostnameSourcePTR, payload.HostnameSource)
+				assert.Equal(st, mac.String(), payload.ID)
+			})
+
+		go service.MonitorNetwork()
+
+		wg.Wait()
+
+		service.Stop()
+	})
+
+	t.Run("sends port updates and banners for service ports", func(st *testing.T) {
+		mockScanner := mock_discovery.NewMockScanner(ctrl)
+		mockDetailScanner := mock_discovery.NewMockDetailScanner(ctrl)
+		mockResolver := mock_discovery.NewMockHostnameResolver(ctrl)
+		mockGrabber := mock_discovery.NewMockBannerGrabber(ctrl)
+		mockHostKeyScanner := mock_discovery.NewMockHostKeyScanner(ctrl)
+		mockEventManager := mock_event.NewMockManager(ctrl)
+
+		mockEventManager.EXPECT().Send(isScanEvent()).AnyTimes()
+
+		resultChan := make(chan *scanner.ScanResult)
+
+		mockScanner.EXPECT().Results().Return(resultChan).AnyTimes()
+
+		mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+
+		service := discovery.NewScannerService(
+			conf,
+			mockScanner,
+			mockDetailScanner,
+			mockResolver,
+			mockGrabber,
+			mockHostKeyScanner,
+			mockEventManager,
+		)
+
+		synResult := func(id uint16) *scanner.ScanResult {
+			return &scanner.ScanResult{
+				Type: scanner.SYNResult,
+				Payload: &scanner.SynScanResult{
+					MAC:    mac,
+					IP:     net.ParseIP("127.0.0.1"),
+					Status: scanner.StatusOnline,
+					Port: scanner.Port{
+						ID:     id,
+						Status: scanner.PortOpen,
+					},
+				},
+			}
+		}
+
+		mockScanner.EXPECT().Scan().DoAndReturn(func() error {
+			go func() {
+				resultChan <- synResult(443)
+				resultChan <- synResult(80)
+			}()
+			return nil
+		})
+
+		mockScanner.EXPECT().Stop()
+
+		httpBanner := &discovery.Banner{
+			Port:     80,
+			Protocol: discovery.BannerProtocolHTTP,
+			Product:  "nginx",
+			Title:    "Welcome",
+		}
+
+		mockGrabber.EXPECT().
+			Grab(gomock.Any(), "127.0.0.1", uint16(443)).
+			Return(nil, discovery.ErrNoBanner)
+
+		mockGrabber.EXPECT().
+			Grab(gomock.Any(), "127.0.0.1", uint16(80)).
+			Return(httpBanner, nil)
+
+		wg := sync.WaitGroup{}
+		wg.Add(2)
+
+		mu := sync.Mutex{}
+		received := [][]uint16{}
+		banners := [][]discovery.Banner{}
+
+		mockEventManager.EXPECT().
+			Send(isEventType(discovery.PortUpdateEvent)).
+			DoAndReturn(func(evt event.Event) {
+				defer wg.Done()
+				mu.Lock()
+				defer mu.Unlock()
+				payload := evt.Payload.(discovery.DiscoveryResult)
+				assert.Equal(st, mac.String(), payload.ID)
+				received = append(received, payload.OpenPorts)
+				banners = append(banners, payload.Banners)
+			}).
+			Times(2)
+
+		go service.MonitorNetwork()
+
+		wg.Wait()
+
+		service.Stop()
+
+		assert.ElementsMatch(st, [][]uint16{{443}, {80, 443}}, received)
+		assert.Contains(st, banners, []discovery.Banner{*httpBanner})
+	})
+
+	t.Run("grabs banners once until the port closes", func(st *testing.T) {
+		mockScanner := mock_discovery.NewMockScanner(ctrl)
+		mockDetailScanner := mock_discovery.NewMockDetailScanner(ctrl)
+		mockResolver := mock_discovery.NewMockHostnameResolver(ctrl)
+		mockGrabber := mock_discovery.NewMockBannerGrabber(ctrl)
+		mockHostKeyScanner := mock_discovery.NewMockHostKeyScanner(ctrl)
+		mockEventManager := mock_event.NewMockManager(ctrl)
+
+		mockEventManager.EXPECT().Send(isScanEvent()).AnyTimes()
+
+		resultChan := make(chan *scanner.ScanResult)
+
+		mockScanner.EXPECT().Results().Return(resultChan).AnyTimes()
+
+		mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+
+		service := discovery.NewScannerService(
+			conf,
+			mockScanner,
+			mockDetailScanner,
+			mockResolver,
+			mockGrabber,
+			mockHostKeyScanner,
+			mockEventManager,
+		)
+
+		synResult := func(status scanner.PortStatus) *scanner.ScanResult {
+			return &scanner.ScanResult{
+				Type: scanner.SYNResult,
+				Payload: &scanner.SynScanResult{
+					MAC:    mac,
+					IP:     net.ParseIP("127.0.0.1"),
+					Status: scanner.StatusOnline,
+					Port: scanner.Port{
+						ID:     80,
+						Status: status,
+					},
+				},
+			}
+		}
+
+		received := make(chan []discovery.Banner)
+		done := make(chan struct{})
+
+		mockScanner.EXPECT().Scan().DoAndReturn(func() error {
+			go func() {
+				// wait for each update so results are handled in order
+				for _, status := range []scanner.PortStatus{
+					scanner.PortOpen,
+					scanner.PortOpen,
+					scanner.PortClosed,
+					scanner.PortOpen,
+				} {
+					resultChan <- synResult(status)
+					<-received
+				}
+
+				close(done)
+			}()
+			return nil
+		})
+
+		mockScanner.EXPECT().Stop()
+
+		httpBanner := &discovery.Banner{
+			Port:     80,
+			Protocol: discovery.BannerProtocolHTTP,
+			Product:  "nginx",
+		}
+
+		mockGrabber.EXPECT().
+			Grab(gomock.Any(), "127.0.0.1", uint16(80)).
+			Return(httpBanner, nil).
+			Times(2)
+
+		banners := [][]discovery.Banner{}
+
+		mockEventManager.EXPECT().
+			Send(isEventType(discovery.PortUpdateEvent)).
+			DoAndReturn(func(evt event.Event) {
+				payload := evt.Payload.(discovery.DiscoveryResult)
+				banners = append(banners, payload.Banners)
+				received <- payload.Banners
+			}).
+			Times(4)
+
+		go service.MonitorNetwork()
+
+		<-done
+
+		service.Stop()
+
+		assert.Equal(st, [][]discovery.Banner{
+			{*httpBanner},
+			{*httpBanner},
+			{},
+			{*httpBanner},
+		}, banners)
+	})
+
+	t.Run("sends scan started and completed events", func(st *testing.T) {
+		mockScanner := mock_discovery.NewMockScanner(ctrl)
+		mockDetailScanner := mock_discovery.NewMockDetailScanner(ctrl)
+		mockResolver := mock_discovery.NewMockHostnameResolver(ctrl)
+		mockGrabber := mock_discovery.NewMockBannerGrabber(ctrl)
+		mockHostKeyScanner := mock_discovery.NewMockHostKeyScanner(ctrl)
+		mockEventManager := mock_event.NewMockManager(ctrl)
+
+		resultChan := make(chan *scanner.ScanResult)
+
+		mockScanner.EXPECT().Results().Return(resultChan).AnyTimes()
+
+		mockResolver.EXPECT().
+			Resolve(gomock.Any(), gomock.Any()).
+			Return("", discovery.HostnameSource(""), discovery.ErrHostnameNotFound).
+			AnyTimes()
+
+		mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+
+		service := discovery.NewScannerService(
+			conf,
+			mockScanner,
+			mockDetailScanner,
+			mockResolver,
+			mockGrabber,
+			mockHostKeyScanner,
+			mockEventManager,
+		)
+
+		result := &scanner.ScanResult{
+			Type: scanner.ARPResult,
+			Payload: &scanner.ArpScanResult{
+				MAC:    mac,
+				IP:     net.ParseIP("127.0.0.1"),
+				Vendor: "vendor",
+			},
+		}
+
+		mockScanner.EXPECT().Scan().DoAndReturn(func() error {
+			resultChan <- result
+			return nil
+		})
+
+		mockScanner.EXPECT().Stop()
+
+		wg := sync.WaitGroup{}
+		wg.Add(2)
+
+		mockEventManager.EXPECT().Send(isEventType(discovery.ArpUpdateEvent))
+
+		mockEventManager.EXPECT().
+			Send(isEventType(discovery.ScanStartedEvent)).
+			DoAndReturn(func(evt event.Event) {
+				wg.Done()
+			})
+
+		mockEventManager.EXPECT().
+			Send(isEventType(discovery.ScanCompletedEvent)).
+			DoAndReturn(func(evt event.Event) {
+				defer wg.Done()
+				cycle := evt.Payload.(discovery.ScanCycle)
+				assert.Equal(st, 1, cycle.HostsFound)
+				assert.Equal(st, 0, cycle.HostsOffline)
+				assert.False(st, cycle.StartedAt.IsZero())
+			})
+
+		go service.MonitorNetwork()
+
+		wg.Wait()
+
+		service.Stop()
+	})
+
+	t.Run("triggers immediate scans", func(st *testing.T) {
+		mockScanner := mock_discovery.NewMockScanner(ctrl)
+		mockDetailScanner := mock_discovery.NewMockDetailScanner(ctrl)
+		mockResolver := mock_discovery.NewMockHostnameResolver(ctrl)
+		mockGrabber := mock_discovery.NewMockBannerGrabber(ctrl)
+		mockHostKeyScanner := mock_discovery.NewMockHostKeyScanner(ctrl)
+		mockEventManager := mock_event.NewMockManager(ctrl)
+
+		mockEventManager.EXPECT().Send(isScanEvent()).AnyTimes()
+
+		mockScanner.EXPECT().Results().Return(make(chan *scanner.ScanResult)).AnyTimes()
+
+		service := discovery.NewScannerService(
+			conf,
+			mockScanner,
+			mockDetailScanner,
+			mockResolver,
+			mockGrabber,
+			mockHostKeyScanner,
+			mockEventManager,
+		)
+
+		assert.ErrorIs(st, service.Scan(), discovery.ErrNotMonitoring)
+
+		scanned := make(chan struct{})
+
+		mockScanner.EXPECT().Scan().DoAndReturn(func() error {
+			scanned <- struct{}{}
+			return nil
+		}).Times(2)
+
+		mockScanner.EXPECT().Stop()
+
+		go service.MonitorNetwork()
+
+		<-scanned
+
+		assert.Eventually(st, func() bool {
+			return service.Scan() == nil
+		}, time.Second, time.Millisecond*10)
+
+		<-scanned
+
+		service.Stop()
+	})
+
+	t.Run("rejects scans while a scan is running", func(st *testing.T) {
+		mockScanner := mock_discovery.NewMockScanner(ctrl)
+		mockDetailScanner := mock_discovery.NewMockDetailScanner(ctrl)
+		mockResolver := mock_discovery.NewMockHostnameResolver(ctrl)
+		mockGrabber := mock_discovery.NewMockBannerGrabber(ctrl)
+		mockHostKeyScanner := mock_discovery.NewMockHostKeyScanner(ctrl)
+		mockEventManager := mock_event.NewMockManager(ctrl)
+
+		mockEventManager.EXPECT().Send(isScanEvent()).AnyTimes()
+
+		mockScanner.EXPECT().Results().Return(make(chan *scanner.ScanResult)).AnyTimes()
+
+		service := discovery.NewScannerService(
+			conf,
+			mockScanner,
+			mockDetailScanner,
+			mockResolver,
+			mockGrabber,
+			mockHostKeyScanner,
+			mockEventManager,
+		)
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+
+		mockScanner.EXPECT().Scan().DoAndReturn(func() error {
+			started <- struct{}{}
+			<-release
+			return nil
+		}).Times(2)
+
+		mockScanner.EXPECT().Stop()
+
+		go service.MonitorNetwork()
+
+		<-started
+
+		assert.ErrorIs(st, service.Scan(), discovery.ErrScanInProgress)
+
+		_, err := service.ScanOnce()
+
+		assert.ErrorIs(st, err, discovery.ErrMonitoring)
+
+		release <- struct{}{}
+
+		assert.Eventually(st, func() bool {
+			return service.Scan() == nil
+		}, time.Second, time.Millisecond*10)
+
+		<-started
+
+		close(release)
+
+		service.Stop()
+	})
+
+	t.Run("performs a single scan", func(st *testing.T) {
+		mockScanner := mock_discovery.NewMockScanner(ctrl)
+		mockDetailScanner := mock_discovery.NewMockDetailScanner(ctrl)
+		mockResolver := mock_discovery.NewMockHostnameResolver(ctrl)
+		mockGrabber := mock_discovery.NewMockBannerGrabber(ctrl)
+		mockHostKeyScanner := mock_discovery.NewMockHostKeyScanner(ctrl)
+		mockEventManager := mock_event.NewMockManager(ctrl)
+
+		resultChan := make(chan *scanner.ScanResult)
+
+		mockScanner.EXPECT().Results().Return(resultChan).AnyTimes()
+
+		mockResolver.EXPECT().
+			Resolve(gomock.Any(), gomock.Any()).
+			Return("", discovery.HostnameSource(""), discovery.ErrHostnameNotFound).
+			AnyTimes()
+
+		mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+
+		service := discovery.NewScannerService(
+			conf,
+			mockScanner,
+			mockDetailScanner,
+			mockResolver,
+			mockGrabber,
+			mockHostKeyScanner,
+			mockEventManager,
+		)
+
+		mockScanner.EXPECT().Scan().DoAndReturn(func() error {
+			resultChan <- &scanner.ScanResult{
+				Type: scanner.ARPResult,
+				Payload: &scanner.ArpScanResult{
+					MAC:    mac,
+					IP:     net.ParseIP("127.0.0.1"),
+					Vendor: "vendor",
+				},
+			}
+			resultChan <- &scanner.ScanResult{
+				Type: scanner.SYNResult,
+				Payload: &scanner.SynScanResult{
+					MAC:    mac,
+					IP:     net.ParseIP("127.0.0.1"),
+					Status: scanner.StatusOnline,
+					Port: scanner.Port{
+						ID:     22,
+						Status: scanner.PortClosed,
+					},
+				},
+			}
+			return nil
+		})
+
+		mockEventManager.EXPECT().Send(isEventType(discovery.ScanStartedEvent))
+		mockEventManager.EXPECT().Send(isEventType(discovery.ArpUpdateEvent))
+		mockEventManager.EXPECT().Send(isEventType(discovery.SynUpdateEvent))
+		mockEventManager.EXPECT().Send(isEventType(discovery.ScanCompletedEvent))
+
+		results, err := service.ScanOnce()
+
+		assert.NoError(st, err)
+		assert.Len(st, results, 2)
+
+		types := []string{}
+
+		for _, r := range results {
+			assert.Equal(st, mac.String(), r.ID)
+			types = append(types, r.Type)
+		}
+
+		assert.ElementsMatch(
+			st,
+			[]string{discovery.ArpUpdateEvent, discovery.SynUpdateEvent},
+			types,
+		)
+	})
+
+	t.Run("returns scan errors for single scans", func(st *testing.T) {
+		mockScanner := mock_discovery.NewMockScanner(ctrl)
+		mockDetailScanner := mock_discovery.NewMockDetailScanner(ctrl)
+		mockResolver := mock_discovery.NewMockHostnameResolver(ctrl)
+		mockGrabber := mock_discovery.NewMockBannerGrabber(ctrl)
+		mockHostKeyScanner := mock_discovery.NewMockHostKeyScanner(ctrl)
+		mockEventManager := mock_event.NewMockManager(ctrl)
+
+		mockEventManager.EXPECT().Send(isScanEvent()).AnyTimes()
+
+		mockScanner.EXPECT().Results().Return(make(chan *scanner.ScanResult)).AnyTimes()
+
+		service := discovery.NewScannerService(
+			conf,
+			mockScanner,
+			mockDetailScanner,
+			mockResolver,
+			mockGrabber,
+			mockHostKeyScanner,
+			mockEventManager,
+		)
+
+		scanErr := errors.New("scan failed")
+
+		mockScanner.EXPECT().Scan().Return(scanErr)
+
+		_, err := service.ScanOnce()
+
+		assert.ErrorIs(st, err, scanErr)
+	})
+
+	t.Run("replaces config and stops the previous scanner", func(st *testing.T) {
+		mockScanner := mock_discovery.NewMockScanner(ctrl)
+		mockNextScanner := mock_discovery.NewMockScanner(ctrl)
+		mockDetailScanner := mock_discovery.NewMockDetailScanner(ctrl)
+		mockResolver := mock_discovery.NewMockHostnameResolver(ctrl)
+		mockGrabber := mock_discovery.NewMockBannerGrabber(ctrl)
+		mockHostKeyScanner := mock_discovery.NewMockHostKeyScanner(ctrl)
+		mockEventManager := mock_event.NewMockManager(ctrl)
+
+		mockScanner.EXPECT().Results().Return(make(chan *scanner.ScanResult)).AnyTimes()
+		mockNextScanner.EXPECT().Results().Return(make(chan *scanner.ScanResult)).AnyTimes()
+
+		fastConf := conf
+		fastConf.Scan = config.ScanConfig{Interval: "10ms"}
+
+		service := discovery.NewScannerService(
+			fastConf,
+			mockScanner,
+			mockDetailScanner,
+			mockResolver,
+			mockGrabber,
+			mockHostKeyScanner,
+			mockEventManager,
+		)
+
+		nextConf := fastConf
+		nextConf.ID = "2"
+
+		started := make(chan discovery.ScanCycle, 10)
+
+		mockEventManager.EXPECT().
+			Send(isEventType(discovery.ScanStartedEvent)).
+			DoAndReturn(func(evt event.Event) {
+				started <- evt.Payload.(discovery.ScanCycle)
+			}).
+			AnyTimes()
+
+		mockEventManager.EXPECT().Send(isEventType(discovery.ScanCompletedEvent)).AnyTimes()
+
+		mockScanner.EXPECT().Scan().Return(nil).AnyTimes()
+		mockScanner.EXPECT().Stop()
+
+		go service.MonitorNetwork()
+
+		cycle := <-started
+		assert.Equal(st, conf.ID, cycle.ConfigID)
+
+		// wait for monitoring so the config change restarts polling
+		assert.Eventually(st, func() bool {
+			return service.Scan() != discovery.ErrNotMonitoring
+		}, time.Second, time.Millisecond*10)
+
+		mockNextScanner.EXPECT().Scan().Return(nil).AnyTimes()
+		mockNextScanner.EXPECT().Stop()
+
+		service.SetConfigAndScanner(nextConf, mockNextScanner, mockDetailScanner)
+
+		// scans started before the change report the previous config
+		for cycle.ConfigID != nextConf.ID {
+			cycle = <-started
+		}
+
+		service.Stop()
+	})
+
+	t.Run("detects hosts going offline and returning", func(st *testing.T) {
+		mockScanner := mock_discovery.NewMockScanner(ctrl)
+		mockDetailScanner := mock_discovery.NewMockDetailScanner(ctrl)
+		mockResolver := mock_discovery.NewMockHostnameResolver(ctrl)
+		mockGrabber := mock_discovery.NewMockBannerGrabber(ctrl)
+		mockHostKeyScanner := mock_discovery.NewMockHostKeyScanner(ctrl)
+		mockEventManager := mock_event.NewMockManager(ctrl)
+
+		resultChan := make(chan *scanner.ScanResult)
+
+		mockScanner.EXPECT().Results().Return(resultChan).AnyTimes()
+
+		mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+
+		fastConf := conf
+		fastConf.Scan = config.ScanConfig{
+			Interval:     "10ms",
+			OfflineAfter: 1,
+		}
+
+		service := discovery.NewScannerService(
+			fastConf,
+			mockScanner,
+			mockDetailScanner,
+			mockResolver,
+			mockGrabber,
+			mockHostKeyScanner,
+			mockEventManager,
+		)
+
+		result := &scanner.ScanResult{
+			Type: scanner.ARPResult,
+			Payload: &scanner.ArpScanResult{
+				MAC:    mac,
+				IP:     net.ParseIP("127.0.0.1"),
+				Vendor: "vendor",
+			},
+		}
+
+		scanCount := atomic.Int32{}
+
+		// host answers the first scan, misses the second, and answers
+		// every scan after that
+		mockScanner.EXPECT().Scan().DoAndReturn(func() error {
+			if scanCount.Add(1) != 2 {
+				resultChan <- result
+			}
+			return nil
+		}).AnyTimes()
+
+		mockScanner.EXPECT().Stop()
+
+		mockEventManager.EXPECT().Send(isScanEvent()).AnyTimes()
+
+		mockResolver.EXPECT().
+			Resolve(gomock.Any(), gomock.Any()).
+			Return("", discovery.HostnameSource(""), discovery.ErrHostnameNotFound).
+			AnyTimes()
+		mockEventManager.EXPECT().Send(isEventType(discovery.ArpUpdateEvent)).AnyTimes()
+
+		offlineWg := sync.WaitGroup{}
+		offlineWg.Add(1)
+
+		returnedWg := sync.WaitGroup{}
+		returnedWg.Add(1)
+
+		mockEventManager.EXPECT().
+			Send(isEventType(discovery.HostOfflineEvent)).
+			DoAndReturn(func(evt event.Event) {
+				defer offlineWg.Done()
+				payload := evt.Payload.(discovery.DiscoveryResult)
+				assert.Equal(st, mac.String(), payload.ID)
+				assert.Equal(st, discovery.ServerOffline, payload.Status)
+			})
+
+		mockEventManager.EXPECT().
+			Send(isEventType(discovery.HostReturnedEvent)).
+			DoAndReturn(func(evt event.Event) {
+				defer returnedWg.Done()
+				payload := evt.Payload.(discovery.DiscoveryResult)
+				assert.Equal(st, mac.String(), payload.ID)
+				assert.Equal(st, discovery.ServerOnline, payload.Status)
+			})
+
+		go service.MonitorNetwork()
+
+		offlineWg.Wait()
+		returnedWg.Wait()
+
+		service.Stop()
+	})
 }
@@ -0,0 +1,51 @@
+package discovery
+
+import (
+	"sync"
+
+	"github.com/robgonnella/ops/internal/config"
+)
+
+// sshTargetStore remembers what is known about each host across results so
+// ssh overrides can be matched by mac address, hostname, and vendor even
+// when a single result does not include them
+type sshTargetStore struct {
+	targets map[string]config.SSHTarget
+	mux     sync.Mutex
+}
+
+// returns a new instance of sshTargetStore
+func newSSHTargetStore() *sshTargetStore {
+	return &sshTargetStore{
+		targets: map[string]config.SSHTarget{},
+		mux:     sync.Mutex{},
+	}
+}
+
+// update merges the known fields from the result into the host's target
+// and returns the merged target
+func (s *sshTargetStore) update(result DiscoveryResult) config.SSHTarget {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	target := s.targets[result.ID]
+	next := result.SSHTarget()
+
+	target.MAC = next.MAC
+
+	if next.IP != "" {
+		target.IP = next.IP
+	}
+
+	if next.Hostname != "" {
+		target.Hostname = next.Hostname
+	}
+
+	if next.Vendor != "" {
+		target.Vendor = next.Vendor
+	}
+
+	s.targets[result.ID] = target
+
+	return target
+}
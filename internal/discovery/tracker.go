@@ -0,0 +1,117 @@
+package discovery
+
+import "sort"
+
+// trackedHost represents a host's answer history across scan cycles
+type trackedHost struct {
+	result  DiscoveryResult
+	seen    bool
+	missed  int
+	offline bool
+}
+
+// hostTracker tracks which hosts answered ARP requests in each scan cycle
+// so we can detect hosts that stop answering and hosts that come back
+type hostTracker struct {
+	hosts map[string]*trackedHost
+	ports map[string]map[uint16]bool
+}
+
+// returns a new instance of hostTracker
+func newHostTracker() *hostTracker {
+	return &hostTracker{
+		hosts: map[string]*trackedHost{},
+		ports: map[string]map[uint16]bool{},
+	}
+}
+
+// markSeen records that a host answered during the current scan cycle.
+// Returns true if the host was previously considered offline.
+func (t *hostTracker) markSeen(result DiscoveryResult) bool {
+	h, ok := t.hosts[result.ID]
+
+	if !ok {
+		t.hosts[result.ID] = &trackedHost{result: result, seen: true}
+		return false
+	}
+
+	returned := h.offline
+
+	h.result = result
+	h.seen = true
+	h.missed = 0
+	h.offline = false
+
+	return returned
+}
+
+// counts returns the number of hosts that answered during the current cycle
+// and the number of known hosts currently considered offline
+func (t *hostTracker) counts() (int, int) {
+	found := 0
+	offline := 0
+
+	for _, h := range t.hosts {
+		if h.seen {
+			found++
+		}
+
+		if h.offline {
+			offline++
+		}
+	}
+
+	return found, offline
+}
+
+// endCycle completes the current scan cycle and returns all hosts that have
+// now missed the given number of consecutive cycles
+func (t *hostTracker) endCycle(offlineAfter int) []DiscoveryResult {
+	offline := []DiscoveryResult{}
+
+	for _, h := range t.hosts {
+		if h.seen {
+			h.seen = false
+			continue
+		}
+
+		h.missed++
+
+		if !h.offline && h.missed >= offlineAfter {
+			h.offline = true
+			offline = append(offline, h.result)
+		}
+	}
+
+	return offline
+}
+
+// updatePort records the latest status of a port for the given host and
+// returns all ports currently known to be open on that host in ascending
+// order
+func (t *hostTracker) updatePort(id string, port uint16, open bool) []uint16 {
+	ports, ok := t.ports[id]
+
+	if !ok {
+		ports = map[uint16]bool{}
+		t.ports[id] = ports
+	}
+
+	if open {
+		ports[port] = true
+	} else {
+		delete(ports, port)
+	}
+
+	openPorts := make([]uint16, 0, len(ports))
+
+	for p := range ports {
+		openPorts = append(openPorts, p)
+	}
+
+	sort.Slice(openPorts, func(i, j int) bool {
+		return openPorts[i] < openPorts[j]
+	})
+
+	return openPorts
+}
@@ -60,6 +60,9 @@ func (m *EventManager) RemoveListener(id int) int {
 
 // Send sends an event to all listeners for that event
 func (m *EventManager) Send(evt Event) {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+
 	for _, l := range m.listeners {
 		if l.eventType == EventType(evt.Type) {
 			go func(listener *EventListener, event Event) {
@@ -0,0 +1,57 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/robgonnella/ops/internal/exception"
+)
+
+// ErrAmbiguousHost returned when a reference matches more than one host
+var ErrAmbiguousHost = errors.New("reference matches more than one host")
+
+// FindHost returns the host matching the given id (mac address), ip, or
+// hostname. IDs and ips take precedence over hostnames, and hostnames are
+// matched case-insensitively with or without a domain.
+func FindHost(hosts []*Host, ref string) (*Host, error) {
+	for _, h := range hosts {
+		if strings.EqualFold(h.ID, ref) || h.IP == ref {
+			return h, nil
+		}
+	}
+
+	matches := []*Host{}
+
+	for _, h := range hosts {
+		if h.Hostname == "" || h.Hostname == unknown {
+			continue
+		}
+
+		short, _, _ := strings.Cut(h.Hostname, ".")
+
+		if strings.EqualFold(h.Hostname, ref) || strings.EqualFold(short, ref) {
+			matches = append(matches, h)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%w: %s", exception.ErrRecordNotFound, ref)
+	case 1:
+		return matches[0], nil
+	default:
+		ips := make([]string, len(matches))
+
+		for i, m := range matches {
+			ips[i] = m.IP
+		}
+
+		return nil, fmt.Errorf(
+			"%w: %s (%s)",
+			ErrAmbiguousHost,
+			ref,
+			strings.Join(ips, ", "),
+		)
+	}
+}
@@ -0,0 +1,56 @@
+package inventory_test
+
+import (
+	"testing"
+
+	"github.com/robgonnella/ops/internal/exception"
+	"github.com/robgonnella/ops/internal/inventory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindHost(t *testing.T) {
+	web := &inventory.Host{ID: "aa:aa:aa:aa:aa:aa", IP: "192.168.1.2", Hostname: "web.local"}
+	db1 := &inventory.Host{ID: "bb:bb:bb:bb:bb:bb", IP: "192.168.1.3", Hostname: "db"}
+	db2 := &inventory.Host{ID: "cc:cc:cc:cc:cc:cc", IP: "192.168.1.4", Hostname: "DB"}
+	unknown := &inventory.Host{ID: "dd:dd:dd:dd:dd:dd", IP: "192.168.1.5", Hostname: "Unknown"}
+
+	hosts := []*inventory.Host{web, db1, db2, unknown}
+
+	t.Run("finds host by id", func(st *testing.T) {
+		found, err := inventory.FindHost(hosts, "AA:AA:AA:AA:AA:AA")
+
+		assert.NoError(st, err)
+		assert.Equal(st, web, found)
+	})
+
+	t.Run("finds host by ip", func(st *testing.T) {
+		found, err := inventory.FindHost(hosts, "192.168.1.3")
+
+		assert.NoError(st, err)
+		assert.Equal(st, db1, found)
+	})
+
+	t.Run("finds host by hostname with or without domain", func(st *testing.T) {
+		found, err := inventory.FindHost(hosts, "web.local")
+
+		assert.NoError(st, err)
+		assert.Equal(st, web, found)
+
+		found, err = inventory.FindHost(hosts, "WEB")
+
+		assert.NoError(st, err)
+		assert.Equal(st, web, found)
+	})
+
+	t.Run("returns error for ambiguous hostnames", func(st *testing.T) {
+		_, err := inventory.FindHost(hosts, "db")
+
+		assert.ErrorIs(st, err, inventory.ErrAmbiguousHost)
+	})
+
+	t.Run("returns not found error", func(st *testing.T) {
+		_, err := inventory.FindHost(hosts, "unknown")
+
+		assert.ErrorIs(st, err, exception.ErrRecordNotFound)
+	})
+}
@@ -0,0 +1,120 @@
+package inventory
+
+import (
+	"time"
+
+	"github.com/robgonnella/ops/internal/discovery"
+)
+
+//go:generate mockgen -destination=../mock/inventory/mock_inventory.go -package=mock_inventory . Repo,Service
+
+// Host represents a persisted record of a device discovered on the network
+type Host struct {
+	ID             string                   `json:"id"`
+	ConfigID       string                   `json:"configId"`
+	Hostname       string                   `json:"hostname"`
+	HostnameSource discovery.HostnameSource `json:"hostnameSource,omitempty"`
+	IP             string                   `json:"ip"`
+	OS             string                   `json:"os"`
+	Vendor         string                   `json:"vendor"`
+	Status         discovery.ServerStatus   `json:"status"`
+	Port           discovery.Port           `json:"port"`
+	OpenPorts      []uint16                 `json:"openPorts,omitempty"`
+	Banners        []discovery.Banner       `json:"banners,omitempty"`
+	HostKeys       []HostKeyRecord          `json:"hostKeys,omitempty"`
+	HostKeyChanged bool                     `json:"hostKeyChanged,omitempty"`
+	Facts          *discovery.Facts         `json:"facts,omitempty"`
+	FirstSeen      time.Time                `json:"firstSeen"`
+	LastSeen       time.Time                `json:"lastSeen"`
+}
+
+// HostKeyRecord represents an ssh host key presented by a host and when
+// it was observed. A host's records are kept in the order keys were first
+// seen so they provide a history of key changes.
+type HostKeyRecord struct {
+	Type        string    `json:"type"`
+	Fingerprint string    `json:"fingerprint"`
+	IP          string    `json:"ip"`
+	FirstSeen   time.Time `json:"firstSeen"`
+	LastSeen    time.Time `json:"lastSeen"`
+}
+
+// Key returns the host key for this record
+func (r HostKeyRecord) Key() discovery.HostKey {
+	return discovery.HostKey{Type: r.Type, Fingerprint: r.Fingerprint}
+}
+
+// HostKeyChange returns the unacknowledged change in ssh host key for this
+// host if there is one
+func (h *Host) HostKeyChange() (discovery.HostKeyChange, bool) {
+	count := len(h.HostKeys)
+
+	if !h.HostKeyChanged || count < 2 {
+		return discovery.HostKeyChange{}, false
+	}
+
+	return discovery.HostKeyChange{
+		ConfigID: h.ConfigID,
+		ID:       h.ID,
+		IP:       h.HostKeys[count-1].IP,
+		Hostname: h.Hostname,
+		Previous: h.HostKeys[count-2].Key(),
+		Current:  h.HostKeys[count-1].Key(),
+	}, true
+}
+
+// NewHostKeyChange returns the change in ssh host key for this host if
+// the current key replaced a different key and has only been seen once
+func (h *Host) NewHostKeyChange() (discovery.HostKeyChange, bool) {
+	change, ok := h.HostKeyChange()
+
+	if !ok {
+		return change, false
+	}
+
+	current := h.HostKeys[len(h.HostKeys)-1]
+
+	return change, current.FirstSeen.Equal(current.LastSeen)
+}
+
+// Result converts the stored host back into a discovery result
+func (h *Host) Result() discovery.DiscoveryResult {
+	return discovery.DiscoveryResult{
+		ID:             h.ID,
+		Hostname:       h.Hostname,
+		HostnameSource: h.HostnameSource,
+		IP:             h.IP,
+		OS:             h.OS,
+		Vendor:         h.Vendor,
+		Status:         h.Status,
+		Port:           h.Port,
+		OpenPorts:      h.OpenPorts,
+		Banners:        h.Banners,
+		Facts:          h.Facts,
+	}
+}
+
+// Inventory represents our collection of persisted hosts
+type Inventory struct {
+	Hosts []*Host `json:"hosts"`
+}
+
+// Repo interface representing access to stored hosts. Saved hosts may
+// not be persisted until Flush is called.
+type Repo interface {
+	Get(configID, id string) (*Host, error)
+	GetAll(configID string) ([]*Host, error)
+	Save(host *Host) (*Host, error)
+	Delete(configID, id string) error
+	Flush() error
+}
+
+// Service interface for recording and retrieving discovered hosts
+type Service interface {
+	Get(configID, id string) (*Host, error)
+	GetAll(configID string) ([]*Host, error)
+	Record(configID string, result discovery.DiscoveryResult) (*Host, error)
+	TrustHostKey(configID, id string) (*Host, error)
+	Delete(configID, id string) error
+	Flush() error
+}
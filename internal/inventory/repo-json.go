@@ -0,0 +1,235 @@
+package inventory
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/robgonnella/ops/internal/exception"
+	"github.com/robgonnella/ops/internal/logger"
+)
+
+// JSONRepo is our repo implementation for json. Saved hosts are kept in
+// memory and only written to the file when flushed since hosts are saved
+// for every discovery event and rewriting the whole file each time is slow.
+type JSONRepo struct {
+	inventoryPath string
+	hosts         []*Host
+	dirty         bool
+	mux           sync.Mutex
+	log           logger.Logger
+}
+
+// NewJSONRepo returns a new inventory repo for flat json file
+func NewJSONRepo(inventoryPath string) (*JSONRepo, error) {
+	repo := &JSONRepo{
+		inventoryPath: inventoryPath,
+		hosts:         []*Host{},
+		mux:           sync.Mutex{},
+		log:           logger.New(),
+	}
+
+	if err := repo.load(); err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+// Get returns a host from the db
+func (r *JSONRepo) Get(configID, id string) (*Host, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if configID == "" || id == "" {
+		return nil, errors.New("config id and host id cannot be empty")
+	}
+
+	idx := r.indexOf(configID, id)
+
+	if idx == -1 {
+		return nil, exception.ErrRecordNotFound
+	}
+
+	return copyHost(r.hosts[idx]), nil
+}
+
+// GetAll returns all hosts in db for the given config
+func (r *JSONRepo) GetAll(configID string) ([]*Host, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	hosts := []*Host{}
+
+	for _, h := range r.hosts {
+		if h.ConfigID == configID {
+			hosts = append(hosts, copyHost(h))
+		}
+	}
+
+	return hosts, nil
+}
+
+// Save creates or updates a host in db. The host is not written to the
+// file until Flush is called.
+func (r *JSONRepo) Save(host *Host) (*Host, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if host.ConfigID == "" || host.ID == "" {
+		return nil, errors.New("config id and host id cannot be empty")
+	}
+
+	copy := copyHost(host)
+
+	if idx := r.indexOf(host.ConfigID, host.ID); idx == -1 {
+		r.hosts = append(r.hosts, copy)
+	} else {
+		r.hosts[idx] = copy
+	}
+
+	r.dirty = true
+
+	return copyHost(copy), nil
+}
+
+// Delete deletes a host from db
+func (r *JSONRepo) Delete(configID, id string) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if configID == "" || id == "" {
+		return errors.New("config id and host id cannot be empty")
+	}
+
+	r.hosts = slices.DeleteFunc(r.hosts, func(h *Host) bool {
+		return h.ConfigID == configID && h.ID == id
+	})
+
+	return r.write()
+}
+
+// Flush writes all saved hosts to the file if any changed since the last
+// write
+func (r *JSONRepo) Flush() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if !r.dirty {
+		return nil
+	}
+
+	return r.write()
+}
+
+func (r *JSONRepo) indexOf(configID, id string) int {
+	return slices.IndexFunc(r.hosts, func(h *Host) bool {
+		return h.ConfigID == configID && h.ID == id
+	})
+}
+
+// writes the inventory to a temp file and renames it into place so a crash
+// mid-write never leaves a truncated inventory behind
+func (r *JSONRepo) write() error {
+	inventory := Inventory{
+		Hosts: r.hosts,
+	}
+
+	data, err := json.MarshalIndent(&inventory, "", "\t")
+
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(
+		filepath.Dir(r.inventoryPath),
+		filepath.Base(r.inventoryPath)+".*.tmp",
+	)
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(file.Name(), 0644); err != nil {
+		return err
+	}
+
+	if err := os.Rename(file.Name(), r.inventoryPath); err != nil {
+		return err
+	}
+
+	r.dirty = false
+
+	return nil
+}
+
+func (r *JSONRepo) load() error {
+	file, err := os.Open(r.inventoryPath)
+
+	if errors.Is(err, os.ErrNotExist) {
+		// nothing has been discovered yet
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+
+	if err != nil {
+		return err
+	}
+
+	inventory := Inventory{}
+
+	if err := json.Unmarshal(data, &inventory); err != nil {
+		// keep the unreadable file for inspection and start over rather
+		// than preventing startup
+		backup := r.inventoryPath + ".corrupt"
+
+		r.log.Error().
+			Err(err).
+			Str("backup", backup).
+			Msg("failed to read inventory - starting with an empty inventory")
+
+		return os.Rename(r.inventoryPath, backup)
+	}
+
+	if inventory.Hosts != nil {
+		r.hosts = inventory.Hosts
+	}
+
+	return nil
+}
+
+// helpers
+
+func copyHost(h *Host) *Host {
+	copy := *h
+	// host key records are updated in place when recording
+	copy.HostKeys = slices.Clone(h.HostKeys)
+	return &copy
+}
@@ -0,0 +1,104 @@
+package inventory
+
+import (
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/robgonnella/ops/internal/exception"
+)
+
+// MemoryRepo is our repo implementation for hosts that are never persisted
+type MemoryRepo struct {
+	hosts []*Host
+	mux   sync.Mutex
+}
+
+// NewMemoryRepo returns a new in-memory inventory repo
+func NewMemoryRepo() *MemoryRepo {
+	return &MemoryRepo{
+		hosts: []*Host{},
+		mux:   sync.Mutex{},
+	}
+}
+
+// Get returns a host from memory
+func (r *MemoryRepo) Get(configID, id string) (*Host, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if configID == "" || id == "" {
+		return nil, errors.New("config id and host id cannot be empty")
+	}
+
+	idx := r.indexOf(configID, id)
+
+	if idx == -1 {
+		return nil, exception.ErrRecordNotFound
+	}
+
+	return copyHost(r.hosts[idx]), nil
+}
+
+// GetAll returns all hosts in memory for the given config
+func (r *MemoryRepo) GetAll(configID string) ([]*Host, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	hosts := []*Host{}
+
+	for _, h := range r.hosts {
+		if h.ConfigID == configID {
+			hosts = append(hosts, copyHost(h))
+		}
+	}
+
+	return hosts, nil
+}
+
+// Save creates or updates a host in memory
+func (r *MemoryRepo) Save(host *Host) (*Host, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if host.ConfigID == "" || host.ID == "" {
+		return nil, errors.New("config id and host id cannot be empty")
+	}
+
+	copy := copyHost(host)
+
+	if idx := r.indexOf(host.ConfigID, host.ID); idx == -1 {
+		r.hosts = append(r.hosts, copy)
+	} else {
+		r.hosts[idx] = copy
+	}
+
+	return copyHost(copy), nil
+}
+
+// Delete deletes a host from memory
+func (r *MemoryRepo) Delete(configID, id string) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if configID == "" || id == "" {
+		return errors.New("config id and host id cannot be empty")
+	}
+
+	r.hosts = slices.DeleteFunc(r.hosts, func(h *Host) bool {
+		return h.ConfigID == configID && h.ID == id
+	})
+
+	return nil
+}
+
+func (r *MemoryRepo) indexOf(configID, id string) int {
+	return slices.IndexFunc(r.hosts, func(h *Host) bool {
+		return h.ConfigID == configID && h.ID == id
+	})
+}
+
+// Flush is a no-op since hosts are only kept in memory
+func (r *MemoryRepo) Flush() error {
+	return nil
+}
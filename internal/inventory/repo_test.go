@@ -0,0 +1,203 @@
+package inventory_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robgonnella/ops/internal/discovery"
+	"github.com/robgonnella/ops/internal/exception"
+	"github.com/robgonnella/ops/internal/inventory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInventoryJsonRepo(t *testing.T) {
+	testInventoryFile := "inventory.json"
+
+	defer func() {
+		os.RemoveAll(testInventoryFile)
+	}()
+
+	repo, err := inventory.NewJSONRepo(testInventoryFile)
+
+	assert.NoError(t, err)
+
+	t.Run("returns record not found error", func(st *testing.T) {
+		_, err := repo.Get("1", "aa:bb:cc:dd:ee:ff")
+
+		assert.Error(st, err)
+		assert.Equal(st, exception.ErrRecordNotFound, err)
+	})
+
+	t.Run("saves, reads, updates, and destroys host", func(st *testing.T) {
+		host := &inventory.Host{
+			ID:        "aa:bb:cc:dd:ee:ff",
+			ConfigID:  "1",
+			Hostname:  "hostname",
+			IP:        "192.168.1.2",
+			OS:        "os",
+			Vendor:    "vendor",
+			Status:    discovery.ServerOnline,
+			FirstSeen: time.Now().UTC(),
+			LastSeen:  time.Now().UTC(),
+		}
+
+		saved, err := repo.Save(host)
+
+		assert.NoError(st, err)
+		assert.Equal(st, host, saved)
+
+		found, err := repo.Get(host.ConfigID, host.ID)
+
+		assert.NoError(st, err)
+		assert.Equal(st, host, found)
+
+		found.IP = "192.168.1.3"
+
+		updated, err := repo.Save(found)
+
+		assert.NoError(st, err)
+		assert.Equal(st, "192.168.1.3", updated.IP)
+
+		all, err := repo.GetAll(host.ConfigID)
+
+		assert.NoError(st, err)
+		assert.Equal(st, 1, len(all))
+
+		err = repo.Delete(host.ConfigID, host.ID)
+
+		assert.NoError(st, err)
+
+		deleted, err := repo.Get(host.ConfigID, host.ID)
+
+		assert.Error(st, err)
+		assert.Equal(st, exception.ErrRecordNotFound, err)
+		assert.Nil(st, deleted)
+	})
+
+	t.Run("scopes hosts by config", func(st *testing.T) {
+		host1 := &inventory.Host{ID: "00:00:00:00:00:01", ConfigID: "2"}
+		host2 := &inventory.Host{ID: "00:00:00:00:00:02", ConfigID: "3"}
+
+		_, err := repo.Save(host1)
+		assert.NoError(st, err)
+
+		_, err = repo.Save(host2)
+		assert.NoError(st, err)
+
+		hosts, err := repo.GetAll("2")
+
+		assert.NoError(st, err)
+		assert.Equal(st, 1, len(hosts))
+		assert.Equal(st, host1.ID, hosts[0].ID)
+	})
+
+	t.Run("defers writes until flushed", func(st *testing.T) {
+		unflushed, err := inventory.NewJSONRepo(testInventoryFile)
+
+		assert.NoError(st, err)
+
+		hosts, err := unflushed.GetAll("3")
+
+		assert.NoError(st, err)
+		assert.Empty(st, hosts)
+
+		assert.NoError(st, repo.Flush())
+	})
+
+	t.Run("loads persisted hosts", func(st *testing.T) {
+		reloaded, err := inventory.NewJSONRepo(testInventoryFile)
+
+		assert.NoError(st, err)
+
+		hosts, err := reloaded.GetAll("3")
+
+		assert.NoError(st, err)
+		assert.Equal(st, 1, len(hosts))
+		assert.Equal(st, "00:00:00:00:00:02", hosts[0].ID)
+	})
+
+	t.Run("does not leave temp files behind", func(st *testing.T) {
+		matches, err := filepath.Glob(testInventoryFile + ".*.tmp")
+
+		assert.NoError(st, err)
+		assert.Empty(st, matches)
+	})
+}
+
+func TestInventoryJsonRepoCorruptFile(t *testing.T) {
+	inventoryFile := filepath.Join(t.TempDir(), "inventory.json")
+
+	err := os.WriteFile(inventoryFile, []byte(`{"hosts": [{"id": "aa:bb`), 0644)
+
+	assert.NoError(t, err)
+
+	repo, err := inventory.NewJSONRepo(inventoryFile)
+
+	assert.NoError(t, err)
+
+	hosts, err := repo.GetAll("1")
+
+	assert.NoError(t, err)
+	assert.Empty(t, hosts)
+
+	_, err = os.Stat(inventoryFile + ".corrupt")
+
+	assert.NoError(t, err)
+
+	_, err = repo.Save(&inventory.Host{ID: "aa:bb:cc:dd:ee:ff", ConfigID: "1"})
+
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Flush())
+
+	reloaded, err := inventory.NewJSONRepo(inventoryFile)
+
+	assert.NoError(t, err)
+
+	hosts, err = reloaded.GetAll("1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(hosts))
+}
+
+func TestInventoryMemoryRepo(t *testing.T) {
+	repo := inventory.NewMemoryRepo()
+
+	host := &inventory.Host{
+		ID:       "aa:bb:cc:dd:ee:ff",
+		ConfigID: "1",
+		Hostname: "hostname",
+		IP:       "192.168.1.2",
+		Status:   discovery.ServerOnline,
+	}
+
+	t.Run("returns record not found error", func(st *testing.T) {
+		_, err := repo.Get(host.ConfigID, host.ID)
+
+		assert.Equal(st, exception.ErrRecordNotFound, err)
+	})
+
+	t.Run("saves, reads, and destroys host", func(st *testing.T) {
+		saved, err := repo.Save(host)
+
+		assert.NoError(st, err)
+		assert.Equal(st, host, saved)
+
+		found, err := repo.Get(host.ConfigID, host.ID)
+
+		assert.NoError(st, err)
+		assert.Equal(st, host, found)
+
+		all, err := repo.GetAll(host.ConfigID)
+
+		assert.NoError(st, err)
+		assert.Equal(st, []*inventory.Host{host}, all)
+
+		assert.NoError(st, repo.Delete(host.ConfigID, host.ID))
+
+		_, err = repo.Get(host.ConfigID, host.ID)
+
+		assert.Equal(st, exception.ErrRecordNotFound, err)
+	})
+}
@@ -0,0 +1,182 @@
+package inventory
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/robgonnella/ops/internal/discovery"
+	"github.com/robgonnella/ops/internal/exception"
+)
+
+// unknown is the placeholder value used by discovery for missing details
+const unknown = "Unknown"
+
+// nolint:revive
+// InventoryService is an implementation of the inventory.Service interface.
+// Results are recorded concurrently so updates to stored hosts are
+// serialized to prevent them from overwriting each other.
+type InventoryService struct {
+	repo Repo
+	mux  sync.Mutex
+}
+
+// NewInventoryService returns a new instance of InventoryService
+func NewInventoryService(repo Repo) *InventoryService {
+	return &InventoryService{repo: repo}
+}
+
+// Get returns a host by config id and host id
+func (s *InventoryService) Get(configID, id string) (*Host, error) {
+	return s.repo.Get(configID, id)
+}
+
+// GetAll returns all stored hosts for the given config
+func (s *InventoryService) GetAll(configID string) ([]*Host, error) {
+	return s.repo.GetAll(configID)
+}
+
+// Record merges a discovery result into the stored record for that host,
+// creating the record if this is the first time the host has been seen
+func (s *InventoryService) Record(
+	configID string,
+	result discovery.DiscoveryResult,
+) (*Host, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	now := time.Now()
+
+	host, err := s.repo.Get(configID, result.ID)
+
+	if err != nil {
+		if !errors.Is(err, exception.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		host = &Host{
+			ID:        result.ID,
+			ConfigID:  configID,
+			Hostname:  unknown,
+			OS:        unknown,
+			FirstSeen: now,
+		}
+	}
+
+	host.IP = result.IP
+
+	// hostnames reported over ssh take precedence over resolved hostnames
+	sshHostname := host.HostnameSource == discovery.HostnameSourceSSH &&
+		result.HostnameSource != discovery.HostnameSourceSSH
+
+	if result.Hostname != "" && result.Hostname != unknown && !sshHostname {
+		host.Hostname = result.Hostname
+		host.HostnameSource = result.HostnameSource
+	}
+
+	if result.OS != "" && result.OS != unknown {
+		host.OS = result.OS
+	}
+
+	// syn results do not include vendor info
+	if result.Vendor != "" {
+		host.Vendor = result.Vendor
+	}
+
+	if result.Type == discovery.SynUpdateEvent {
+		host.Port = result.Port
+	}
+
+	// arp results do not include port info
+	if result.OpenPorts != nil {
+		host.OpenPorts = result.OpenPorts
+	}
+
+	if result.Banners != nil {
+		host.Banners = result.Banners
+	}
+
+	if result.HostKey != nil {
+		recordHostKey(host, *result.HostKey, result.IP, now)
+	}
+
+	if result.Facts != nil {
+		host.Facts = result.Facts
+	}
+
+	if result.Status != "" {
+		host.Status = result.Status
+	}
+
+	if result.Status == discovery.ServerOnline {
+		host.LastSeen = now
+	}
+
+	return s.repo.Save(host)
+}
+
+// TrustHostKey acknowledges a change in the host's ssh host key so the
+// current key is trusted going forward. The change is persisted immediately.
+func (s *InventoryService) TrustHostKey(configID, id string) (*Host, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	host, err := s.repo.Get(configID, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	host.HostKeyChanged = false
+
+	saved, err := s.repo.Save(host)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Flush(); err != nil {
+		return nil, err
+	}
+
+	return saved, nil
+}
+
+// Delete deletes a host
+func (s *InventoryService) Delete(configID, id string) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.repo.Delete(configID, id)
+}
+
+// Flush persists all recorded hosts
+func (s *InventoryService) Flush() error {
+	return s.repo.Flush()
+}
+
+// adds the given key to the host's key history flagging the host if the
+// key differs from the most recently recorded key
+func recordHostKey(host *Host, key discovery.HostKey, ip string, now time.Time) {
+	count := len(host.HostKeys)
+
+	if count > 0 {
+		last := &host.HostKeys[count-1]
+
+		if last.Key() == key {
+			last.IP = ip
+			last.LastSeen = now
+			return
+		}
+
+		host.HostKeyChanged = true
+	}
+
+	host.HostKeys = append(host.HostKeys, HostKeyRecord{
+		Type:        key.Type,
+		Fingerprint: key.Fingerprint,
+		IP:          ip,
+		FirstSeen:   now,
+		LastSeen:    now,
+	})
+}
@@ -0,0 +1,363 @@
+package inventory_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/robgonnella/ops/internal/discovery"
+	"github.com/robgonnella/ops/internal/exception"
+	"github.com/robgonnella/ops/internal/inventory"
+	mock_inventory "github.com/robgonnella/ops/internal/mock/inventory"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestInventoryService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	mockRepo := mock_inventory.NewMockRepo(ctrl)
+
+	service := inventory.NewInventoryService(mockRepo)
+
+	t.Run("gets host", func(st *testing.T) {
+		expected := &inventory.Host{ID: "aa:bb:cc:dd:ee:ff", ConfigID: "1"}
+
+		mockRepo.EXPECT().Get("1", expected.ID).Return(expected, nil)
+
+		found, err := service.Get("1", expected.ID)
+
+		assert.NoError(st, err)
+		assert.Equal(st, expected, found)
+	})
+
+	t.Run("records newly discovered host", func(st *testing.T) {
+		result := discovery.DiscoveryResult{
+			Type:     discovery.ArpUpdateEvent,
+			ID:       "aa:bb:cc:dd:ee:ff",
+			Hostname: "Unknown",
+			IP:       "192.168.1.2",
+			OS:       "Unknown",
+			Vendor:   "vendor",
+			Status:   discovery.ServerOnline,
+		}
+
+		mockRepo.EXPECT().
+			Get("1", result.ID).
+			Return(nil, exception.ErrRecordNotFound)
+
+		mockRepo.EXPECT().
+			Save(gomock.Any()).
+			DoAndReturn(func(h *inventory.Host) (*inventory.Host, error) {
+				return h, nil
+			})
+
+		host, err := service.Record("1", result)
+
+		assert.NoError(st, err)
+		assert.Equal(st, "1", host.ConfigID)
+		assert.Equal(st, result.IP, host.IP)
+		assert.Equal(st, result.Vendor, host.Vendor)
+		assert.False(st, host.FirstSeen.IsZero())
+		assert.Equal(st, host.FirstSeen, host.LastSeen)
+	})
+
+	t.Run("merges result into existing host", func(st *testing.T) {
+		firstSeen := time.Now().Add(-time.Hour)
+
+		existing := &inventory.Host{
+			ID:        "aa:bb:cc:dd:ee:ff",
+			ConfigID:  "1",
+			Hostname:  "hostname",
+			IP:        "192.168.1.2",
+			OS:        "os",
+			Vendor:    "vendor",
+			Status:    discovery.ServerOnline,
+			FirstSeen: firstSeen,
+			LastSeen:  firstSeen,
+		}
+
+		result := discovery.DiscoveryResult{
+			Type:     discovery.SynUpdateEvent,
+			ID:       existing.ID,
+			Hostname: "Unknown",
+			IP:       "192.168.1.3",
+			OS:       "Unknown",
+			Status:   discovery.ServerOnline,
+			Port: discovery.Port{
+				ID:     22,
+				Status: discovery.PortOpen,
+			},
+		}
+
+		mockRepo.EXPECT().Get("1", existing.ID).Return(existing, nil)
+
+		mockRepo.EXPECT().
+			Save(gomock.Any()).
+			DoAndReturn(func(h *inventory.Host) (*inventory.Host, error) {
+				return h, nil
+			})
+
+		host, err := service.Record("1", result)
+
+		assert.NoError(st, err)
+		assert.Equal(st, "hostname", host.Hostname)
+		assert.Equal(st, "os", host.OS)
+		assert.Equal(st, "vendor", host.Vendor)
+		assert.Equal(st, "192.168.1.3", host.IP)
+		assert.Equal(st, result.Port, host.Port)
+		assert.Equal(st, firstSeen, host.FirstSeen)
+		assert.True(st, host.LastSeen.After(firstSeen))
+	})
+
+	t.Run("keeps facts when result has none", func(st *testing.T) {
+		facts := &discovery.Facts{Kernel: "6.1.0", CPUCount: 4}
+
+		existing := &inventory.Host{
+			ID:       "aa:bb:cc:dd:ee:ff",
+			ConfigID: "1",
+			Facts:    facts,
+		}
+
+		result := discovery.DiscoveryResult{
+			Type:   discovery.ArpUpdateEvent,
+			ID:     existing.ID,
+			IP:     "192.168.1.2",
+			Status: discovery.ServerOnline,
+		}
+
+		mockRepo.EXPECT().Get("1", existing.ID).Return(existing, nil)
+
+		mockRepo.EXPECT().
+			Save(gomock.Any()).
+			DoAndReturn(func(h *inventory.Host) (*inventory.Host, error) {
+				return h, nil
+			})
+
+		host, err := service.Record("1", result)
+
+		assert.NoError(st, err)
+		assert.Equal(st, facts, host.Facts)
+	})
+
+	t.Run("records open ports and banners from port updates", func(st *testing.T) {
+		banner := discovery.Banner{
+			Port:     80,
+			Protocol: discovery.BannerProtocolHTTP,
+			Product:  "nginx",
+		}
+
+		existing := &inventory.Host{
+			ID:        "aa:bb:cc:dd:ee:ff",
+			ConfigID:  "1",
+			OpenPorts: []uint16{22},
+		}
+
+		result := discovery.DiscoveryResult{
+			Type:      discovery.PortUpdateEvent,
+			ID:        existing.ID,
+			IP:        "192.168.1.2",
+			Status:    discovery.ServerOnline,
+			OpenPorts: []uint16{22, 80},
+			Banners:   []discovery.Banner{banner},
+		}
+
+		mockRepo.EXPECT().Get("1", existing.ID).Return(existing, nil)
+
+		mockRepo.EXPECT().
+			Save(gomock.Any()).
+			DoAndReturn(func(h *inventory.Host) (*inventory.Host, error) {
+				return h, nil
+			})
+
+		host, err := service.Record("1", result)
+
+		assert.NoError(st, err)
+		assert.Equal(st, []uint16{22, 80}, host.OpenPorts)
+		assert.Equal(st, []discovery.Banner{banner}, host.Banners)
+	})
+
+	t.Run("prefers ssh hostname over resolved hostname", func(st *testing.T) {
+		existing := &inventory.Host{
+			ID:             "aa:bb:cc:dd:ee:ff",
+			ConfigID:       "1",
+			Hostname:       "ssh-host",
+			HostnameSource: discovery.HostnameSourceSSH,
+		}
+
+		result := discovery.DiscoveryResult{
+			Type:           discovery.HostnameResolvedEvent,
+			ID:             existing.ID,
+			Hostname:       "ptr-host.lan",
+			HostnameSource: discovery.HostnameSourcePTR,
+			IP:             "192.168.1.2",
+			Status:         discovery.ServerOnline,
+		}
+
+		mockRepo.EXPECT().Get("1", existing.ID).Return(existing, nil)
+
+		mockRepo.EXPECT().
+			Save(gomock.Any()).
+			DoAndReturn(func(h *inventory.Host) (*inventory.Host, error) {
+				return h, nil
+			})
+
+		host, err := service.Record("1", result)
+
+		assert.NoError(st, err)
+		assert.Equal(st, "ssh-host", host.Hostname)
+		assert.Equal(st, discovery.HostnameSourceSSH, host.HostnameSource)
+	})
+
+	t.Run("records host key history and flags changes", func(st *testing.T) {
+		seen := time.Now().Add(-time.Hour)
+
+		existing := &inventory.Host{
+			ID:       "aa:bb:cc:dd:ee:ff",
+			ConfigID: "1",
+			HostKeys: []inventory.HostKeyRecord{{
+				Type:        "ssh-ed25519",
+				Fingerprint: "SHA256:old",
+				IP:          "192.168.1.2",
+				FirstSeen:   seen,
+				LastSeen:    seen,
+			}},
+		}
+
+		result := discovery.DiscoveryResult{
+			Type:   discovery.SynUpdateEvent,
+			ID:     existing.ID,
+			IP:     "192.168.1.2",
+			Status: discovery.ServerOnline,
+			HostKey: &discovery.HostKey{
+				Type:        "ssh-ed25519",
+				Fingerprint: "SHA256:new",
+			},
+		}
+
+		mockRepo.EXPECT().Get("1", existing.ID).Return(existing, nil)
+
+		mockRepo.EXPECT().
+			Save(gomock.Any()).
+			DoAndReturn(func(h *inventory.Host) (*inventory.Host, error) {
+				return h, nil
+			})
+
+		host, err := service.Record("1", result)
+
+		assert.NoError(st, err)
+		assert.True(st, host.HostKeyChanged)
+		assert.Len(st, host.HostKeys, 2)
+
+		change, ok := host.NewHostKeyChange()
+
+		assert.True(st, ok)
+		assert.Equal(st, discovery.HostKeyChange{
+			ConfigID: "1",
+			ID:       existing.ID,
+			IP:       "192.168.1.2",
+			Previous: discovery.HostKey{Type: "ssh-ed25519", Fingerprint: "SHA256:old"},
+			Current:  *result.HostKey,
+		}, change)
+
+		// seeing the same key again is no longer a new change
+		mockRepo.EXPECT().Get("1", existing.ID).Return(host, nil)
+
+		mockRepo.EXPECT().
+			Save(gomock.Any()).
+			DoAndReturn(func(h *inventory.Host) (*inventory.Host, error) {
+				return h, nil
+			})
+
+		host, err = service.Record("1", result)
+
+		assert.NoError(st, err)
+		assert.Len(st, host.HostKeys, 2)
+
+		_, ok = host.NewHostKeyChange()
+		assert.False(st, ok)
+
+		_, ok = host.HostKeyChange()
+		assert.True(st, ok)
+	})
+
+	t.Run("trusts changed host key", func(st *testing.T) {
+		existing := &inventory.Host{
+			ID:             "aa:bb:cc:dd:ee:ff",
+			ConfigID:       "1",
+			HostKeyChanged: true,
+		}
+
+		mockRepo.EXPECT().Get("1", existing.ID).Return(existing, nil)
+
+		mockRepo.EXPECT().
+			Save(gomock.Any()).
+			DoAndReturn(func(h *inventory.Host) (*inventory.Host, error) {
+				return h, nil
+			})
+
+		mockRepo.EXPECT().Flush().Return(nil)
+
+		host, err := service.TrustHostKey("1", existing.ID)
+
+		assert.NoError(st, err)
+		assert.False(st, host.HostKeyChanged)
+	})
+
+	t.Run("deletes host", func(st *testing.T) {
+		mockRepo.EXPECT().Delete("1", "aa:bb:cc:dd:ee:ff").Return(nil)
+
+		err := service.Delete("1", "aa:bb:cc:dd:ee:ff")
+
+		assert.NoError(st, err)
+	})
+
+	t.Run("flushes repo", func(st *testing.T) {
+		mockRepo.EXPECT().Flush().Return(nil)
+
+		err := service.Flush()
+
+		assert.NoError(st, err)
+	})
+}
+
+func TestInventoryServiceConcurrentRecords(t *testing.T) {
+	service := inventory.NewInventoryService(inventory.NewMemoryRepo())
+
+	result := discovery.DiscoveryResult{
+		Type:     discovery.PortUpdateEvent,
+		ConfigID: "1",
+		ID:       "aa:bb:cc:dd:ee:ff",
+		IP:       "192.168.1.2",
+		Status:   discovery.ServerOnline,
+	}
+
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			r := result
+			r.HostKey = &discovery.HostKey{
+				Type:        "ssh-ed25519",
+				Fingerprint: fmt.Sprintf("SHA256:%d", i),
+			}
+
+			_, err := service.Record("1", r)
+			assert.NoError(t, err)
+		}(i)
+	}
+
+	wg.Wait()
+
+	host, err := service.Get("1", result.ID)
+
+	assert.NoError(t, err)
+	assert.Len(t, host.HostKeys, 50)
+}
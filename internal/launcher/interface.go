@@ -0,0 +1,8 @@
+package launcher
+
+//go:generate mockgen -destination=../mock/launcher/mock_launcher.go -package=mock_launcher . Launcher
+
+// Launcher interface for opening interactive sessions
+type Launcher interface {
+	Launch(session Session) error
+}
@@ -0,0 +1,242 @@
+package launcher
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/robgonnella/ops/internal/config"
+)
+
+// ErrNotInTmux returned when using a tmux launcher outside of tmux
+var ErrNotInTmux = errors.New("tmux launcher requires running ops inside tmux")
+
+// ErrNotInScreen returned when using the screen launcher outside of screen
+var ErrNotInScreen = errors.New("screen launcher requires running ops inside screen")
+
+// matches arguments that never need quoting in a shell
+var safeShellArg = regexp.MustCompile(`^[a-zA-Z0-9_@%+=:,./-]+$`)
+
+// Session represents an interactive session to open for a single host
+type Session struct {
+	// Name used to title new windows - usually the hostname or ip
+	Name     string
+	IP       string
+	Hostname string
+	User     string
+	Port     string
+	Identity string
+	// Command the command and arguments to run e.g. ssh -l user 10.0.0.1
+	Command []string
+}
+
+// CommandString returns the session's command quoted for use in a shell
+func (s Session) CommandString() string {
+	quoted := make([]string, len(s.Command))
+
+	for i, arg := range s.Command {
+		quoted[i] = ShellQuote(arg)
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// ShellQuote quotes the given argument for safe use in a posix shell
+func ShellQuote(arg string) string {
+	if safeShellArg.MatchString(arg) {
+		return arg
+	}
+
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// New returns the launcher for the given session config. Suspend is used by
+// the inline launcher to hand the terminal over to the session.
+func New(conf config.SessionConfig, suspend func(f func())) (Launcher, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch conf.Kind() {
+	case config.LauncherTmuxWindow:
+		return NewTmuxLauncher(false), nil
+	case config.LauncherTmuxPane:
+		return NewTmuxLauncher(true), nil
+	case config.LauncherScreen:
+		return NewScreenLauncher(), nil
+	case config.LauncherTemplate:
+		return NewTemplateLauncher(conf.Template)
+	default:
+		return NewInlineLauncher(suspend), nil
+	}
+}
+
+// InlineLauncher runs sessions in the current terminal
+type InlineLauncher struct {
+	suspend func(f func())
+}
+
+// NewInlineLauncher returns a new instance of InlineLauncher
+func NewInlineLauncher(suspend func(f func())) *InlineLauncher {
+	return &InlineLauncher{suspend: suspend}
+}
+
+// Launch runs the session attached to the terminal while the ui is
+// suspended and returns once it ends
+func (l *InlineLauncher) Launch(session Session) error {
+	var err error
+
+	l.suspend(func() {
+		cmd := exec.Command(session.Command[0], session.Command[1:]...)
+
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.Stdin = os.Stdin
+
+		err = cmd.Run()
+	})
+
+	return err
+}
+
+// TmuxLauncher opens sessions in a new tmux window or pane
+type TmuxLauncher struct {
+	pane bool
+}
+
+// NewTmuxLauncher returns a new instance of TmuxLauncher. If pane is true
+// sessions are opened in a pane split from the current one.
+func NewTmuxLauncher(pane bool) *TmuxLauncher {
+	return &TmuxLauncher{pane: pane}
+}
+
+// Launch opens the session in tmux and returns without waiting for it
+func (l *TmuxLauncher) Launch(session Session) error {
+	if os.Getenv("TMUX") == "" {
+		return ErrNotInTmux
+	}
+
+	args := []string{"new-window", "-n", session.Name, session.CommandString()}
+
+	if l.pane {
+		args = []string{"split-window", session.CommandString()}
+	}
+
+	return run("tmux", args...)
+}
+
+// ScreenLauncher opens sessions in a new GNU screen window
+type ScreenLauncher struct{}
+
+// NewScreenLauncher returns a new instance of ScreenLauncher
+func NewScreenLauncher() *ScreenLauncher {
+	return &ScreenLauncher{}
+}
+
+// Launch opens the session in the current screen session and returns
+// without waiting for it
+func (l *ScreenLauncher) Launch(session Session) error {
+	sty := os.Getenv("STY")
+
+	if sty == "" {
+		return ErrNotInScreen
+	}
+
+	args := append(
+		[]string{"-S", sty, "-X", "screen", "-t", session.Name},
+		session.Command...,
+	)
+
+	return run("screen", args...)
+}
+
+// TemplateLauncher opens sessions by running a user supplied command
+// template with sh -c
+type TemplateLauncher struct {
+	tmpl *template.Template
+}
+
+// templateData data available to session templates. Every field is quoted
+// for the shell so values reported by remote hosts, like hostnames, cannot
+// inject commands.
+type templateData struct {
+	IP       string
+	Hostname string
+	User     string
+	Port     string
+	Identity string
+	Command  string
+}
+
+// NewTemplateLauncher returns a new instance of TemplateLauncher
+func NewTemplateLauncher(text string) (*TemplateLauncher, error) {
+	tmpl, err := template.New("session").Option("missingkey=error").Parse(text)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid session launcher template: %w", err)
+	}
+
+	return &TemplateLauncher{tmpl: tmpl}, nil
+}
+
+// Render returns the shell command for the given session with every
+// session value quoted for the shell
+func (l *TemplateLauncher) Render(session Session) (string, error) {
+	buf := bytes.Buffer{}
+
+	err := l.tmpl.Execute(&buf, templateData{
+		IP:       ShellQuote(session.IP),
+		Hostname: ShellQuote(session.Hostname),
+		User:     ShellQuote(session.User),
+		Port:     ShellQuote(session.Port),
+		Identity: ShellQuote(session.Identity),
+		Command:  session.CommandString(),
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("failed to render session launcher template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// Launch starts the rendered command and returns without waiting for it
+// to finish e.g. a terminal emulator opening a new tab
+func (l *TemplateLauncher) Launch(session Session) error {
+	command, err := l.Render(session)
+
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("sh", "-c", command)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// reap the process once it exits
+	go cmd.Wait()
+
+	return nil
+}
+
+// runs a command returning its output in the error if it fails
+func run(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,212 @@
+package launcher_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robgonnella/ops/internal/config"
+	"github.com/robgonnella/ops/internal/launcher"
+	"github.com/stretchr/testify/assert"
+)
+
+// writes a fake executable to a temp directory at the front of PATH that
+// records its arguments one per line to the returned file
+func fakeCommand(t *testing.T, name string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, name+".args")
+
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" >> " + out + "\n"
+
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	return out
+}
+
+// returns the lines written to the given file waiting briefly for it to
+// be written
+func readLines(t *testing.T, file string) []string {
+	t.Helper()
+
+	var data []byte
+
+	assert.Eventually(t, func() bool {
+		var err error
+		data, err = os.ReadFile(file)
+		return err == nil && len(data) > 0
+	}, time.Second*5, time.Millisecond*10)
+
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestLauncher(t *testing.T) {
+	session := launcher.Session{
+		Name:     "web01",
+		IP:       "192.168.1.2",
+		Hostname: "web01",
+		User:     "deploy",
+		Port:     "22",
+		Identity: "/home/me/.ssh/id rsa",
+		Command: []string{
+			"ssh", "-i", "/home/me/.ssh/id rsa", "-p", "22", "-l", "deploy", "192.168.1.2",
+		},
+	}
+
+	t.Run("quotes shell arguments", func(st *testing.T) {
+		assert.Equal(st, "192.168.1.2", launcher.ShellQuote("192.168.1.2"))
+		assert.Equal(st, "'a b'", launcher.ShellQuote("a b"))
+		assert.Equal(st, `'it'\''s'`, launcher.ShellQuote("it's"))
+		assert.Equal(st, "''", launcher.ShellQuote(""))
+		assert.Equal(
+			st,
+			"ssh -i '/home/me/.ssh/id rsa' -p 22 -l deploy 192.168.1.2",
+			session.CommandString(),
+		)
+	})
+
+	t.Run("runs inline sessions while suspended", func(st *testing.T) {
+		suspended := false
+
+		l, err := launcher.New(config.SessionConfig{}, func(f func()) {
+			suspended = true
+			f()
+		})
+
+		assert.NoError(st, err)
+
+		err = l.Launch(launcher.Session{Command: []string{"true"}})
+
+		assert.NoError(st, err)
+		assert.True(st, suspended)
+
+		err = l.Launch(launcher.Session{Command: []string{"false"}})
+
+		assert.Error(st, err)
+	})
+
+	t.Run("opens tmux windows", func(st *testing.T) {
+		out := fakeCommand(st, "tmux")
+		st.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+
+		l, err := launcher.New(config.SessionConfig{Launcher: config.LauncherTmuxWindow}, nil)
+
+		assert.NoError(st, err)
+		assert.NoError(st, l.Launch(session))
+		assert.Equal(st, []string{"new-window", "-n", "web01", session.CommandString()}, readLines(st, out))
+	})
+
+	t.Run("opens tmux panes", func(st *testing.T) {
+		out := fakeCommand(st, "tmux")
+		st.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+
+		l, err := launcher.New(config.SessionConfig{Launcher: config.LauncherTmuxPane}, nil)
+
+		assert.NoError(st, err)
+		assert.NoError(st, l.Launch(session))
+		assert.Equal(st, []string{"split-window", session.CommandString()}, readLines(st, out))
+	})
+
+	t.Run("returns error outside of tmux", func(st *testing.T) {
+		st.Setenv("TMUX", "")
+
+		l := launcher.NewTmuxLauncher(false)
+
+		assert.ErrorIs(st, l.Launch(session), launcher.ErrNotInTmux)
+	})
+
+	t.Run("opens screen windows", func(st *testing.T) {
+		out := fakeCommand(st, "screen")
+		st.Setenv("STY", "1234.pts-0.host")
+
+		l, err := launcher.New(config.SessionConfig{Launcher: config.LauncherScreen}, nil)
+
+		assert.NoError(st, err)
+		assert.NoError(st, l.Launch(session))
+		assert.Equal(
+			st,
+			append([]string{"-S", "1234.pts-0.host", "-X", "screen", "-t", "web01"}, session.Command...),
+			readLines(st, out),
+		)
+	})
+
+	t.Run("returns error outside of screen", func(st *testing.T) {
+		st.Setenv("STY", "")
+
+		assert.ErrorIs(st, launcher.NewScreenLauncher().Launch(session), launcher.ErrNotInScreen)
+	})
+
+	t.Run("runs command templates", func(st *testing.T) {
+		out := filepath.Join(st.TempDir(), "template.out")
+
+		l, err := launcher.New(config.SessionConfig{
+			Launcher: config.LauncherTemplate,
+			Template: "echo {{.Hostname}} {{.IP}} {{.User}} {{.Port}} > " + out,
+		}, nil)
+
+		assert.NoError(st, err)
+		assert.NoError(st, l.Launch(session))
+		assert.Equal(st, []string{"web01 192.168.1.2 deploy 22"}, readLines(st, out))
+	})
+
+	t.Run("renders quoted commands in templates", func(st *testing.T) {
+		l, err := launcher.NewTemplateLauncher("wezterm cli spawn -- {{.Command}}")
+
+		assert.NoError(st, err)
+
+		command, err := l.Render(session)
+
+		assert.NoError(st, err)
+		assert.Equal(st, "wezterm cli spawn -- "+session.CommandString(), command)
+	})
+
+	t.Run("quotes session values in templates", func(st *testing.T) {
+		l, err := launcher.NewTemplateLauncher("echo {{.Hostname}} {{.Identity}}")
+
+		assert.NoError(st, err)
+
+		unsafe := session
+		unsafe.Hostname = "web01; touch pwned $(id) 'x'"
+
+		command, err := l.Render(unsafe)
+
+		assert.NoError(st, err)
+		assert.Equal(
+			st,
+			`echo 'web01; touch pwned $(id) '\''x'\''' '/home/me/.ssh/id rsa'`,
+			command,
+		)
+	})
+
+	t.Run("does not run commands injected through session values", func(st *testing.T) {
+		dir := st.TempDir()
+		out := filepath.Join(dir, "action.out")
+
+		// e.g. a custom action opening a vnc viewer for the server
+		l, err := launcher.NewTemplateLauncher(
+			"cd " + dir + " && printf '%s\\n' {{.Hostname}} {{.User}} > " + out,
+		)
+
+		assert.NoError(st, err)
+
+		unsafe := session
+		unsafe.Hostname = "web01;touch pwned;$(touch pwned)'"
+		unsafe.User = "$(touch pwned)"
+
+		assert.NoError(st, l.Launch(unsafe))
+		assert.Equal(st, []string{unsafe.Hostname, unsafe.User}, readLines(st, out))
+		assert.NoFileExists(st, filepath.Join(dir, "pwned"))
+	})
+
+	t.Run("returns error for invalid config", func(st *testing.T) {
+		_, err := launcher.New(config.SessionConfig{Launcher: "xterm"}, nil)
+		assert.Error(st, err)
+	})
+}
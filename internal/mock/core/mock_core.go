@@ -0,0 +1,112 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/robgonnella/ops/internal/core (interfaces: CommandRunner,FileTransferer)
+//
+// Generated by this command:
+//
+//	mockgen -destination=../mock/core/mock_core.go -package=mock_core . CommandRunner,FileTransferer
+//
+
+// Package mock_core is a generated GoMock package.
+package mock_core
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	core "github.com/robgonnella/ops/internal/core"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCommandRunner is a mock of CommandRunner interface.
+type MockCommandRunner struct {
+	ctrl     *gomock.Controller
+	recorder *MockCommandRunnerMockRecorder
+}
+
+// MockCommandRunnerMockRecorder is the mock recorder for MockCommandRunner.
+type MockCommandRunnerMockRecorder struct {
+	mock *MockCommandRunner
+}
+
+// NewMockCommandRunner creates a new mock instance.
+func NewMockCommandRunner(ctrl *gomock.Controller) *MockCommandRunner {
+	mock := &MockCommandRunner{ctrl: ctrl}
+	mock.recorder = &MockCommandRunnerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCommandRunner) EXPECT() *MockCommandRunnerMockRecorder {
+	return m.recorder
+}
+
+// Run mocks base method.
+func (m *MockCommandRunner) Run(arg0 context.Context, arg1 core.ExecTarget, arg2 string, arg3, arg4 io.Writer) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Run", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockCommandRunnerMockRecorder) Run(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockCommandRunner)(nil).Run), arg0, arg1, arg2, arg3, arg4)
+}
+
+// MockFileTransferer is a mock of FileTransferer interface.
+type MockFileTransferer struct {
+	ctrl     *gomock.Controller
+	recorder *MockFileTransfererMockRecorder
+}
+
+// MockFileTransfererMockRecorder is the mock recorder for MockFileTransferer.
+type MockFileTransfererMockRecorder struct {
+	mock *MockFileTransferer
+}
+
+// NewMockFileTransferer creates a new mock instance.
+func NewMockFileTransferer(ctrl *gomock.Controller) *MockFileTransferer {
+	mock := &MockFileTransferer{ctrl: ctrl}
+	mock.recorder = &MockFileTransfererMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFileTransferer) EXPECT() *MockFileTransfererMockRecorder {
+	return m.recorder
+}
+
+// Download mocks base method.
+func (m *MockFileTransferer) Download(arg0 context.Context, arg1 core.ExecTarget, arg2, arg3 string, arg4 func(int64, int64)) (string, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Download", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Download indicates an expected call of Download.
+func (mr *MockFileTransfererMockRecorder) Download(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Download", reflect.TypeOf((*MockFileTransferer)(nil).Download), arg0, arg1, arg2, arg3, arg4)
+}
+
+// Upload mocks base method.
+func (m *MockFileTransferer) Upload(arg0 context.Context, arg1 core.ExecTarget, arg2, arg3 string, arg4 func(int64, int64)) (string, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upload", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Upload indicates an expected call of Upload.
+func (mr *MockFileTransfererMockRecorder) Upload(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upload", reflect.TypeOf((*MockFileTransferer)(nil).Upload), arg0, arg1, arg2, arg3, arg4)
+}
@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/robgonnella/ops/internal/discovery (interfaces: DetailScanner,Scanner)
+// Source: github.com/robgonnella/ops/internal/discovery (interfaces: BannerGrabber,DetailScanner,HostKeyScanner,HostnameResolver,Scanner)
 //
 // Generated by this command:
 //
-//	mockgen -destination=../mock/discovery/mock_discovery.go -package=mock_discovery . DetailScanner,Scanner
+//	mockgen -destination=../mock/discovery/mock_discovery.go -package=mock_discovery . BannerGrabber,DetailScanner,HostKeyScanner,HostnameResolver,Scanner
 //
 
 // Package mock_discovery is a generated GoMock package.
@@ -14,10 +14,49 @@ import (
 	reflect "reflect"
 
 	scanner "github.com/robgonnella/go-lanscan/pkg/scanner"
+	config "github.com/robgonnella/ops/internal/config"
 	discovery "github.com/robgonnella/ops/internal/discovery"
 	gomock "go.uber.org/mock/gomock"
 )
 
+// MockBannerGrabber is a mock of BannerGrabber interface.
+type MockBannerGrabber struct {
+	ctrl     *gomock.Controller
+	recorder *MockBannerGrabberMockRecorder
+}
+
+// MockBannerGrabberMockRecorder is the mock recorder for MockBannerGrabber.
+type MockBannerGrabberMockRecorder struct {
+	mock *MockBannerGrabber
+}
+
+// NewMockBannerGrabber creates a new mock instance.
+func NewMockBannerGrabber(ctrl *gomock.Controller) *MockBannerGrabber {
+	mock := &MockBannerGrabber{ctrl: ctrl}
+	mock.recorder = &MockBannerGrabberMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBannerGrabber) EXPECT() *MockBannerGrabberMockRecorder {
+	return m.recorder
+}
+
+// Grab mocks base method.
+func (m *MockBannerGrabber) Grab(arg0 context.Context, arg1 string, arg2 uint16) (*discovery.Banner, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Grab", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*discovery.Banner)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Grab indicates an expected call of Grab.
+func (mr *MockBannerGrabberMockRecorder) Grab(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Grab", reflect.TypeOf((*MockBannerGrabber)(nil).Grab), arg0, arg1, arg2)
+}
+
 // MockDetailScanner is a mock of DetailScanner interface.
 type MockDetailScanner struct {
 	ctrl     *gomock.Controller
@@ -42,7 +81,7 @@ func (m *MockDetailScanner) EXPECT() *MockDetailScannerMockRecorder {
 }
 
 // GetServerDetails mocks base method.
-func (m *MockDetailScanner) GetServerDetails(arg0 context.Context, arg1, arg2 string) (*discovery.Details, error) {
+func (m *MockDetailScanner) GetServerDetails(arg0 context.Context, arg1 string, arg2 config.SSHCredentials) (*discovery.Details, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetServerDetails", arg0, arg1, arg2)
 	ret0, _ := ret[0].(*discovery.Details)
@@ -56,6 +95,83 @@ func (mr *MockDetailScannerMockRecorder) GetServerDetails(arg0, arg1, arg2 any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServerDetails", reflect.TypeOf((*MockDetailScanner)(nil).GetServerDetails), arg0, arg1, arg2)
 }
 
+// MockHostKeyScanner is a mock of HostKeyScanner interface.
+type MockHostKeyScanner struct {
+	ctrl     *gomock.Controller
+	recorder *MockHostKeyScannerMockRecorder
+}
+
+// MockHostKeyScannerMockRecorder is the mock recorder for MockHostKeyScanner.
+type MockHostKeyScannerMockRecorder struct {
+	mock *MockHostKeyScanner
+}
+
+// NewMockHostKeyScanner creates a new mock instance.
+func NewMockHostKeyScanner(ctrl *gomock.Controller) *MockHostKeyScanner {
+	mock := &MockHostKeyScanner{ctrl: ctrl}
+	mock.recorder = &MockHostKeyScannerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHostKeyScanner) EXPECT() *MockHostKeyScannerMockRecorder {
+	return m.recorder
+}
+
+// ScanHostKey mocks base method.
+func (m *MockHostKeyScanner) ScanHostKey(arg0 context.Context, arg1, arg2 string) (*discovery.HostKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScanHostKey", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*discovery.HostKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScanHostKey indicates an expected call of ScanHostKey.
+func (mr *MockHostKeyScannerMockRecorder) ScanHostKey(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanHostKey", reflect.TypeOf((*MockHostKeyScanner)(nil).ScanHostKey), arg0, arg1, arg2)
+}
+
+// MockHostnameResolver is a mock of HostnameResolver interface.
+type MockHostnameResolver struct {
+	ctrl     *gomock.Controller
+	recorder *MockHostnameResolverMockRecorder
+}
+
+// MockHostnameResolverMockRecorder is the mock recorder for MockHostnameResolver.
+type MockHostnameResolverMockRecorder struct {
+	mock *MockHostnameResolver
+}
+
+// NewMockHostnameResolver creates a new mock instance.
+func NewMockHostnameResolver(ctrl *gomock.Controller) *MockHostnameResolver {
+	mock := &MockHostnameResolver{ctrl: ctrl}
+	mock.recorder = &MockHostnameResolverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHostnameResolver) EXPECT() *MockHostnameResolverMockRecorder {
+	return m.recorder
+}
+
+// Resolve mocks base method.
+func (m *MockHostnameResolver) Resolve(arg0 context.Context, arg1 string) (string, discovery.HostnameSource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resolve", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(discovery.HostnameSource)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Resolve indicates an expected call of Resolve.
+func (mr *MockHostnameResolverMockRecorder) Resolve(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resolve", reflect.TypeOf((*MockHostnameResolver)(nil).Resolve), arg0, arg1)
+}
+
 // MockScanner is a mock of Scanner interface.
 type MockScanner struct {
 	ctrl     *gomock.Controller
@@ -0,0 +1,54 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/robgonnella/ops/internal/launcher (interfaces: Launcher)
+//
+// Generated by this command:
+//
+//	mockgen -destination=../mock/launcher/mock_launcher.go -package=mock_launcher . Launcher
+//
+
+// Package mock_launcher is a generated GoMock package.
+package mock_launcher
+
+import (
+	reflect "reflect"
+
+	launcher "github.com/robgonnella/ops/internal/launcher"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLauncher is a mock of Launcher interface.
+type MockLauncher struct {
+	ctrl     *gomock.Controller
+	recorder *MockLauncherMockRecorder
+}
+
+// MockLauncherMockRecorder is the mock recorder for MockLauncher.
+type MockLauncherMockRecorder struct {
+	mock *MockLauncher
+}
+
+// NewMockLauncher creates a new mock instance.
+func NewMockLauncher(ctrl *gomock.Controller) *MockLauncher {
+	mock := &MockLauncher{ctrl: ctrl}
+	mock.recorder = &MockLauncherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLauncher) EXPECT() *MockLauncherMockRecorder {
+	return m.recorder
+}
+
+// Launch mocks base method.
+func (m *MockLauncher) Launch(arg0 launcher.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Launch", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Launch indicates an expected call of Launch.
+func (mr *MockLauncherMockRecorder) Launch(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Launch", reflect.TypeOf((*MockLauncher)(nil).Launch), arg0)
+}
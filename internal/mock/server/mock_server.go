@@ -0,0 +1,128 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/robgonnella/ops/internal/server (interfaces: Core)
+//
+// Generated by this command:
+//
+//	mockgen -destination=../mock/server/mock_server.go -package=mock_server . Core
+//
+
+// Package mock_server is a generated GoMock package.
+package mock_server
+
+import (
+	reflect "reflect"
+
+	config "github.com/robgonnella/ops/internal/config"
+	inventory "github.com/robgonnella/ops/internal/inventory"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCore is a mock of Core interface.
+type MockCore struct {
+	ctrl     *gomock.Controller
+	recorder *MockCoreMockRecorder
+}
+
+// MockCoreMockRecorder is the mock recorder for MockCore.
+type MockCoreMockRecorder struct {
+	mock *MockCore
+}
+
+// NewMockCore creates a new mock instance.
+func NewMockCore(ctrl *gomock.Controller) *MockCore {
+	mock := &MockCore{ctrl: ctrl}
+	mock.recorder = &MockCoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCore) EXPECT() *MockCoreMockRecorder {
+	return m.recorder
+}
+
+// Conf mocks base method.
+func (m *MockCore) Conf() config.Config {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Conf")
+	ret0, _ := ret[0].(config.Config)
+	return ret0
+}
+
+// Conf indicates an expected call of Conf.
+func (mr *MockCoreMockRecorder) Conf() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Conf", reflect.TypeOf((*MockCore)(nil).Conf))
+}
+
+// GetConfigs mocks base method.
+func (m *MockCore) GetConfigs() ([]*config.Config, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConfigs")
+	ret0, _ := ret[0].([]*config.Config)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConfigs indicates an expected call of GetConfigs.
+func (mr *MockCoreMockRecorder) GetConfigs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigs", reflect.TypeOf((*MockCore)(nil).GetConfigs))
+}
+
+// Host mocks base method.
+func (m *MockCore) Host(arg0 string) (*inventory.Host, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Host", arg0)
+	ret0, _ := ret[0].(*inventory.Host)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Host indicates an expected call of Host.
+func (mr *MockCoreMockRecorder) Host(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Host", reflect.TypeOf((*MockCore)(nil).Host), arg0)
+}
+
+// Inventory mocks base method.
+func (m *MockCore) Inventory() ([]*inventory.Host, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Inventory")
+	ret0, _ := ret[0].([]*inventory.Host)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Inventory indicates an expected call of Inventory.
+func (mr *MockCoreMockRecorder) Inventory() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Inventory", reflect.TypeOf((*MockCore)(nil).Inventory))
+}
+
+// Scan mocks base method.
+func (m *MockCore) Scan() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Scan")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *MockCoreMockRecorder) Scan() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockCore)(nil).Scan))
+}
+
+// SetConfig mocks base method.
+func (m *MockCore) SetConfig(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetConfig", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetConfig indicates an expected call of SetConfig.
+func (mr *MockCoreMockRecorder) SetConfig(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetConfig", reflect.TypeOf((*MockCore)(nil).SetConfig), arg0)
+}
@@ -0,0 +1,196 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/robgonnella/ops/internal/discovery"
+	"github.com/robgonnella/ops/internal/inventory"
+)
+
+// Format represents an output format for reports
+type Format string
+
+const (
+	// FormatTable human readable aligned columns
+	FormatTable Format = "table"
+	// FormatJSON a single json array of hosts
+	FormatJSON Format = "json"
+	// FormatNDJSON one json host per line
+	FormatNDJSON Format = "ndjson"
+	// FormatCSV comma separated values with a header row
+	FormatCSV Format = "csv"
+)
+
+// Formats all supported output formats
+var Formats = []Format{FormatTable, FormatJSON, FormatNDJSON, FormatCSV}
+
+// columns written for table and csv formats
+var columns = []string{"HOSTNAME", "IP", "ID", "OS", "VENDOR", "SSH", "STATUS", "PORTS"}
+
+// Host represents a single host in a report
+type Host struct {
+	*inventory.Host
+	DetailError string `json:"detailError,omitempty"`
+}
+
+// ParseFormat returns the format matching the given name
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats {
+		if strings.EqualFold(string(f), name) {
+			return f, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown output format: %s", name)
+}
+
+// Hosts merges all results for each host into a single host sorted by ip
+func Hosts(configID string, results []discovery.DiscoveryResult) ([]Host, error) {
+	service := inventory.NewInventoryService(inventory.NewMemoryRepo())
+
+	detailErrors := map[string]string{}
+
+	for _, r := range results {
+		if _, err := service.Record(configID, r); err != nil {
+			return nil, err
+		}
+
+		// only ssh port results include detail errors
+		if r.Type == discovery.SynUpdateEvent {
+			detailErrors[r.ID] = r.DetailError
+		}
+	}
+
+	recorded, err := service.GetAll(configID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make([]Host, 0, len(recorded))
+
+	for _, h := range recorded {
+		hosts = append(hosts, Host{Host: h, DetailError: detailErrors[h.ID]})
+	}
+
+	slices.SortFunc(hosts, func(h1, h2 Host) int {
+		return bytes.Compare(net.ParseIP(h1.IP), net.ParseIP(h2.IP))
+	})
+
+	return hosts, nil
+}
+
+// Write writes hosts to the writer in the given format
+func Write(w io.Writer, format Format, hosts []Host) error {
+	switch format {
+	case FormatTable:
+		return writeTable(w, hosts)
+	case FormatJSON:
+		return writeJSON(w, hosts)
+	case FormatNDJSON:
+		return writeNDJSON(w, hosts)
+	case FormatCSV:
+		return writeCSV(w, hosts)
+	default:
+		return fmt.Errorf("unknown output format: %s", format)
+	}
+}
+
+func writeTable(w io.Writer, hosts []Host) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, strings.Join(columns, "\t"))
+
+	for _, h := range hosts {
+		fmt.Fprintln(tw, strings.Join(hostToRow(h), "\t"))
+	}
+
+	return tw.Flush()
+}
+
+func writeJSON(w io.Writer, hosts []Host) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(hosts)
+}
+
+func writeNDJSON(w io.Writer, hosts []Host) error {
+	encoder := json.NewEncoder(w)
+
+	for _, h := range hosts {
+		if err := encoder.Encode(h); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeCSV(w io.Writer, hosts []Host) error {
+	cw := csv.NewWriter(w)
+
+	header := make([]string, len(columns))
+
+	for i, c := range columns {
+		header[i] = strings.ToLower(c)
+	}
+
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, h := range hosts {
+		if err := cw.Write(hostToRow(h)); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// converts a host into a table or csv row
+func hostToRow(h Host) []string {
+	status := "offline"
+
+	if h.Status == discovery.ServerOnline {
+		status = "online"
+	}
+
+	ssh := "disabled"
+
+	if h.Port.Status == discovery.PortOpen {
+		ssh = "enabled"
+
+		if h.DetailError != "" {
+			ssh = "enabled (" + h.DetailError + ")"
+		}
+	}
+
+	ports := make([]string, 0, len(h.OpenPorts))
+
+	for _, p := range h.OpenPorts {
+		ports = append(ports, strconv.Itoa(int(p)))
+	}
+
+	return []string{
+		h.Hostname,
+		h.IP,
+		h.ID,
+		h.OS,
+		h.Vendor,
+		ssh,
+		status,
+		strings.Join(ports, ","),
+	}
+}
@@ -0,0 +1,131 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/robgonnella/ops/internal/discovery"
+	"github.com/robgonnella/ops/internal/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReport(t *testing.T) {
+	results := []discovery.DiscoveryResult{
+		{
+			Type:     discovery.ArpUpdateEvent,
+			ID:       "aa:aa:aa:aa:aa:aa",
+			IP:       "192.168.1.10",
+			Hostname: "Unknown",
+			OS:       "Unknown",
+			Vendor:   "vendor",
+			Status:   discovery.ServerOnline,
+		},
+		{
+			Type:     discovery.ArpUpdateEvent,
+			ID:       "bb:bb:bb:bb:bb:bb",
+			IP:       "192.168.1.2",
+			Hostname: "Unknown",
+			OS:       "Unknown",
+			Vendor:   "other",
+			Status:   discovery.ServerOnline,
+		},
+		{
+			Type:     discovery.SynUpdateEvent,
+			ID:       "aa:aa:aa:aa:aa:aa",
+			IP:       "192.168.1.10",
+			Hostname: "server",
+			OS:       "linux",
+			Status:   discovery.ServerOnline,
+			Port: discovery.Port{
+				ID:     22,
+				Status: discovery.PortOpen,
+			},
+			OpenPorts:   []uint16{22, 80},
+			DetailError: "auth failed",
+		},
+	}
+
+	hosts, err := report.Hosts("1", results)
+
+	assert.NoError(t, err)
+
+	t.Run("merges and sorts hosts by ip", func(st *testing.T) {
+		assert.Len(st, hosts, 2)
+		assert.Equal(st, "192.168.1.2", hosts[0].IP)
+		assert.Equal(st, "192.168.1.10", hosts[1].IP)
+		assert.Equal(st, "server", hosts[1].Hostname)
+		assert.Equal(st, "vendor", hosts[1].Vendor)
+		assert.Equal(st, []uint16{22, 80}, hosts[1].OpenPorts)
+		assert.Equal(st, "auth failed", hosts[1].DetailError)
+	})
+
+	t.Run("parses formats", func(st *testing.T) {
+		format, err := report.ParseFormat("NDJSON")
+
+		assert.NoError(st, err)
+		assert.Equal(st, report.FormatNDJSON, format)
+
+		_, err = report.ParseFormat("xml")
+
+		assert.Error(st, err)
+	})
+
+	t.Run("writes table", func(st *testing.T) {
+		buf := bytes.Buffer{}
+
+		assert.NoError(st, report.Write(&buf, report.FormatTable, hosts))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+		assert.Len(st, lines, 3)
+		assert.True(st, strings.HasPrefix(lines[0], "HOSTNAME"))
+		assert.Contains(st, lines[2], "enabled (auth failed)")
+		assert.Contains(st, lines[2], "22,80")
+	})
+
+	t.Run("writes json", func(st *testing.T) {
+		buf := bytes.Buffer{}
+
+		assert.NoError(st, report.Write(&buf, report.FormatJSON, hosts))
+
+		decoded := []map[string]any{}
+
+		assert.NoError(st, json.Unmarshal(buf.Bytes(), &decoded))
+		assert.Len(st, decoded, 2)
+		assert.Equal(st, "bb:bb:bb:bb:bb:bb", decoded[0]["id"])
+		assert.Equal(st, "auth failed", decoded[1]["detailError"])
+	})
+
+	t.Run("writes ndjson", func(st *testing.T) {
+		buf := bytes.Buffer{}
+
+		assert.NoError(st, report.Write(&buf, report.FormatNDJSON, hosts))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+		assert.Len(st, lines, 2)
+
+		for _, line := range lines {
+			decoded := map[string]any{}
+			assert.NoError(st, json.Unmarshal([]byte(line), &decoded))
+		}
+	})
+
+	t.Run("writes csv", func(st *testing.T) {
+		buf := bytes.Buffer{}
+
+		assert.NoError(st, report.Write(&buf, report.FormatCSV, hosts))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+		assert.Len(st, lines, 3)
+		assert.Equal(st, "hostname,ip,id,os,vendor,ssh,status,ports", lines[0])
+		assert.Equal(
+			st,
+			`server,192.168.1.10,aa:aa:aa:aa:aa:aa,linux,vendor,enabled (auth failed),online,"22,80"`,
+			lines[2],
+		)
+	})
+}
@@ -0,0 +1,18 @@
+package server
+
+import (
+	"github.com/robgonnella/ops/internal/config"
+	"github.com/robgonnella/ops/internal/inventory"
+)
+
+//go:generate mockgen -destination=../mock/server/mock_server.go -package=mock_server . Core
+
+// Core interface representing the parts of the app core exposed by the api
+type Core interface {
+	Conf() config.Config
+	GetConfigs() ([]*config.Config, error)
+	SetConfig(id string) error
+	Inventory() ([]*inventory.Host, error)
+	Host(id string) (*inventory.Host, error)
+	Scan() error
+}
@@ -0,0 +1,386 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robgonnella/ops/internal/discovery"
+	"github.com/robgonnella/ops/internal/event"
+	"github.com/robgonnella/ops/internal/exception"
+	"github.com/robgonnella/ops/internal/logger"
+)
+
+// APIPrefix prefix for all api routes
+const APIPrefix = "/api/v1"
+
+// DefaultAddr default localhost address the api listens on
+const DefaultAddr = "127.0.0.1:7474"
+
+// time allowed for in-flight requests to finish when shutting down
+const shutdownTimeout = time.Second * 5
+
+// ErrNotLocal returned when attempting to listen on a non-loopback address
+var ErrNotLocal = errors.New("api may only listen on a loopback address")
+
+// ErrNotSocket returned when the socket path exists and is not a socket
+var ErrNotSocket = errors.New("socket path exists and is not a socket")
+
+// hostnames accepted in the Host header of tcp requests
+var localHostnames = []string{"localhost", "127.0.0.1", "::1"}
+
+// SetConfigRequest request body for switching the active config
+type SetConfigRequest struct {
+	ID string `json:"id"`
+}
+
+// ErrorResponse response body returned for all errors
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// StatusResponse response body returned for actions without a resource
+type StatusResponse struct {
+	Status string `json:"status"`
+}
+
+// Server exposes the app core as a local HTTP/JSON api
+type Server struct {
+	core         Core
+	eventManager event.Manager
+	done         chan struct{}
+	mux          sync.Mutex
+	log          logger.Logger
+}
+
+// NewServer returns a new instance of Server
+func NewServer(core Core, eventManager event.Manager) *Server {
+	return &Server{
+		core:         core,
+		eventManager: eventManager,
+		done:         make(chan struct{}),
+		log:          logger.New(),
+	}
+}
+
+// Listen returns a listener for the unix socket at the given path, or for
+// the given tcp address if path is empty. TCP addresses must be loopback
+// addresses as the api is unauthenticated.
+func Listen(socketPath, addr string) (net.Listener, error) {
+	if socketPath != "" {
+		if err := removeStaleSocket(socketPath); err != nil {
+			return nil, err
+		}
+
+		listener, err := net.Listen("unix", socketPath)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := os.Chmod(socketPath, 0600); err != nil {
+			listener.Close()
+			return nil, err
+		}
+
+		return listener, nil
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, fmt.Errorf("%w: %s", ErrNotLocal, addr)
+	}
+
+	return net.Listen("tcp", addr)
+}
+
+// removes a socket left behind by a previous run refusing to remove
+// anything that is not a socket
+func removeStaleSocket(socketPath string) error {
+	info, err := os.Lstat(socketPath)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%w: %s", ErrNotSocket, socketPath)
+	}
+
+	return os.Remove(socketPath)
+}
+
+// Serve handles api requests on the given listener until the context is
+// canceled
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	httpServer := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: time.Second * 10,
+	}
+
+	// end event streams so shutdown doesn't wait on them
+	httpServer.RegisterOnShutdown(func() {
+		close(s.done)
+	})
+
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- httpServer.Serve(listener)
+	}()
+
+	s.log.Info().Str("addr", listener.Addr().String()).Msg("api server listening")
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		s.log.Info().Msg("api server shutting down")
+
+		return httpServer.Shutdown(shutdownCtx)
+	}
+}
+
+// Handler returns the http handler for all api routes
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(APIPrefix+"/hosts", s.handleHosts)
+	mux.HandleFunc(APIPrefix+"/hosts/", s.handleHost)
+	mux.HandleFunc(APIPrefix+"/configs", s.handleConfigs)
+	mux.HandleFunc(APIPrefix+"/configs/current", s.handleCurrentConfig)
+	mux.HandleFunc(APIPrefix+"/scan", s.handleScan)
+	mux.HandleFunc(APIPrefix+"/events", s.handleEvents)
+
+	return localOnly(mux)
+}
+
+// rejects requests that may have been made by a web page rather than a
+// local client. Pages on other sites can send simple requests to localhost
+// with a foreign Origin, and can read responses through dns rebinding with
+// a foreign Host. Unix sockets can't be reached by browsers so any Host is
+// accepted for them.
+func localOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		local, _ := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+
+		if _, isUnix := local.(*net.UnixAddr); !isUnix && !isLocalHost(r.Host, local) {
+			writeJSON(w, http.StatusForbidden, ErrorResponse{
+				Error: "host not allowed: " + r.Host,
+			})
+			return
+		}
+
+		if origin := r.Header.Get("Origin"); origin != "" && !isSameOrigin(origin, r.Host) {
+			writeJSON(w, http.StatusForbidden, ErrorResponse{
+				Error: "cross origin requests are not allowed",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// returns true if host is a loopback hostname with the port the request
+// was received on
+func isLocalHost(host string, local net.Addr) bool {
+	hostname, port, err := net.SplitHostPort(host)
+
+	if err != nil {
+		hostname, port = strings.Trim(host, "[]"), ""
+	}
+
+	if !slices.Contains(localHostnames, hostname) {
+		return false
+	}
+
+	if addr, ok := local.(*net.TCPAddr); ok {
+		return port == strconv.Itoa(addr.Port)
+	}
+
+	return true
+}
+
+// returns true if the origin refers to the api itself
+func isSameOrigin(origin, host string) bool {
+	u, err := url.Parse(origin)
+
+	if err != nil {
+		return false
+	}
+
+	return u.Scheme == "http" && u.Host == host
+}
+
+// GET /hosts - lists all hosts for the active config
+func (s *Server) handleHosts(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+
+	s.mux.Lock()
+	hosts, err := s.core.Inventory()
+	s.mux.Unlock()
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, hosts)
+}
+
+// GET /hosts/{id} - returns a single host for the active config
+func (s *Server) handleHost(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, APIPrefix+"/hosts/")
+
+	if id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mux.Lock()
+	host, err := s.core.Host(id)
+	s.mux.Unlock()
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, host)
+}
+
+// GET /configs - lists all configs
+func (s *Server) handleConfigs(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+
+	s.mux.Lock()
+	confs, err := s.core.GetConfigs()
+	s.mux.Unlock()
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, confs)
+}
+
+// GET /configs/current - returns the active config
+// PUT /configs/current - switches the active config
+func (s *Server) handleCurrentConfig(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodPut) {
+		return
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if r.Method == http.MethodPut {
+		body := SetConfigRequest{}
+
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ID == "" {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "request body must contain a config id",
+			})
+			return
+		}
+
+		if err := s.core.SetConfig(body.ID); err != nil {
+			s.writeError(w, err)
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, s.core.Conf())
+}
+
+// POST /scan - triggers an immediate network scan
+func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+
+	s.mux.Lock()
+	err := s.core.Scan()
+	s.mux.Unlock()
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, StatusResponse{Status: "scan started"})
+}
+
+// writes an error response with a status code based on the error
+func (s *Server) writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+
+	switch {
+	case errors.Is(err, exception.ErrRecordNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, discovery.ErrNotMonitoring):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, discovery.ErrScanInProgress):
+		status = http.StatusConflict
+	default:
+		s.log.Error().Err(err).Msg("api request failed")
+	}
+
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
+
+// responds with 405 and returns false if the request method is not allowed
+func allowMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, m := range methods {
+		if r.Method == m {
+			return true
+		}
+	}
+
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{
+		Error: "method not allowed",
+	})
+
+	return false
+}
+
+// writes the given value as a json response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	// nolint:errcheck
+	json.NewEncoder(w).Encode(v)
+}
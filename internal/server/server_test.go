@@ -0,0 +1,353 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robgonnella/ops/internal/config"
+	"github.com/robgonnella/ops/internal/discovery"
+	"github.com/robgonnella/ops/internal/event"
+	"github.com/robgonnella/ops/internal/exception"
+	"github.com/robgonnella/ops/internal/inventory"
+	mock_server "github.com/robgonnella/ops/internal/mock/server"
+	"github.com/robgonnella/ops/internal/server"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+// address requests are received on in handler tests
+var localAddr = &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 7474}
+
+// returns a request as received by the api listening on localAddr
+func newLocalRequest(method, path, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Host = localAddr.String()
+
+	return req.WithContext(
+		context.WithValue(req.Context(), http.LocalAddrContextKey, localAddr),
+	)
+}
+
+// performs a request against the handler and decodes the json response
+func doRequest(t *testing.T, handler http.Handler, method, path, body string, out any) int {
+	t.Helper()
+
+	req := newLocalRequest(method, path, body)
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if out != nil {
+		if err := json.NewDecoder(rec.Body).Decode(out); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return rec.Code
+}
+
+func TestServer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	mockCore := mock_server.NewMockCore(ctrl)
+
+	handler := server.NewServer(mockCore, event.NewEventManager()).Handler()
+
+	conf := config.Config{ID: "1", Name: "default"}
+
+	host := &inventory.Host{
+		ID:       "aa:bb:cc:dd:ee:ff",
+		ConfigID: conf.ID,
+		Hostname: "server",
+		IP:       "192.168.1.2",
+	}
+
+	t.Run("lists hosts", func(st *testing.T) {
+		mockCore.EXPECT().Inventory().Return([]*inventory.Host{host}, nil)
+
+		hosts := []*inventory.Host{}
+
+		code := doRequest(st, handler, http.MethodGet, server.APIPrefix+"/hosts", "", &hosts)
+
+		assert.Equal(st, http.StatusOK, code)
+		assert.Equal(st, []*inventory.Host{host}, hosts)
+	})
+
+	t.Run("gets host", func(st *testing.T) {
+		mockCore.EXPECT().Host(host.ID).Return(host, nil)
+
+		found := &inventory.Host{}
+
+		code := doRequest(st, handler, http.MethodGet, server.APIPrefix+"/hosts/"+host.ID, "", found)
+
+		assert.Equal(st, http.StatusOK, code)
+		assert.Equal(st, host, found)
+	})
+
+	t.Run("returns not found for unknown host", func(st *testing.T) {
+		mockCore.EXPECT().Host("unknown").Return(nil, exception.ErrRecordNotFound)
+
+		res := server.ErrorResponse{}
+
+		code := doRequest(st, handler, http.MethodGet, server.APIPrefix+"/hosts/unknown", "", &res)
+
+		assert.Equal(st, http.StatusNotFound, code)
+		assert.Equal(st, exception.ErrRecordNotFound.Error(), res.Error)
+	})
+
+	t.Run("lists configs", func(st *testing.T) {
+		mockCore.EXPECT().GetConfigs().Return([]*config.Config{&conf}, nil)
+
+		confs := []*config.Config{}
+
+		code := doRequest(st, handler, http.MethodGet, server.APIPrefix+"/configs", "", &confs)
+
+		assert.Equal(st, http.StatusOK, code)
+		assert.Equal(st, []*config.Config{&conf}, confs)
+	})
+
+	t.Run("switches config", func(st *testing.T) {
+		newConf := config.Config{ID: "2", Name: "other"}
+
+		mockCore.EXPECT().SetConfig("2").Return(nil)
+		mockCore.EXPECT().Conf().Return(newConf)
+
+		current := config.Config{}
+
+		code := doRequest(st, handler, http.MethodPut, server.APIPrefix+"/configs/current", `{"id":"2"}`, &current)
+
+		assert.Equal(st, http.StatusOK, code)
+		assert.Equal(st, newConf, current)
+	})
+
+	t.Run("rejects switching config without id", func(st *testing.T) {
+		code := doRequest(st, handler, http.MethodPut, server.APIPrefix+"/configs/current", `{}`, nil)
+
+		assert.Equal(st, http.StatusBadRequest, code)
+	})
+
+	t.Run("triggers scan", func(st *testing.T) {
+		mockCore.EXPECT().Scan().Return(nil)
+
+		res := server.StatusResponse{}
+
+		code := doRequest(st, handler, http.MethodPost, server.APIPrefix+"/scan", "", &res)
+
+		assert.Equal(st, http.StatusAccepted, code)
+		assert.Equal(st, "scan started", res.Status)
+	})
+
+	t.Run("returns unavailable when not monitoring", func(st *testing.T) {
+		mockCore.EXPECT().Scan().Return(discovery.ErrNotMonitoring)
+
+		code := doRequest(st, handler, http.MethodPost, server.APIPrefix+"/scan", "", nil)
+
+		assert.Equal(st, http.StatusServiceUnavailable, code)
+	})
+
+	t.Run("returns conflict when a scan is already running", func(st *testing.T) {
+		mockCore.EXPECT().Scan().Return(discovery.ErrScanInProgress)
+
+		res := server.ErrorResponse{}
+
+		code := doRequest(st, handler, http.MethodPost, server.APIPrefix+"/scan", "", &res)
+
+		assert.Equal(st, http.StatusConflict, code)
+		assert.Equal(st, discovery.ErrScanInProgress.Error(), res.Error)
+	})
+
+	t.Run("returns internal error for unexpected errors", func(st *testing.T) {
+		mockCore.EXPECT().Inventory().Return(nil, errors.New("boom"))
+
+		code := doRequest(st, handler, http.MethodGet, server.APIPrefix+"/hosts", "", nil)
+
+		assert.Equal(st, http.StatusInternalServerError, code)
+	})
+
+	t.Run("rejects unsupported methods", func(st *testing.T) {
+		code := doRequest(st, handler, http.MethodDelete, server.APIPrefix+"/hosts", "", nil)
+
+		assert.Equal(st, http.StatusMethodNotAllowed, code)
+	})
+
+	t.Run("accepts loopback hosts with the listen port", func(st *testing.T) {
+		for _, host := range []string{"127.0.0.1:7474", "localhost:7474", "[::1]:7474"} {
+			mockCore.EXPECT().Inventory().Return([]*inventory.Host{}, nil)
+
+			req := newLocalRequest(http.MethodGet, server.APIPrefix+"/hosts", "")
+			req.Host = host
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(st, http.StatusOK, rec.Code, host)
+		}
+	})
+
+	t.Run("rejects foreign hosts", func(st *testing.T) {
+		hosts := []string{
+			"attacker.example:7474",
+			"attacker.example",
+			"127.0.0.1:8080",
+			"localhost",
+			"192.168.1.2:7474",
+			"",
+		}
+
+		for _, host := range hosts {
+			req := newLocalRequest(http.MethodGet, server.APIPrefix+"/configs", "")
+			req.Host = host
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(st, http.StatusForbidden, rec.Code, host)
+		}
+	})
+
+	t.Run("accepts same origin requests", func(st *testing.T) {
+		mockCore.EXPECT().Scan().Return(nil)
+
+		req := newLocalRequest(http.MethodPost, server.APIPrefix+"/scan", "")
+		req.Header.Set("Origin", "http://127.0.0.1:7474")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(st, http.StatusAccepted, rec.Code)
+	})
+
+	t.Run("rejects cross origin requests", func(st *testing.T) {
+		origins := []string{
+			"http://attacker.example",
+			"http://localhost:7474",
+			"https://127.0.0.1:7474",
+			"null",
+		}
+
+		for _, origin := range origins {
+			req := newLocalRequest(http.MethodPost, server.APIPrefix+"/scan", "")
+			req.Header.Set("Origin", origin)
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(st, http.StatusForbidden, rec.Code, origin)
+		}
+	})
+}
+
+func TestListen(t *testing.T) {
+	t.Run("listens on unix socket", func(st *testing.T) {
+		socketPath := filepath.Join(st.TempDir(), "ops.sock")
+
+		listener, err := server.Listen(socketPath, "")
+
+		assert.NoError(st, err)
+
+		defer listener.Close()
+
+		assert.Equal(st, "unix", listener.Addr().Network())
+	})
+
+	t.Run("replaces stale socket", func(st *testing.T) {
+		socketPath := filepath.Join(st.TempDir(), "ops.sock")
+
+		stale, err := net.Listen("unix", socketPath)
+
+		assert.NoError(st, err)
+
+		// leave the socket file behind like a crashed run would
+		stale.(*net.UnixListener).SetUnlinkOnClose(false)
+		stale.Close()
+
+		listener, err := server.Listen(socketPath, "")
+
+		assert.NoError(st, err)
+
+		listener.Close()
+	})
+
+	t.Run("refuses to remove files that are not sockets", func(st *testing.T) {
+		socketPath := filepath.Join(st.TempDir(), "ops.sock")
+
+		assert.NoError(st, os.WriteFile(socketPath, []byte("data"), 0600))
+
+		_, err := server.Listen(socketPath, "")
+
+		assert.ErrorIs(st, err, server.ErrNotSocket)
+
+		data, err := os.ReadFile(socketPath)
+
+		assert.NoError(st, err)
+		assert.Equal(st, "data", string(data))
+	})
+
+	t.Run("listens on localhost", func(st *testing.T) {
+		listener, err := server.Listen("", "127.0.0.1:0")
+
+		assert.NoError(st, err)
+
+		listener.Close()
+	})
+
+	t.Run("rejects non-loopback addresses", func(st *testing.T) {
+		_, err := server.Listen("", "0.0.0.0:7474")
+
+		assert.ErrorIs(st, err, server.ErrNotLocal)
+	})
+}
+
+func TestServe(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	mockCore := mock_server.NewMockCore(ctrl)
+
+	socketPath := filepath.Join(t.TempDir(), "ops.sock")
+
+	listener, err := server.Listen(socketPath, "")
+
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error)
+
+	go func() {
+		done <- server.NewServer(mockCore, event.NewEventManager()).Serve(ctx, listener)
+	}()
+
+	client := http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				d := net.Dialer{}
+				return d.DialContext(ctx, "unix", socketPath)
+			},
+		},
+	}
+
+	mockCore.EXPECT().Inventory().Return([]*inventory.Host{}, nil)
+
+	res, err := client.Get("http://ops" + server.APIPrefix + "/hosts")
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	res.Body.Close()
+
+	cancel()
+
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,350 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robgonnella/ops/internal/discovery"
+	"github.com/robgonnella/ops/internal/event"
+)
+
+// StreamEventTypes all event types that can be streamed from the api
+var StreamEventTypes = []event.EventType{
+	discovery.ArpUpdateEvent,
+	discovery.SynUpdateEvent,
+	discovery.HostOfflineEvent,
+	discovery.HostReturnedEvent,
+	discovery.HostnameResolvedEvent,
+	discovery.PortUpdateEvent,
+	discovery.HostKeyChangedEvent,
+	discovery.ScanStartedEvent,
+	discovery.ScanCompletedEvent,
+	event.ErrorEventType,
+	event.FatalErrorEventType,
+}
+
+const (
+	// interval at which keep-alive comments are sent to stream clients
+	streamKeepAlive = time.Second * 15
+	// number of events buffered for each stream client
+	streamBuffer = 64
+	// time to keep draining events sent to a client's channel after the
+	// client disconnects so senders are not blocked forever
+	streamDrainTimeout = time.Second * 5
+)
+
+// StreamEvent represents a single event written to the event stream.
+// ConfigID is empty for events that don't belong to a config e.g. errors.
+type StreamEvent struct {
+	Type     string    `json:"type"`
+	ConfigID string    `json:"configId,omitempty"`
+	Time     time.Time `json:"time"`
+	Payload  any       `json:"payload"`
+}
+
+// StreamResult payload for discovery events
+type StreamResult struct {
+	ID             string                   `json:"id"`
+	Hostname       string                   `json:"hostname"`
+	HostnameSource discovery.HostnameSource `json:"hostnameSource,omitempty"`
+	IP             string                   `json:"ip"`
+	OS             string                   `json:"os"`
+	Vendor         string                   `json:"vendor"`
+	Status         discovery.ServerStatus   `json:"status"`
+	Port           discovery.Port           `json:"port"`
+	OpenPorts      []uint16                 `json:"openPorts,omitempty"`
+	Banners        []discovery.Banner       `json:"banners,omitempty"`
+	HostKey        *discovery.HostKey       `json:"hostKey,omitempty"`
+	DetailError    string                   `json:"detailError,omitempty"`
+	Facts          *discovery.Facts         `json:"facts,omitempty"`
+}
+
+// StreamScanCycle payload for scan started and completed events
+type StreamScanCycle struct {
+	StartedAt    time.Time     `json:"startedAt"`
+	Duration     time.Duration `json:"duration"`
+	HostsFound   int           `json:"hostsFound"`
+	HostsOffline int           `json:"hostsOffline"`
+}
+
+// StreamHostKeyChange payload for host key changed events
+type StreamHostKeyChange struct {
+	ID       string            `json:"id"`
+	IP       string            `json:"ip"`
+	Hostname string            `json:"hostname"`
+	Previous discovery.HostKey `json:"previous"`
+	Current  discovery.HostKey `json:"current"`
+}
+
+// streamFilter limits which events are written to a stream client
+type streamFilter struct {
+	types    []event.EventType
+	networks []*net.IPNet
+	configID string
+}
+
+// parses filters from the query string. Supported filters:
+//   - type: event types (comma separated or repeated)
+//   - ip: ip addresses or CIDR blocks (comma separated or repeated)
+//   - config: config id
+func parseStreamFilter(r *http.Request) (*streamFilter, error) {
+	query := r.URL.Query()
+
+	filter := &streamFilter{
+		types:    []event.EventType{},
+		networks: []*net.IPNet{},
+		configID: query.Get("config"),
+	}
+
+	for _, t := range splitQuery(query["type"]) {
+		idx := slices.IndexFunc(StreamEventTypes, func(e event.EventType) bool {
+			return strings.EqualFold(string(e), t)
+		})
+
+		if idx == -1 {
+			return nil, fmt.Errorf("unknown event type: %s", t)
+		}
+
+		filter.types = append(filter.types, StreamEventTypes[idx])
+	}
+
+	if len(filter.types) == 0 {
+		filter.types = StreamEventTypes
+	}
+
+	for _, value := range splitQuery(query["ip"]) {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+
+			if ip == nil {
+				return nil, fmt.Errorf("invalid ip: %s", value)
+			}
+
+			bits := 32
+
+			if ip.To4() == nil {
+				bits = 128
+			}
+
+			value = fmt.Sprintf("%s/%d", value, bits)
+		}
+
+		_, network, err := net.ParseCIDR(value)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid cidr: %s", value)
+		}
+
+		filter.networks = append(filter.networks, network)
+	}
+
+	return filter, nil
+}
+
+// returns true if the event should be written to the client. Events that
+// don't belong to a config are never filtered by config.
+func (f *streamFilter) matches(evt event.Event, configID string) bool {
+	if f.configID != "" && configID != "" && f.configID != configID {
+		return false
+	}
+
+	if len(f.networks) == 0 {
+		return true
+	}
+
+	var ip string
+
+	switch payload := evt.Payload.(type) {
+	case discovery.DiscoveryResult:
+		ip = payload.IP
+	case discovery.HostKeyChange:
+		ip = payload.IP
+	default:
+		// events without an ip never match an ip filter
+		return false
+	}
+
+	parsed := net.ParseIP(ip)
+
+	if parsed == nil {
+		return false
+	}
+
+	for _, n := range f.networks {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GET /events - streams events as server-sent events until the client
+// disconnects
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "streaming not supported",
+		})
+		return
+	}
+
+	filter, err := parseStreamFilter(r)
+
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	events := make(chan event.Event, streamBuffer)
+	listenerIDs := []int{}
+
+	for _, t := range filter.types {
+		listenerIDs = append(listenerIDs, s.eventManager.RegisterListener(t, events))
+	}
+
+	defer func() {
+		for _, id := range listenerIDs {
+			s.eventManager.RemoveListener(id)
+		}
+
+		go drain(events)
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	// let clients know they are subscribed
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case evt := <-events:
+			configID, payload := streamPayload(evt)
+
+			if !filter.matches(evt, configID) {
+				continue
+			}
+
+			if err := writeStreamEvent(w, evt.Type, configID, payload); err != nil {
+				s.log.Error().Err(err).Msg("failed to write stream event")
+				return
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+// returns the id of the config the event belongs to and the payload to
+// write to clients for the event
+func streamPayload(evt event.Event) (string, any) {
+	switch payload := evt.Payload.(type) {
+	case discovery.DiscoveryResult:
+		return payload.ConfigID, StreamResult{
+			ID:             payload.ID,
+			Hostname:       payload.Hostname,
+			HostnameSource: payload.HostnameSource,
+			IP:             payload.IP,
+			OS:             payload.OS,
+			Vendor:         payload.Vendor,
+			Status:         payload.Status,
+			Port:           payload.Port,
+			OpenPorts:      payload.OpenPorts,
+			Banners:        payload.Banners,
+			HostKey:        payload.HostKey,
+			DetailError:    payload.DetailError,
+			Facts:          payload.Facts,
+		}
+	case discovery.ScanCycle:
+		return payload.ConfigID, StreamScanCycle{
+			StartedAt:    payload.StartedAt,
+			Duration:     payload.Duration,
+			HostsFound:   payload.HostsFound,
+			HostsOffline: payload.HostsOffline,
+		}
+	case discovery.HostKeyChange:
+		return payload.ConfigID, StreamHostKeyChange{
+			ID:       payload.ID,
+			IP:       payload.IP,
+			Hostname: payload.Hostname,
+			Previous: payload.Previous,
+			Current:  payload.Current,
+		}
+	case error:
+		return "", ErrorResponse{Error: payload.Error()}
+	default:
+		return "", payload
+	}
+}
+
+// writes a single event in server-sent event format
+func writeStreamEvent(w http.ResponseWriter, eventType event.EventType, configID string, payload any) error {
+	data, err := json.Marshal(StreamEvent{
+		Type:     string(eventType),
+		ConfigID: configID,
+		Time:     time.Now(),
+		Payload:  payload,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, data)
+
+	return err
+}
+
+// splits repeated and comma separated query values
+func splitQuery(values []string) []string {
+	result := []string{}
+
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+
+	return result
+}
+
+// keeps receiving events that were already in flight to a removed
+// listener so their senders are not blocked forever
+func drain(events chan event.Event) {
+	timeout := time.After(streamDrainTimeout)
+
+	for {
+		select {
+		case <-events:
+		case <-timeout:
+			return
+		}
+	}
+}
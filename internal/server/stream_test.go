@@ -0,0 +1,304 @@
+package server_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robgonnella/ops/internal/config"
+	"github.com/robgonnella/ops/internal/discovery"
+	"github.com/robgonnella/ops/internal/event"
+	mock_server "github.com/robgonnella/ops/internal/mock/server"
+	"github.com/robgonnella/ops/internal/server"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+// opens an event stream and returns a reader positioned after the
+// initial connected comment
+func openStream(t *testing.T, url string) (*bufio.Reader, func()) {
+	t.Helper()
+
+	res, err := http.Get(url)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(res.Body)
+
+	line, err := reader.ReadString('\n')
+
+	assert.NoError(t, err)
+	assert.Equal(t, ": connected\n", line)
+
+	// blank line terminating the comment
+	_, err = reader.ReadString('\n')
+
+	assert.NoError(t, err)
+
+	return reader, func() { res.Body.Close() }
+}
+
+// reads the next event from the stream
+func readStreamEvent(t *testing.T, reader *bufio.Reader) (string, server.StreamEvent) {
+	t.Helper()
+
+	eventLine, err := reader.ReadString('\n')
+
+	assert.NoError(t, err)
+
+	dataLine, err := reader.ReadString('\n')
+
+	assert.NoError(t, err)
+
+	// blank line terminating the event
+	_, err = reader.ReadString('\n')
+
+	assert.NoError(t, err)
+
+	evt := server.StreamEvent{}
+
+	data := strings.TrimPrefix(strings.TrimSpace(dataLine), "data: ")
+
+	if err := json.Unmarshal([]byte(data), &evt); err != nil {
+		t.Fatal(err)
+	}
+
+	return strings.TrimPrefix(strings.TrimSpace(eventLine), "event: "), evt
+}
+
+func TestEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	mockCore := mock_server.NewMockCore(ctrl)
+
+	eventManager := event.NewEventManager()
+
+	ts := httptest.NewServer(server.NewServer(mockCore, eventManager).Handler())
+
+	defer ts.Close()
+
+	conf := config.Config{ID: "1", Name: "default"}
+
+	url := ts.URL + server.APIPrefix + "/events"
+
+	inSubnet := discovery.DiscoveryResult{
+		Type:     discovery.HostOfflineEvent,
+		ConfigID: conf.ID,
+		ID:       "aa:bb:cc:dd:ee:ff",
+		IP:       "192.168.1.2",
+		Status:   discovery.ServerOffline,
+		Port:     discovery.Port{ID: 22, Status: discovery.PortOpen},
+	}
+
+	outOfSubnet := discovery.DiscoveryResult{
+		Type:     discovery.HostOfflineEvent,
+		ConfigID: conf.ID,
+		ID:       "ff:ee:dd:cc:bb:aa",
+		IP:       "10.0.0.2",
+		Status:   discovery.ServerOffline,
+	}
+
+	t.Run("streams all events", func(st *testing.T) {
+		reader, closeStream := openStream(st, url)
+
+		defer closeStream()
+
+		eventManager.Send(event.Event{
+			Type:    discovery.ScanStartedEvent,
+			Payload: discovery.ScanCycle{ConfigID: conf.ID, StartedAt: time.Now()},
+		})
+
+		eventType, evt := readStreamEvent(st, reader)
+
+		assert.Equal(st, discovery.ScanStartedEvent, eventType)
+		assert.Equal(st, discovery.ScanStartedEvent, evt.Type)
+		assert.Equal(st, conf.ID, evt.ConfigID)
+
+		payload, ok := evt.Payload.(map[string]any)
+
+		assert.True(st, ok)
+		assert.Contains(st, payload, "startedAt")
+		assert.Contains(st, payload, "hostsFound")
+		assert.NotContains(st, payload, "StartedAt")
+	})
+
+	t.Run("streams payloads with camel case keys", func(st *testing.T) {
+		reader, closeStream := openStream(st, url+"?type=DISCOVERY_HOST_OFFLINE")
+
+		defer closeStream()
+
+		eventManager.Send(event.Event{
+			Type:    discovery.HostOfflineEvent,
+			Payload: inSubnet,
+		})
+
+		_, evt := readStreamEvent(st, reader)
+
+		payload, ok := evt.Payload.(map[string]any)
+
+		assert.True(st, ok)
+		assert.Equal(st, inSubnet.ID, payload["id"])
+		assert.Equal(st, inSubnet.IP, payload["ip"])
+		assert.Equal(st, string(discovery.ServerOffline), payload["status"])
+		assert.Equal(st, map[string]any{"id": float64(22), "status": "open"}, payload["port"])
+		assert.NotContains(st, payload, "Type")
+		assert.NotContains(st, payload, "ConfigID")
+		assert.NotContains(st, payload, "IP")
+	})
+
+	t.Run("streams host key changes", func(st *testing.T) {
+		reader, closeStream := openStream(st, url+"?type=HOST_KEY_CHANGED")
+
+		defer closeStream()
+
+		eventManager.Send(event.Event{
+			Type: discovery.HostKeyChangedEvent,
+			Payload: discovery.HostKeyChange{
+				ConfigID: conf.ID,
+				ID:       inSubnet.ID,
+				IP:       inSubnet.IP,
+				Previous: discovery.HostKey{Type: "ssh-ed25519", Fingerprint: "SHA256:old"},
+				Current:  discovery.HostKey{Type: "ssh-ed25519", Fingerprint: "SHA256:new"},
+			},
+		})
+
+		_, evt := readStreamEvent(st, reader)
+
+		assert.Equal(st, conf.ID, evt.ConfigID)
+
+		payload, ok := evt.Payload.(map[string]any)
+
+		assert.True(st, ok)
+		assert.Equal(st, inSubnet.IP, payload["ip"])
+		assert.Equal(
+			st,
+			map[string]any{"type": "ssh-ed25519", "fingerprint": "SHA256:new"},
+			payload["current"],
+		)
+	})
+
+	t.Run("streams errors without a config", func(st *testing.T) {
+		reader, closeStream := openStream(st, url+"?type="+string(event.ErrorEventType)+"&config="+conf.ID)
+
+		defer closeStream()
+
+		eventManager.ReportError(errors.New("boom"))
+
+		eventType, evt := readStreamEvent(st, reader)
+
+		assert.Equal(st, string(event.ErrorEventType), eventType)
+		assert.Empty(st, evt.ConfigID)
+		assert.Equal(st, map[string]any{"error": "boom"}, evt.Payload)
+	})
+
+	t.Run("uses the config id the event was produced for", func(st *testing.T) {
+		reader, closeStream := openStream(st, url+"?config=2&type=DISCOVERY_HOST_OFFLINE")
+
+		defer closeStream()
+
+		// a result from a scan using a config that is no longer active
+		previous := inSubnet
+		previous.ConfigID = "2"
+
+		eventManager.Send(event.Event{
+			Type:    discovery.HostOfflineEvent,
+			Payload: previous,
+		})
+
+		_, evt := readStreamEvent(st, reader)
+
+		assert.Equal(st, "2", evt.ConfigID)
+	})
+
+	t.Run("filters events by type and ip", func(st *testing.T) {
+		reader, closeStream := openStream(
+			st,
+			url+"?type=discovery_host_offline&ip=192.168.1.0/24",
+		)
+
+		defer closeStream()
+
+		eventManager.Send(event.Event{
+			Type:    discovery.ScanStartedEvent,
+			Payload: discovery.ScanCycle{StartedAt: time.Now()},
+		})
+
+		eventManager.Send(event.Event{
+			Type:    discovery.HostOfflineEvent,
+			Payload: outOfSubnet,
+		})
+
+		// give filtered events time to be processed before the matching one
+		time.Sleep(time.Millisecond * 100)
+
+		eventManager.Send(event.Event{
+			Type:    discovery.HostOfflineEvent,
+			Payload: inSubnet,
+		})
+
+		eventType, evt := readStreamEvent(st, reader)
+
+		assert.Equal(st, discovery.HostOfflineEvent, eventType)
+
+		payload, ok := evt.Payload.(map[string]any)
+
+		assert.True(st, ok)
+		assert.Equal(st, inSubnet.IP, payload["ip"])
+	})
+
+	t.Run("filters events by config", func(st *testing.T) {
+		reader, closeStream := openStream(st, url+"?config=2&type=DISCOVERY_HOST_OFFLINE")
+
+		defer closeStream()
+
+		eventManager.Send(event.Event{
+			Type:    discovery.HostOfflineEvent,
+			Payload: inSubnet,
+		})
+
+		done := make(chan struct{})
+
+		go func() {
+			reader.ReadString('\n') // nolint:errcheck
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			st.Fatal("received event for a different config")
+		case <-time.After(time.Millisecond * 200):
+		}
+	})
+
+	t.Run("rejects unknown event types", func(st *testing.T) {
+		res, err := http.Get(url + "?type=unknown")
+
+		assert.NoError(st, err)
+
+		defer res.Body.Close()
+
+		assert.Equal(st, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("rejects invalid ip filters", func(st *testing.T) {
+		res, err := http.Get(url + "?ip=not-an-ip")
+
+		assert.NoError(st, err)
+
+		defer res.Body.Close()
+
+		assert.Equal(st, http.StatusBadRequest, res.StatusCode)
+	})
+}
@@ -0,0 +1,430 @@
+package sshclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// DefaultConnectTimeout timeout used when connecting if the provided context
+// does not have a deadline
+const DefaultConnectTimeout = time.Second * 10
+
+// Options represents the settings used to establish an ssh connection
+type Options struct {
+	User           string
+	Identity       string
+	Port           string
+	Passphrase     string
+	KnownHostsFile string
+	// HostKey SHA256 fingerprint of the host key recorded for the target.
+	// When set the target must present this key.
+	HostKey string
+	// Jumps hosts to tunnel through in order before connecting to the
+	// target, like ssh -J
+	Jumps []Jump
+}
+
+// Jump represents a single jump host. User and Port default to the user
+// being connected as and 22. The identity, passphrase, and known hosts file
+// from Options are used to authenticate with every jump host.
+type Jump struct {
+	User string
+	Host string
+	Port string
+}
+
+// Client wraps a single ssh connection which is reused for every command
+// run against the remote host
+type Client struct {
+	client *ssh.Client
+	jumps  []*ssh.Client
+}
+
+// Dial establishes a new ssh connection to the given host, tunneling through
+// any jump hosts. Connecting is aborted if the context is canceled or its
+// deadline is exceeded.
+func Dial(ctx context.Context, host string, opts Options) (*Client, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultConnectTimeout)
+		defer cancel()
+	}
+
+	knownHosts, err := loadKnownHosts(opts.KnownHostsFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	auth, closeAgent, identityErr := authMethods(opts)
+
+	defer closeAgent()
+
+	clientConfig := func(user, fingerprint string) *ssh.ClientConfig {
+		return &ssh.ClientConfig{
+			User:            user,
+			Auth:            auth,
+			HostKeyCallback: knownHosts.callback(fingerprint),
+		}
+	}
+
+	c := &Client{jumps: []*ssh.Client{}}
+
+	for _, jump := range opts.Jumps {
+		user := jump.User
+
+		if user == "" {
+			user = opts.User
+		}
+
+		port := jump.Port
+
+		if port == "" {
+			port = "22"
+		}
+
+		jumpClient, err := c.connect(
+			ctx,
+			net.JoinHostPort(jump.Host, port),
+			clientConfig(user, ""),
+		)
+
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf(
+				"jump host %s: %w",
+				jump.Host,
+				withIdentityErr(err, identityErr),
+			)
+		}
+
+		c.jumps = append(c.jumps, jumpClient)
+	}
+
+	c.client, err = c.connect(
+		ctx,
+		net.JoinHostPort(host, opts.Port),
+		clientConfig(opts.User, opts.HostKey),
+	)
+
+	if err != nil {
+		c.Close()
+		return nil, withIdentityErr(err, identityErr)
+	}
+
+	return c, nil
+}
+
+// connects to addr directly or through the last connected jump host and
+// performs the ssh handshake
+func (c *Client) connect(ctx context.Context, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	var conn net.Conn
+	var err error
+
+	if len(c.jumps) == 0 {
+		dialer := net.Dialer{}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = c.jumps[len(c.jumps)-1].DialContext(ctx, "tcp", addr)
+	}
+
+	if err != nil {
+		return nil, classify(ctx, err)
+	}
+
+	// tunneled connections don't support deadlines - closing the connection
+	// when the context is done below covers them
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	// make sure we don't hang in the handshake if context is canceled
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+
+	stop()
+
+	if err != nil {
+		conn.Close()
+		return nil, classify(ctx, err)
+	}
+
+	conn.SetDeadline(time.Time{})
+
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
+
+// Run runs a command in a new session on the existing connection and
+// returns its output
+func (c *Client) Run(ctx context.Context, cmd string) (string, error) {
+	session, err := c.client.NewSession()
+
+	if err != nil {
+		return "", classify(ctx, err)
+	}
+
+	defer session.Close()
+
+	stdout := bytes.Buffer{}
+	stderr := bytes.Buffer{}
+
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- session.Run(cmd)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return "", fmt.Errorf(
+				"%s: %w: %s",
+				cmd,
+				err,
+				strings.TrimSpace(stderr.String()),
+			)
+		}
+
+		return stdout.String(), nil
+	case <-ctx.Done():
+		return "", classify(ctx, ctx.Err())
+	}
+}
+
+// Stream runs a command in a new session writing its output to the given
+// writers as it is produced and returns the command's exit status
+func (c *Client) Stream(ctx context.Context, cmd string, stdout, stderr io.Writer) (int, error) {
+	session, err := c.client.NewSession()
+
+	if err != nil {
+		return -1, classify(ctx, err)
+	}
+
+	defer session.Close()
+
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- session.Run(cmd)
+	}()
+
+	select {
+	case err := <-done:
+		var exitErr *ssh.ExitError
+
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitStatus(), nil
+		}
+
+		if err != nil {
+			return -1, classify(ctx, err)
+		}
+
+		return 0, nil
+	case <-ctx.Done():
+		return -1, classify(ctx, ctx.Err())
+	}
+}
+
+// Close closes the underlying ssh connection and any jump host connections
+func (c *Client) Close() error {
+	var err error
+
+	if c.client != nil {
+		err = c.client.Close()
+	}
+
+	for i := len(c.jumps) - 1; i >= 0; i-- {
+		c.jumps[i].Close()
+	}
+
+	return err
+}
+
+// helpers
+
+// returns the auth methods to try in order - identity file then ssh-agent.
+// The returned function must be called to close the agent connection. A
+// failure to load the identity file is returned so it can be reported if
+// no agent key is accepted either.
+func authMethods(opts Options) ([]ssh.AuthMethod, func(), error) {
+	signers := []ssh.Signer{}
+	cleanup := func() {}
+
+	var identityErr error
+
+	if opts.Identity != "" {
+		signer, err := loadIdentity(opts.Identity, opts.Passphrase)
+
+		if err == nil {
+			signers = append(signers, signer)
+		} else {
+			identityErr = fmt.Errorf("%s: %w", opts.Identity, err)
+		}
+	}
+
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			cleanup = func() {
+				conn.Close()
+			}
+
+			if agentSigners, err := agent.NewClient(conn).Signers(); err == nil {
+				signers = append(signers, agentSigners...)
+			}
+		}
+	}
+
+	return []ssh.AuthMethod{ssh.PublicKeys(signers...)}, cleanup, identityErr
+}
+
+// loads a private key file decrypting it with passphrase if necessary
+func loadIdentity(identity, passphrase string) (ssh.Signer, error) {
+	data, err := os.ReadFile(expandHome(identity))
+
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := ssh.ParsePrivateKey(data)
+
+	var missingErr *ssh.PassphraseMissingError
+
+	if errors.As(err, &missingErr) && passphrase != "" {
+		return ssh.ParsePrivateKeyWithPassphrase(data, []byte(passphrase))
+	}
+
+	return signer, err
+}
+
+// serializes writes to known_hosts files across concurrent dials
+var knownHostsMux sync.Mutex
+
+// knownHosts represents a known_hosts file used to verify host keys
+type knownHosts struct {
+	path  string
+	check ssh.HostKeyCallback
+}
+
+// loads the given known_hosts file defaulting to ~/.ssh/known_hosts. A
+// missing file is created the first time a host key is recorded.
+func loadKnownHosts(knownHostsFile string) (*knownHosts, error) {
+	if knownHostsFile == "" {
+		home, err := os.UserHomeDir()
+
+		if err != nil {
+			return nil, err
+		}
+
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+
+	k := &knownHosts{path: knownHostsFile}
+
+	if _, err := os.Stat(knownHostsFile); errors.Is(err, os.ErrNotExist) {
+		return k, nil
+	}
+
+	check, err := knownhosts.New(knownHostsFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	k.check = check
+
+	return k, nil
+}
+
+// returns a callback that rejects keys that don't match the given
+// fingerprint, when set, or the key recorded in known_hosts. Keys for hosts
+// that are not yet in known_hosts are trusted on first use and recorded.
+func (k *knownHosts) callback(fingerprint string) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if fingerprint != "" && ssh.FingerprintSHA256(key) != fingerprint {
+			return fmt.Errorf(
+				"%w: %s presented %s but %s is recorded",
+				ErrHostKeyMismatch,
+				hostname,
+				ssh.FingerprintSHA256(key),
+				fingerprint,
+			)
+		}
+
+		if k.check != nil {
+			err := k.check(hostname, remote, key)
+
+			var keyErr *knownhosts.KeyError
+
+			if !errors.As(err, &keyErr) || len(keyErr.Want) > 0 {
+				// known host or mismatch
+				return err
+			}
+		}
+
+		return k.record(hostname, key)
+	}
+}
+
+// appends the host's key to the known_hosts file
+func (k *knownHosts) record(hostname string, key ssh.PublicKey) error {
+	knownHostsMux.Lock()
+	defer knownHostsMux.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(k.path), 0700); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(
+		k.path,
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY,
+		0600,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	_, err = file.WriteString(knownhosts.Line([]string{hostname}, key) + "\n")
+
+	return err
+}
+
+// expands leading "~" to the user's home directory
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~") {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+
+	if err != nil {
+		return path
+	}
+
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
@@ -0,0 +1,308 @@
+package sshclient_test
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robgonnella/ops/internal/sshclient"
+	"github.com/robgonnella/ops/internal/test_util"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func TestSSHClient(t *testing.T) {
+	server := test_util.NewSSHServer(t, "user", func(cmd string) (string, uint32) {
+		if cmd == "hostname" {
+			return "test-host\n", 0
+		}
+		return "", 127
+	})
+
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+
+	opts := sshclient.Options{
+		User:           server.User,
+		Identity:       server.IdentityFile,
+		Port:           server.Port,
+		KnownHostsFile: knownHostsFile,
+	}
+
+	t.Run("runs multiple commands on one connection", func(st *testing.T) {
+		client, err := sshclient.Dial(context.Background(), server.Host, opts)
+
+		assert.NoError(st, err)
+
+		defer client.Close()
+
+		out, err := client.Run(context.Background(), "hostname")
+
+		assert.NoError(st, err)
+		assert.Equal(st, "test-host\n", out)
+
+		_, err = client.Run(context.Background(), "not-a-command")
+
+		assert.Error(st, err)
+	})
+
+	t.Run("streams command output and exit status", func(st *testing.T) {
+		client, err := sshclient.Dial(context.Background(), server.Host, opts)
+
+		assert.NoError(st, err)
+
+		defer client.Close()
+
+		stdout := bytes.Buffer{}
+		stderr := bytes.Buffer{}
+
+		code, err := client.Stream(context.Background(), "hostname", &stdout, &stderr)
+
+		assert.NoError(st, err)
+		assert.Equal(st, 0, code)
+		assert.Equal(st, "test-host\n", stdout.String())
+
+		code, err = client.Stream(context.Background(), "not-a-command", &stdout, &stderr)
+
+		assert.NoError(st, err)
+		assert.Equal(st, 127, code)
+	})
+
+	t.Run("uploads and downloads files", func(st *testing.T) {
+		client, err := sshclient.Dial(context.Background(), server.Host, opts)
+
+		assert.NoError(st, err)
+
+		defer client.Close()
+
+		localDir := st.TempDir()
+		remoteDir := st.TempDir()
+		content := bytes.Repeat([]byte("ops"), 50000)
+
+		localFile := filepath.Join(localDir, "script.sh")
+
+		assert.NoError(st, os.WriteFile(localFile, content, 0755))
+
+		var lastProgress, lastTotal int64
+
+		progress := func(transferred, total int64) {
+			lastProgress = transferred
+			lastTotal = total
+		}
+
+		dest, n, err := client.Upload(context.Background(), localFile, remoteDir, progress)
+
+		assert.NoError(st, err)
+		assert.Equal(st, filepath.Join(remoteDir, "script.sh"), dest)
+		assert.Equal(st, int64(len(content)), n)
+		assert.Equal(st, int64(len(content)), lastProgress)
+		assert.Equal(st, int64(len(content)), lastTotal)
+
+		uploaded, err := os.ReadFile(dest)
+
+		assert.NoError(st, err)
+		assert.Equal(st, content, uploaded)
+
+		downloadDir := filepath.Join(st.TempDir(), "downloads") + string(os.PathSeparator)
+
+		dest, n, err = client.Download(context.Background(), dest, downloadDir, nil)
+
+		assert.NoError(st, err)
+		assert.Equal(st, filepath.Join(downloadDir, "script.sh"), dest)
+		assert.Equal(st, int64(len(content)), n)
+
+		downloaded, err := os.ReadFile(dest)
+
+		assert.NoError(st, err)
+		assert.Equal(st, content, downloaded)
+
+		_, _, err = client.Download(context.Background(), filepath.Join(remoteDir, "missing"), localDir, nil)
+
+		assert.Error(st, err)
+	})
+
+	t.Run("connects through jump hosts", func(st *testing.T) {
+		bastion := test_util.NewSSHServer(st, "admin", func(cmd string) (string, uint32) {
+			return "bastion\n", 0
+		})
+
+		// the same identity is used for the jump host and the target
+		server.AuthorizeIdentityFile(st, bastion.IdentityFile)
+
+		jumpOpts := opts
+		jumpOpts.Identity = bastion.IdentityFile
+		jumpOpts.Jumps = []sshclient.Jump{
+			{User: bastion.User, Host: bastion.Host, Port: bastion.Port},
+		}
+
+		client, err := sshclient.Dial(context.Background(), server.Host, jumpOpts)
+
+		assert.NoError(st, err)
+
+		defer client.Close()
+
+		out, err := client.Run(context.Background(), "hostname")
+
+		assert.NoError(st, err)
+		assert.Equal(st, "test-host\n", out)
+	})
+
+	t.Run("returns jump host errors", func(st *testing.T) {
+		bastion := test_util.NewSSHServer(st, "admin", func(cmd string) (string, uint32) {
+			return "", 0
+		})
+
+		jumpOpts := opts
+		jumpOpts.Jumps = []sshclient.Jump{
+			{User: bastion.User, Host: bastion.Host, Port: bastion.Port},
+		}
+
+		_, err := sshclient.Dial(context.Background(), server.Host, jumpOpts)
+
+		assert.ErrorIs(st, err, sshclient.ErrAuthFailed)
+		assert.ErrorContains(st, err, "jump host "+bastion.Host)
+	})
+
+	t.Run("returns auth failed error", func(st *testing.T) {
+		_, otherIdentity := test_util.GenerateIdentityFile(st)
+
+		badOpts := opts
+		badOpts.Identity = otherIdentity
+
+		_, err := sshclient.Dial(context.Background(), server.Host, badOpts)
+
+		assert.ErrorIs(st, err, sshclient.ErrAuthFailed)
+		assert.Equal(st, "auth failed", sshclient.Reason(err))
+	})
+
+	t.Run("returns identity errors when auth fails", func(st *testing.T) {
+		st.Setenv("SSH_AUTH_SOCK", "")
+
+		badOpts := opts
+		badOpts.Identity = filepath.Join(st.TempDir(), "missing")
+
+		_, err := sshclient.Dial(context.Background(), server.Host, badOpts)
+
+		assert.ErrorIs(st, err, sshclient.ErrAuthFailed)
+		assert.ErrorIs(st, err, sshclient.ErrIdentity)
+		assert.ErrorIs(st, err, os.ErrNotExist)
+		assert.ErrorContains(st, err, badOpts.Identity)
+		assert.Equal(st, "bad identity", sshclient.Reason(err))
+	})
+
+	t.Run("returns host key mismatch error", func(st *testing.T) {
+		otherKey := test_util.GenerateSigner(st)
+
+		line := knownhosts.Line(
+			[]string{net.JoinHostPort(server.Host, server.Port)},
+			otherKey.PublicKey(),
+		)
+
+		mismatchFile := filepath.Join(st.TempDir(), "known_hosts")
+
+		assert.NoError(st, os.WriteFile(mismatchFile, []byte(line+"\n"), 0600))
+
+		mismatchOpts := opts
+		mismatchOpts.KnownHostsFile = mismatchFile
+
+		_, err := sshclient.Dial(context.Background(), server.Host, mismatchOpts)
+
+		assert.ErrorIs(st, err, sshclient.ErrHostKeyMismatch)
+		assert.Equal(st, "host key mismatch", sshclient.Reason(err))
+	})
+
+	t.Run("records unknown host keys on first use", func(st *testing.T) {
+		firstUseOpts := opts
+		firstUseOpts.KnownHostsFile = filepath.Join(st.TempDir(), "ssh", "known_hosts")
+
+		client, err := sshclient.Dial(context.Background(), server.Host, firstUseOpts)
+
+		assert.NoError(st, err)
+
+		client.Close()
+
+		data, err := os.ReadFile(firstUseOpts.KnownHostsFile)
+
+		assert.NoError(st, err)
+
+		expected := knownhosts.Line(
+			[]string{net.JoinHostPort(server.Host, server.Port)},
+			server.HostKey.PublicKey(),
+		)
+
+		assert.Equal(st, expected+"\n", string(data))
+
+		// recorded key is accepted on subsequent connections
+		client, err = sshclient.Dial(context.Background(), server.Host, firstUseOpts)
+
+		assert.NoError(st, err)
+
+		client.Close()
+	})
+
+	t.Run("accepts pinned host key", func(st *testing.T) {
+		pinnedOpts := opts
+		pinnedOpts.HostKey = ssh.FingerprintSHA256(server.HostKey.PublicKey())
+
+		client, err := sshclient.Dial(context.Background(), server.Host, pinnedOpts)
+
+		assert.NoError(st, err)
+
+		client.Close()
+	})
+
+	t.Run("returns host key mismatch error for pinned host key", func(st *testing.T) {
+		otherKey := test_util.GenerateSigner(st)
+
+		pinnedOpts := opts
+		pinnedOpts.KnownHostsFile = filepath.Join(st.TempDir(), "known_hosts")
+		pinnedOpts.HostKey = ssh.FingerprintSHA256(otherKey.PublicKey())
+
+		_, err := sshclient.Dial(context.Background(), server.Host, pinnedOpts)
+
+		assert.ErrorIs(st, err, sshclient.ErrHostKeyMismatch)
+		assert.Equal(st, "host key mismatch", sshclient.Reason(err))
+
+		// mismatched keys are never recorded
+		_, err = os.Stat(pinnedOpts.KnownHostsFile)
+
+		assert.ErrorIs(st, err, os.ErrNotExist)
+	})
+
+	t.Run("returns timeout error", func(st *testing.T) {
+		// accepts connections but never completes the ssh handshake
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+		assert.NoError(st, err)
+
+		defer listener.Close()
+
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+			}
+		}()
+
+		host, port, _ := net.SplitHostPort(listener.Addr().String())
+
+		timeoutOpts := opts
+		timeoutOpts.Port = port
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+
+		defer cancel()
+
+		_, err = sshclient.Dial(ctx, host, timeoutOpts)
+
+		assert.ErrorIs(st, err, sshclient.ErrTimeout)
+		assert.Equal(st, "timeout", sshclient.Reason(err))
+	})
+}
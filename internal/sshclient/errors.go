@@ -0,0 +1,82 @@
+package sshclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+var (
+	// ErrAuthFailed returned when none of the available auth methods
+	// were accepted by the server
+	ErrAuthFailed = errors.New("ssh authentication failed")
+	// ErrIdentity returned with ErrAuthFailed when the identity file could
+	// not be used, e.g. it is missing, unreadable, or the passphrase is wrong
+	ErrIdentity = errors.New("ssh identity could not be loaded")
+	// ErrHostKeyMismatch returned when the server's host key does not match
+	// the key recorded in known_hosts or the pinned host key
+	ErrHostKeyMismatch = errors.New("ssh host key mismatch")
+	// ErrTimeout returned when connecting or running a command takes longer
+	// than allowed
+	ErrTimeout = errors.New("ssh connection timed out")
+)
+
+// Reason returns a short human readable reason for the given error suitable
+// for displaying next to a host
+func Reason(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrIdentity):
+		return "bad identity"
+	case errors.Is(err, ErrAuthFailed):
+		return "auth failed"
+	case errors.Is(err, ErrHostKeyMismatch):
+		return "host key mismatch"
+	case errors.Is(err, ErrTimeout):
+		return "timeout"
+	default:
+		return "error"
+	}
+}
+
+// wraps errors returned from the ssh library with our typed errors
+func classify(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var keyErr *knownhosts.KeyError
+
+	if errors.As(err, &keyErr) && len(keyErr.Want) > 0 {
+		return fmt.Errorf("%w: %s", ErrHostKeyMismatch, err)
+	}
+
+	var netErr net.Error
+
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(ctx.Err(), context.DeadlineExceeded) ||
+		(errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("%w: %s", ErrTimeout, err)
+	}
+
+	if strings.Contains(err.Error(), "unable to authenticate") {
+		return fmt.Errorf("%w: %s", ErrAuthFailed, err)
+	}
+
+	return err
+}
+
+// explains an auth failure with the reason the identity file could not be
+// used since the server only reports that no key was accepted
+func withIdentityErr(err, identityErr error) error {
+	if identityErr == nil || !errors.Is(err, ErrAuthFailed) {
+		return err
+	}
+
+	return fmt.Errorf("%w: %w: %w", err, ErrIdentity, identityErr)
+}
@@ -0,0 +1,213 @@
+package sshclient
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/sftp"
+)
+
+// Progress is called as a file is transferred with the number of bytes
+// transferred so far and the total size of the file
+type Progress func(transferred, total int64)
+
+// Upload copies a local file to the remote host over sftp. If the remote
+// path is an existing directory the file is copied into it. Returns the
+// remote path written and the number of bytes copied.
+func (c *Client) Upload(
+	ctx context.Context,
+	localPath,
+	remotePath string,
+	progress Progress,
+) (string, int64, error) {
+	client, stop, err := c.sftp(ctx)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	defer stop()
+
+	src, err := os.Open(expandHome(localPath))
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	defer src.Close()
+
+	info, err := src.Stat()
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	if info, err := client.Stat(remotePath); err == nil && info.IsDir() {
+		remotePath = path.Join(remotePath, filepath.Base(localPath))
+	}
+
+	dst, err := client.Create(remotePath)
+
+	if err != nil {
+		return "", 0, classify(ctx, err)
+	}
+
+	defer dst.Close()
+
+	n, err := copyWithProgress(ctx, dst, src, info.Size(), progress)
+
+	if err != nil {
+		return "", n, classify(ctx, err)
+	}
+
+	if err := client.Chmod(remotePath, info.Mode().Perm()); err != nil {
+		return "", n, classify(ctx, err)
+	}
+
+	return remotePath, n, nil
+}
+
+// Download copies a remote file to the local machine over sftp. If the
+// local path is an existing directory or ends in a path separator the file
+// is copied into it. Returns the local path written and the number of
+// bytes copied.
+func (c *Client) Download(
+	ctx context.Context,
+	remotePath,
+	localPath string,
+	progress Progress,
+) (string, int64, error) {
+	client, stop, err := c.sftp(ctx)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	defer stop()
+
+	src, err := client.Open(remotePath)
+
+	if err != nil {
+		return "", 0, classify(ctx, err)
+	}
+
+	defer src.Close()
+
+	info, err := src.Stat()
+
+	if err != nil {
+		return "", 0, classify(ctx, err)
+	}
+
+	localPath = expandHome(localPath)
+
+	if isDir(localPath) {
+		localPath = filepath.Join(localPath, path.Base(remotePath))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return "", 0, err
+	}
+
+	dst, err := os.OpenFile(
+		localPath,
+		os.O_CREATE|os.O_TRUNC|os.O_WRONLY,
+		info.Mode().Perm(),
+	)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	defer dst.Close()
+
+	n, err := copyWithProgress(ctx, dst, src, info.Size(), progress)
+
+	if err != nil {
+		return "", n, classify(ctx, err)
+	}
+
+	return localPath, n, nil
+}
+
+// starts a new sftp session on the existing connection. The returned
+// function must be called to close the session.
+func (c *Client) sftp(ctx context.Context) (*sftp.Client, func(), error) {
+	client, err := sftp.NewClient(c.client)
+
+	if err != nil {
+		return nil, nil, classify(ctx, err)
+	}
+
+	// make sure we don't hang on a transfer if context is canceled
+	stop := context.AfterFunc(ctx, func() {
+		client.Close()
+	})
+
+	return client, func() {
+		stop()
+		client.Close()
+	}, nil
+}
+
+// copies src to dst reporting progress after each chunk
+func copyWithProgress(
+	ctx context.Context,
+	dst io.Writer,
+	src io.Reader,
+	total int64,
+	progress Progress,
+) (int64, error) {
+	buf := make([]byte, 32*1024)
+	written := int64(0)
+
+	if progress != nil {
+		progress(0, total)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+
+		nr, readErr := src.Read(buf)
+
+		if nr > 0 {
+			nw, err := dst.Write(buf[:nr])
+
+			written += int64(nw)
+
+			if err != nil {
+				return written, err
+			}
+
+			if progress != nil {
+				progress(written, total)
+			}
+		}
+
+		if readErr == io.EOF {
+			return written, nil
+		}
+
+		if readErr != nil {
+			return written, readErr
+		}
+	}
+}
+
+// returns true if the local path is an existing directory or is meant to
+// be one
+func isDir(p string) bool {
+	if strings.HasSuffix(p, string(os.PathSeparator)) {
+		return true
+	}
+
+	info, err := os.Stat(p)
+
+	return err == nil && info.IsDir()
+}
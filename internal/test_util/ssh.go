@@ -0,0 +1,306 @@
+package test_util
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// CommandHandler returns stdout and exit status for a command run on
+// the test ssh server
+type CommandHandler func(cmd string) (string, uint32)
+
+// SSHServer in-process ssh server used for testing ssh clients
+type SSHServer struct {
+	Host         string
+	Port         string
+	User         string
+	HostKey      ssh.Signer
+	IdentityFile string
+	listener     net.Listener
+	handler      CommandHandler
+	authorized   [][]byte
+	mux          sync.Mutex
+	wg           sync.WaitGroup
+}
+
+// NewSSHServer starts a new ssh server on localhost that accepts the
+// generated identity file for the given user and runs commands using
+// the given handler. The sftp subsystem is served from the local
+// filesystem and direct-tcpip channels are forwarded so the server can
+// be used as a jump host.
+func NewSSHServer(t *testing.T, user string, handler CommandHandler) *SSHServer {
+	t.Helper()
+
+	hostKey := GenerateSigner(t)
+	clientKey, identityFile := GenerateIdentityFile(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	s := &SSHServer{
+		Host:         host,
+		Port:         port,
+		User:         user,
+		HostKey:      hostKey,
+		IdentityFile: identityFile,
+		listener:     listener,
+		handler:      handler,
+		authorized:   [][]byte{clientKey.PublicKey().Marshal()},
+	}
+
+	conf := &ssh.ServerConfig{
+		PublicKeyCallback: func(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if meta.User() == user && s.isAuthorized(key) {
+				return &ssh.Permissions{}, nil
+			}
+			return nil, os.ErrPermission
+		},
+	}
+
+	conf.AddHostKey(hostKey)
+
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+
+		for {
+			conn, err := listener.Accept()
+
+			if err != nil {
+				return
+			}
+
+			go s.serve(conn, conf)
+		}
+	}()
+
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// AuthorizeIdentityFile allows clients to authenticate with the given
+// identity file in addition to the generated one
+func (s *SSHServer) AuthorizeIdentityFile(t *testing.T, identityFile string) {
+	t.Helper()
+
+	data, err := os.ReadFile(identityFile)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(data)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.authorized = append(s.authorized, signer.PublicKey().Marshal())
+}
+
+func (s *SSHServer) isAuthorized(key ssh.PublicKey) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for _, authorized := range s.authorized {
+		if string(key.Marshal()) == string(authorized) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Close stops the ssh server
+func (s *SSHServer) Close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *SSHServer) serve(conn net.Conn, conf *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, conf)
+
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	go ssh.DiscardRequests(reqs)
+
+	for newChan := range chans {
+		if newChan.ChannelType() == "direct-tcpip" {
+			go s.forward(newChan)
+			continue
+		}
+
+		if newChan.ChannelType() != "session" {
+			newChan.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+
+		channel, requests, err := newChan.Accept()
+
+		if err != nil {
+			continue
+		}
+
+		go s.handleSession(channel, requests)
+	}
+}
+
+// forwards a direct-tcpip channel to the requested address
+func (s *SSHServer) forward(newChan ssh.NewChannel) {
+	payload := struct {
+		Host       string
+		Port       uint32
+		OriginHost string
+		OriginPort uint32
+	}{}
+
+	if err := ssh.Unmarshal(newChan.ExtraData(), &payload); err != nil {
+		newChan.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+
+	conn, err := net.Dial(
+		"tcp",
+		net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))),
+	)
+
+	if err != nil {
+		newChan.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+
+	channel, requests, err := newChan.Accept()
+
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	go ssh.DiscardRequests(requests)
+
+	go func() {
+		io.Copy(conn, channel)
+		conn.Close()
+	}()
+
+	io.Copy(channel, conn)
+	channel.Close()
+}
+
+func (s *SSHServer) handleSession(channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+
+	for req := range requests {
+		if req.Type == "subsystem" && string(req.Payload[4:]) == "sftp" {
+			req.Reply(true, nil)
+
+			server, err := sftp.NewServer(channel)
+
+			if err != nil {
+				return
+			}
+
+			server.Serve()
+			server.Close()
+
+			return
+		}
+
+		if req.Type != "exec" {
+			req.Reply(false, nil)
+			continue
+		}
+
+		cmdLen := binary.BigEndian.Uint32(req.Payload[:4])
+		cmd := string(req.Payload[4 : 4+cmdLen])
+
+		req.Reply(true, nil)
+
+		stdout, status := s.handler(cmd)
+
+		channel.Write([]byte(stdout))
+
+		exitStatus := make([]byte, 4)
+		binary.BigEndian.PutUint32(exitStatus, status)
+
+		channel.SendRequest("exit-status", false, exitStatus)
+
+		return
+	}
+}
+
+// GenerateSigner generates a new ed25519 ssh signer
+func GenerateSigner(t *testing.T) ssh.Signer {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := ssh.NewSignerFromKey(key)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signer
+}
+
+// GenerateIdentityFile generates a new ed25519 private key and writes it
+// to a temporary file returning the signer and file path
+func GenerateIdentityFile(t *testing.T) (ssh.Signer, string) {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := ssh.NewSignerFromKey(key)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := ssh.MarshalPrivateKey(key, "")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(t.TempDir(), "id_ed25519")
+
+	if err := os.WriteFile(file, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return signer, file
+}
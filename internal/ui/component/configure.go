@@ -1,6 +1,9 @@
 package component
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	"github.com/robgonnella/ops/internal/config"
@@ -14,7 +17,12 @@ type ConfigureForm struct {
 	sshUserInput      *tview.InputField
 	sshIdentityInput  *tview.InputField
 	sshPortInput      *tview.InputField
+	sshProxyJumpInput *tview.InputField
+	sshNativeInput    *tview.Checkbox
+	sshConfigInput    *tview.Checkbox
 	ifaceInput        *tview.InputField
+	scanInputs        *scanInputs
+	sessionInputs     *sessionInputs
 	overrides         []map[string]*tview.InputField
 	conf              config.Config
 	onUpdate          func(conf config.Config)
@@ -63,10 +71,188 @@ func addBlankFormItems(
 	return configName, sshUserInput, sshIdentityInput, sshPortInput, ifaceInput
 }
 
-// every time the add ssh override button is clicked we add three new inputs
-func createOverrideInputs(conf config.Config) (*tview.InputField, *tview.InputField, *tview.InputField, *tview.InputField) {
+// adds input for the default proxy jump chain
+func addProxyJumpFormItem(form *tview.Form) *tview.InputField {
+	sshProxyJumpInput := tview.NewInputField()
+	sshProxyJumpInput.SetLabel("SSH Proxy Jump: ")
+
+	form.AddFormItem(sshProxyJumpInput)
+
+	return sshProxyJumpInput
+}
+
+// adds checkboxes for toggling the native ssh client and reading settings
+// from ~/.ssh/config
+func addSSHOptionFormItems(form *tview.Form) (*tview.Checkbox, *tview.Checkbox) {
+	sshNativeInput := tview.NewCheckbox()
+	sshNativeInput.SetLabel("Native SSH Client: ")
+
+	sshConfigInput := tview.NewCheckbox()
+	sshConfigInput.SetLabel("Use ~/.ssh/config: ")
+
+	form.AddFormItem(sshNativeInput)
+	form.AddFormItem(sshConfigInput)
+
+	return sshNativeInput, sshConfigInput
+}
+
+// number of form items added before any ssh overrides
+const baseFormItemCount = 16
+
+// scanInputs inputs for configuring network scan settings
+type scanInputs struct {
+	interval     *tview.InputField
+	timeout      *tview.InputField
+	listenPort   *tview.InputField
+	offlineAfter *tview.InputField
+	servicePorts *tview.InputField
+	concurrent   *tview.Checkbox
+}
+
+// adds blank scan settings inputs to form
+func addScanFormItems(form *tview.Form) *scanInputs {
+	interval := tview.NewInputField()
+	interval.SetLabel("Scan Interval: ")
+
+	timeout := tview.NewInputField()
+	timeout.SetLabel("Scan Timeout: ")
+
+	listenPort := tview.NewInputField()
+	listenPort.SetLabel("Scan Listen Port: ")
+
+	offlineAfter := tview.NewInputField()
+	offlineAfter.SetLabel("Offline After Missed Scans: ")
+
+	servicePorts := tview.NewInputField()
+	servicePorts.SetLabel("Service Ports (comma separated): ")
+
+	concurrent := tview.NewCheckbox()
+	concurrent.SetLabel("Concurrent Detail Scans: ")
+
+	form.AddFormItem(interval)
+	form.AddFormItem(timeout)
+	form.AddFormItem(listenPort)
+	form.AddFormItem(offlineAfter)
+	form.AddFormItem(servicePorts)
+	form.AddFormItem(concurrent)
+
+	return &scanInputs{
+		interval:     interval,
+		timeout:      timeout,
+		listenPort:   listenPort,
+		offlineAfter: offlineAfter,
+		servicePorts: servicePorts,
+		concurrent:   concurrent,
+	}
+}
+
+// sets scan input values from the given scan config
+func (i *scanInputs) setValues(scanConf config.ScanConfig) {
+	offlineAfter := ""
+
+	if scanConf.OfflineAfter > 0 {
+		offlineAfter = strconv.Itoa(scanConf.OfflineAfter)
+	}
+
+	i.interval.SetText(scanConf.Interval)
+	i.timeout.SetText(scanConf.Timeout)
+	i.listenPort.SetText(scanConf.ListenPort)
+	i.offlineAfter.SetText(offlineAfter)
+	i.servicePorts.SetText(strings.Join(scanConf.ServicePorts, ","))
+	i.concurrent.SetChecked(scanConf.ConcurrentDetails)
+}
+
+// returns a validated scan config from input values
+func (i *scanInputs) getValues() (config.ScanConfig, error) {
+	offlineAfter := 0
+
+	if text := i.offlineAfter.GetText(); text != "" {
+		value, err := strconv.Atoi(text)
+
+		if err != nil {
+			return config.ScanConfig{}, err
+		}
+
+		offlineAfter = value
+	}
+
+	var servicePorts []string
+
+	for _, p := range strings.Split(i.servicePorts.GetText(), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			servicePorts = append(servicePorts, p)
+		}
+	}
+
+	scanConf := config.ScanConfig{
+		Interval:          i.interval.GetText(),
+		Timeout:           i.timeout.GetText(),
+		ListenPort:        i.listenPort.GetText(),
+		OfflineAfter:      offlineAfter,
+		ServicePorts:      servicePorts,
+		ConcurrentDetails: i.concurrent.IsChecked(),
+	}
+
+	return scanConf, scanConf.Validate()
+}
+
+// sessionInputs inputs for configuring how ssh sessions are opened
+type sessionInputs struct {
+	launcher *tview.DropDown
+	template *tview.InputField
+}
+
+// adds blank session launcher inputs to form
+func addSessionFormItems(form *tview.Form) *sessionInputs {
+	options := make([]string, len(config.LauncherKinds))
+
+	for i, kind := range config.LauncherKinds {
+		options[i] = string(kind)
+	}
+
+	launcher := tview.NewDropDown()
+	launcher.SetLabel("SSH Session Launcher: ")
+	launcher.SetOptions(options, nil)
+	launcher.SetCurrentOption(0)
+
+	template := tview.NewInputField()
+	template.SetLabel("Session Launcher Template: ")
+
+	form.AddFormItem(launcher)
+	form.AddFormItem(template)
+
+	return &sessionInputs{launcher: launcher, template: template}
+}
+
+// sets session input values from the given session config
+func (i *sessionInputs) setValues(sessionConf config.SessionConfig) {
+	i.launcher.SetCurrentOption(0)
+
+	for idx, kind := range config.LauncherKinds {
+		if kind == sessionConf.Kind() {
+			i.launcher.SetCurrentOption(idx)
+		}
+	}
+
+	i.template.SetText(sessionConf.Template)
+}
+
+// returns a validated session config from input values
+func (i *sessionInputs) getValues() (config.SessionConfig, error) {
+	idx, _ := i.launcher.GetCurrentOption()
+
+	sessionConf := config.SessionConfig{
+		Launcher: config.LauncherKinds[idx],
+		Template: i.template.GetText(),
+	}
+
+	return sessionConf, sessionConf.Validate()
+}
+
+// every time the add ssh override button is clicked we add five new inputs
+func createOverrideInputs(conf config.Config) (*tview.InputField, *tview.InputField, *tview.InputField, *tview.InputField, *tview.InputField) {
 	overrideTarget := tview.NewInputField()
-	overrideTarget.SetLabel("Override Target IP: ")
+	overrideTarget.SetLabel("Override Target: ")
 
 	overrideSSHUser := tview.NewInputField()
 	overrideSSHUser.SetLabel("Override SSH User: ")
@@ -77,7 +263,10 @@ func createOverrideInputs(conf config.Config) (*tview.InputField, *tview.InputFi
 	overrideSSHPort := tview.NewInputField()
 	overrideSSHPort.SetLabel("Override SSH Port: ")
 
-	return overrideTarget, overrideSSHUser, overrideSSHIdentity, overrideSSHPort
+	overrideSSHProxyJump := tview.NewInputField()
+	overrideSSHProxyJump.SetLabel("Override SSH Proxy Jump: ")
+
+	return overrideTarget, overrideSSHUser, overrideSSHIdentity, overrideSSHPort, overrideSSHProxyJump
 }
 
 // NewConfigureForm returns a new instance of ConfigureForm
@@ -94,13 +283,26 @@ func NewConfigureForm(
 		conf.Name,
 	)
 
+	sshProxyJumpInput := addProxyJumpFormItem(form)
+
+	sshNativeInput, sshConfigInput := addSSHOptionFormItems(form)
+
+	scanInputs := addScanFormItems(form)
+
+	sessionInputs := addSessionFormItems(form)
+
 	return &ConfigureForm{
 		root:              form,
 		configName:        configName,
 		sshUserInput:      sshUserInput,
 		sshIdentityInput:  sshIdentityInput,
 		sshPortInput:      sshPortInput,
+		sshProxyJumpInput: sshProxyJumpInput,
+		sshNativeInput:    sshNativeInput,
+		sshConfigInput:    sshConfigInput,
 		ifaceInput:        ifaceInput,
+		scanInputs:        scanInputs,
+		sessionInputs:     sessionInputs,
 		overrides:         []map[string]*tview.InputField{},
 		conf:              conf,
 		onUpdate:          onUpdate,
@@ -129,34 +331,50 @@ func (f *ConfigureForm) render() {
 	f.configName, f.sshUserInput, f.sshIdentityInput, f.sshPortInput, f.ifaceInput =
 		addBlankFormItems(f.root, f.conf.Name)
 
+	f.sshProxyJumpInput = addProxyJumpFormItem(f.root)
+
+	f.sshNativeInput, f.sshConfigInput = addSSHOptionFormItems(f.root)
+
+	f.scanInputs = addScanFormItems(f.root)
+
+	f.sessionInputs = addSessionFormItems(f.root)
+
 	networkTargets := f.conf.Interface
 
 	f.configName.SetText(f.conf.Name)
 	f.sshUserInput.SetText(f.conf.SSH.User)
 	f.sshIdentityInput.SetText(f.conf.SSH.Identity)
 	f.sshPortInput.SetText(f.conf.SSH.Port)
+	f.sshProxyJumpInput.SetText(f.conf.SSH.ProxyJump)
+	f.sshNativeInput.SetChecked(f.conf.SSH.Native)
+	f.sshConfigInput.SetChecked(f.conf.SSH.UseSSHConfig)
 	f.ifaceInput.SetText(networkTargets)
+	f.scanInputs.setValues(f.conf.Scan)
+	f.sessionInputs.setValues(f.conf.Session)
 
 	for _, o := range f.conf.SSH.Overrides {
-		target, user, identity, port := createOverrideInputs(f.conf)
+		target, user, identity, port, proxyJump := createOverrideInputs(f.conf)
 
 		f.overrides = append(f.overrides, map[string]*tview.InputField{
-			"target":   target,
-			"user":     user,
-			"identity": identity,
-			"port":     port,
+			"target":    target,
+			"user":      user,
+			"identity":  identity,
+			"port":      port,
+			"proxyJump": proxyJump,
 		})
 
 		target.SetText(o.Target)
 		user.SetText(o.User)
 		identity.SetText(o.Identity)
 		port.SetText(o.Port)
+		proxyJump.SetText(o.ProxyJump)
 
 		f.root.
 			AddFormItem(target).
 			AddFormItem(user).
 			AddFormItem(identity).
-			AddFormItem(port)
+			AddFormItem(port).
+			AddFormItem(proxyJump)
 	}
 
 	f.addFormButtons()
@@ -175,13 +393,14 @@ func (f *ConfigureForm) addFormButtons() {
 	})
 
 	f.root.AddButton("Add SSH Override", func() {
-		target, user, identity, port := createOverrideInputs(f.conf)
+		target, user, identity, port, proxyJump := createOverrideInputs(f.conf)
 
 		f.overrides = append(f.overrides, map[string]*tview.InputField{
-			"target":   target,
-			"user":     user,
-			"identity": identity,
-			"port":     port,
+			"target":    target,
+			"user":      user,
+			"identity":  identity,
+			"port":      port,
+			"proxyJump": proxyJump,
 		})
 
 		// add defaults when creating a new override
@@ -193,16 +412,13 @@ func (f *ConfigureForm) addFormButtons() {
 			AddFormItem(target).
 			AddFormItem(user).
 			AddFormItem(identity).
-			AddFormItem(port)
+			AddFormItem(port).
+			AddFormItem(proxyJump)
 	})
 
 	f.root.AddButton("New", func() {
-		for _, o := range f.overrides {
-			for range o {
-				// TODO find a better way
-				// overrides start at 4th index
-				f.root.RemoveFormItem(4)
-			}
+		for f.root.GetFormItemCount() > baseFormItemCount {
+			f.root.RemoveFormItem(baseFormItemCount)
 		}
 
 		f.overrides = []map[string]*tview.InputField{}
@@ -211,6 +427,11 @@ func (f *ConfigureForm) addFormButtons() {
 		f.sshUserInput.SetText("")
 		f.sshIdentityInput.SetText("")
 		f.sshPortInput.SetText("")
+		f.sshProxyJumpInput.SetText("")
+		f.sshNativeInput.SetChecked(false)
+		f.sshConfigInput.SetChecked(false)
+		f.scanInputs.setValues(config.ScanConfig{})
+		f.sessionInputs.setValues(config.SessionConfig{})
 		f.creatingNewConfig = true
 	})
 
@@ -226,14 +447,29 @@ func (f *ConfigureForm) addFormButtons() {
 			return
 		}
 
+		scanConf, err := f.scanInputs.getValues()
+
+		if err != nil {
+			f.creatingNewConfig = false
+			return
+		}
+
+		sessionConf, err := f.sessionInputs.getValues()
+
+		if err != nil {
+			f.creatingNewConfig = false
+			return
+		}
+
 		confOverrides := []config.SSHOverride{}
 
 		for _, o := range f.overrides {
 			confOverride := config.SSHOverride{
-				Target:   o["target"].GetText(),
-				User:     o["user"].GetText(),
-				Identity: o["identity"].GetText(),
-				Port:     o["port"].GetText(),
+				Target:    o["target"].GetText(),
+				User:      o["user"].GetText(),
+				Identity:  o["identity"].GetText(),
+				Port:      o["port"].GetText(),
+				ProxyJump: o["proxyJump"].GetText(),
 			}
 
 			confOverrides = append(confOverrides, confOverride)
@@ -242,11 +478,16 @@ func (f *ConfigureForm) addFormButtons() {
 		conf := config.Config{
 			Name: name,
 			SSH: config.SSHConfig{
-				User:      sshUser,
-				Identity:  sshIdentity,
-				Port:      sshPort,
-				Overrides: confOverrides,
+				User:         sshUser,
+				Identity:     sshIdentity,
+				Port:         sshPort,
+				ProxyJump:    f.sshProxyJumpInput.GetText(),
+				Native:       f.sshNativeInput.IsChecked(),
+				UseSSHConfig: f.sshConfigInput.IsChecked(),
+				Overrides:    confOverrides,
 			},
+			Scan:      scanConf,
+			Session:   sessionConf,
 			Interface: iface,
 		}
 
@@ -257,6 +498,11 @@ func (f *ConfigureForm) addFormButtons() {
 		}
 
 		conf.ID = f.conf.ID
+		// preserve settings that cannot be edited in the form
+		conf.Aliases = f.conf.Aliases
+		conf.Actions = f.conf.Actions
+		conf.Sort = f.conf.Sort
+		conf.Active = f.conf.Active
 		f.onUpdate(conf)
 	})
 }
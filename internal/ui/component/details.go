@@ -0,0 +1,154 @@
+package component
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/robgonnella/ops/internal/discovery"
+	"github.com/robgonnella/ops/internal/ui/key"
+	"github.com/robgonnella/ops/internal/ui/style"
+)
+
+// HostDetails table displaying gathered facts for a single server
+type HostDetails struct {
+	table         *tview.Table
+	columnHeaders []string
+	result        discovery.DiscoveryResult
+}
+
+// NewHostDetails returns a new instance of HostDetails
+func NewHostDetails(onDismiss func()) *HostDetails {
+	columnHeaders := []string{"FIELD", "VALUE"}
+
+	table := createTable("details", columnHeaders)
+
+	table.SetInputCapture(func(evt *tcell.EventKey) *tcell.EventKey {
+		if evt.Key() == key.KeyEsc {
+			onDismiss()
+			return nil
+		}
+
+		return evt
+	})
+
+	return &HostDetails{
+		table:         table,
+		columnHeaders: columnHeaders,
+	}
+}
+
+// Primitive returns the root primitive for HostDetails
+func (d *HostDetails) Primitive() tview.Primitive {
+	return d.table
+}
+
+// ID returns the id of the server currently being displayed
+func (d *HostDetails) ID() string {
+	return d.result.ID
+}
+
+// SetResult displays the details for the given server
+func (d *HostDetails) SetResult(result discovery.DiscoveryResult) {
+	d.result = result
+
+	d.table.Clear()
+	setTableHeaders(d.table, d.columnHeaders)
+	d.table.SetTitle(result.Hostname + " details")
+
+	rows := [][]string{
+		{"Hostname", result.Hostname},
+		{"Hostname Source", string(result.HostnameSource)},
+		{"IP", result.IP},
+		{"ID", result.ID},
+		{"OS", result.OS},
+		{"Vendor", result.Vendor},
+		{"Status", string(result.Status)},
+	}
+
+	if result.DetailError != "" {
+		rows = append(rows, []string{"Detail Error", result.DetailError})
+	}
+
+	if facts := result.Facts; facts != nil {
+		rows = append(
+			rows,
+			[]string{"Kernel", facts.Kernel},
+			[]string{"Architecture", facts.Arch},
+			[]string{"CPUs", strconv.Itoa(facts.CPUCount)},
+			[]string{"Memory", formatUsage(facts.MemoryUsed, facts.MemoryTotal)},
+			[]string{"Disk (/)", formatUsage(facts.DiskUsed, facts.DiskTotal)},
+			[]string{"Uptime", formatUptime(facts.Uptime)},
+			[]string{"Load Average", fmt.Sprintf(
+				"%.2f %.2f %.2f",
+				facts.LoadAverage[0],
+				facts.LoadAverage[1],
+				facts.LoadAverage[2],
+			)},
+		)
+	} else {
+		rows = append(rows, []string{"Facts", "not available"})
+	}
+
+	for rowIdx, row := range rows {
+		for col, text := range row {
+			cell := tview.NewTableCell(text)
+			cell.SetExpansion(1)
+			cell.SetAlign(tview.AlignLeft)
+			cell.SetTextColor(style.ColorWhite)
+
+			if col == 0 {
+				cell.SetTextColor(style.ColorOrange)
+			}
+
+			d.table.SetCell(rowIdx+2, col, cell)
+		}
+	}
+}
+
+// formats used and total bytes along with percentage used
+func formatUsage(used, total uint64) string {
+	if total == 0 {
+		return "unknown"
+	}
+
+	return fmt.Sprintf(
+		"%s / %s (%.0f%%)",
+		formatBytes(used),
+		formatBytes(total),
+		float64(used)/float64(total)*100,
+	)
+}
+
+// formats bytes using binary units
+func formatBytes(b uint64) string {
+	const unit = 1024
+
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+
+	div, exp := uint64(unit), 0
+
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+// formats uptime as days, hours, and minutes
+func formatUptime(d time.Duration) string {
+	if d == 0 {
+		return "unknown"
+	}
+
+	days := d / (time.Hour * 24)
+	hours := (d % (time.Hour * 24)) / time.Hour
+	minutes := (d % time.Hour) / time.Minute
+
+	return fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
+}
@@ -7,6 +7,9 @@ import (
 	"github.com/robgonnella/ops/internal/ui/style"
 )
 
+// MaxEvents maximum number of events displayed in the event table
+const MaxEvents = 50
+
 // EventTable table for viewing all incoming events in realtime
 type EventTable struct {
 	table         *tview.Table
@@ -32,7 +35,7 @@ func NewEventTable() *EventTable {
 		table:         createTable("events", columnHeaders),
 		columnHeaders: columnHeaders,
 		count:         0,
-		maxEvents:     50,
+		maxEvents:     MaxEvents,
 	}
 }
 
@@ -0,0 +1,225 @@
+package component
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/robgonnella/ops/internal/core"
+	"github.com/robgonnella/ops/internal/ui/key"
+	"github.com/robgonnella/ops/internal/ui/style"
+)
+
+// ExecPrompt form for entering a command to run on selected servers
+type ExecPrompt struct {
+	root *tview.Flex
+}
+
+// NewExecPrompt returns a new instance of ExecPrompt
+func NewExecPrompt(
+	hostCount int,
+	onSubmit func(command string),
+	onCancel func(),
+) *ExecPrompt {
+	input := tview.NewInputField()
+	input.SetLabel("Command: ")
+
+	form := tview.NewForm()
+	form.AddFormItem(input)
+
+	submit := func() {
+		if input.GetText() == "" {
+			return
+		}
+
+		onSubmit(input.GetText())
+	}
+
+	form.AddButton("Run", submit)
+	form.AddButton("Cancel", onCancel)
+
+	form.SetTitle(fmt.Sprintf("Run on %d server(s)", hostCount))
+	form.SetBorder(true)
+	form.SetBorderColor(style.ColorPurple)
+	form.SetFieldBackgroundColor(tcell.ColorDefault)
+	form.SetButtonBackgroundColor(style.ColorLightGreen)
+	form.SetLabelColor(style.ColorOrange)
+	form.SetButtonTextColor(style.ColorBlack)
+	form.SetButtonActivatedStyle(
+		style.StyleDefault.Background(style.ColorLightGreen),
+	)
+
+	form.SetCancelFunc(onCancel)
+
+	input.SetDoneFunc(func(k tcell.Key) {
+		if k == key.KeyEnter {
+			submit()
+		}
+	})
+
+	// center the form on screen
+	root := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(
+			tview.NewFlex().SetDirection(tview.FlexRow).
+				AddItem(nil, 0, 1, false).
+				AddItem(form, 7, 1, true).
+				AddItem(nil, 0, 1, false),
+			80,
+			1,
+			true,
+		).
+		AddItem(nil, 0, 1, false)
+
+	return &ExecPrompt{root: root}
+}
+
+// Primitive returns the root primitive for ExecPrompt
+func (p *ExecPrompt) Primitive() tview.Primitive {
+	return p.root
+}
+
+// ExecResults displays streamed output and the exit status of a command run
+// on one or more servers
+type ExecResults struct {
+	root          *tview.Flex
+	table         *tview.Table
+	output        *tview.TextView
+	columnHeaders []string
+}
+
+// NewExecResults returns a new instance of ExecResults
+func NewExecResults(onDismiss func()) *ExecResults {
+	columnHeaders := []string{"HOST", "IP", "STATUS", "EXIT CODE", "DURATION", "ERROR"}
+
+	table := createTable("results", columnHeaders)
+	table.SetSelectable(false, false)
+
+	output := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true)
+
+	output.SetBorder(true)
+	output.SetBorderPadding(0, 0, 1, 1)
+	output.SetTitle("output")
+	output.SetTitleColor(style.ColorLightGreen)
+
+	output.SetFocusFunc(func() {
+		output.SetBorderColor(style.ColorPurple)
+	})
+
+	output.SetBlurFunc(func() {
+		output.SetBorderColor(style.ColorDefault)
+	})
+
+	root := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, false).
+		AddItem(output, 0, 2, true)
+
+	root.SetInputCapture(func(evt *tcell.EventKey) *tcell.EventKey {
+		if evt.Key() == key.KeyEsc {
+			onDismiss()
+			return nil
+		}
+
+		return evt
+	})
+
+	return &ExecResults{
+		root:          root,
+		table:         table,
+		output:        output,
+		columnHeaders: columnHeaders,
+	}
+}
+
+// Primitive returns the root primitive for ExecResults
+func (r *ExecResults) Primitive() tview.Primitive {
+	return r.root
+}
+
+// Start clears previous results and marks each target as running
+func (r *ExecResults) Start(command string, targets []core.ExecTarget) {
+	r.output.Clear()
+	r.table.Clear()
+	setTableHeaders(r.table, r.columnHeaders)
+	r.table.SetTitle("results: " + command)
+
+	for rowIdx, target := range targets {
+		r.setRow(rowIdx, []string{
+			hostLabel(target.Hostname, target.IP),
+			target.IP,
+			"running",
+			"",
+			"",
+			"",
+		}, style.ColorOrange)
+	}
+}
+
+// AddOutput appends a single line of output prefixed with its server
+func (r *ExecResults) AddOutput(o core.ExecOutput) {
+	color := "green"
+
+	if o.Stream == core.ExecStderr {
+		color = "orange"
+	}
+
+	fmt.Fprintf(
+		r.output,
+		"[%s][%s][-] %s\n",
+		color,
+		tview.Escape(hostLabel(o.Target.Hostname, o.Target.IP)),
+		tview.Escape(o.Line),
+	)
+
+	r.output.ScrollToEnd()
+}
+
+// SetResults displays the exit status and duration for each server
+func (r *ExecResults) SetResults(results []core.ExecResult) {
+	for rowIdx, result := range results {
+		status := "success"
+		color := style.ColorMediumGreen
+
+		if result.Failed() {
+			status = "failed"
+			color = style.ColorRed
+		}
+
+		r.setRow(rowIdx, []string{
+			hostLabel(result.Hostname, result.IP),
+			result.IP,
+			status,
+			strconv.Itoa(result.ExitCode),
+			result.Duration.Round(time.Millisecond).String(),
+			result.Error,
+		}, color)
+	}
+}
+
+func (r *ExecResults) setRow(rowIdx int, row []string, statusColor tcell.Color) {
+	for col, text := range row {
+		cell := tview.NewTableCell(text)
+		cell.SetExpansion(1)
+		cell.SetAlign(tview.AlignLeft)
+		cell.SetTextColor(style.ColorWhite)
+
+		if col == 2 {
+			cell.SetTextColor(statusColor)
+		}
+
+		r.table.SetCell(rowIdx+2, col, cell)
+	}
+}
+
+// returns the hostname if known otherwise the ip
+func hostLabel(hostname, ip string) string {
+	if hostname == "" || hostname == "Unknown" {
+		return ip
+	}
+
+	return hostname
+}
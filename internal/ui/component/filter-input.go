@@ -0,0 +1,57 @@
+package component
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/robgonnella/ops/internal/ui/key"
+	"github.com/robgonnella/ops/internal/ui/style"
+)
+
+// FilterInput input for filtering the server table as the user types
+type FilterInput struct {
+	root *tview.InputField
+}
+
+// NewFilterInput returns a new instance of FilterInput. OnChange is called
+// with the filter text on every change and onDone when the user presses
+// enter to keep the filter or esc to clear it.
+func NewFilterInput(onChange func(text string), onDone func()) *FilterInput {
+	input := tview.NewInputField()
+	input.SetFieldStyle(style.StyleDefault.Dim(true))
+	input.SetBorderPadding(0, 0, 1, 1)
+	input.SetPlaceholderStyle(style.StyleDefault.Dim(true))
+	input.SetLabel("/")
+	input.SetLabelColor(style.ColorOrange)
+
+	// show when focused
+	input.SetFocusFunc(func() {
+		input.SetBorder(true)
+		input.SetBorderColor(style.ColorPurple)
+		input.SetPlaceholder(
+			"Filter servers: text or regex, status:online ssh:enabled os:ubuntu vendor: ip: port: - esc to clear",
+		)
+	})
+
+	// hide when blurred leaving the filter text in place
+	input.SetBlurFunc(func() {
+		input.SetBorder(false)
+		input.SetPlaceholder("")
+	})
+
+	input.SetChangedFunc(onChange)
+
+	input.SetDoneFunc(func(k tcell.Key) {
+		if k == key.KeyEsc {
+			input.SetText("")
+		}
+
+		onDone()
+	})
+
+	return &FilterInput{root: input}
+}
+
+// Primitive returns the root primitive for FilterInput
+func (i *FilterInput) Primitive() tview.Primitive {
+	return i.root
+}
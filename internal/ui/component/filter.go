@@ -0,0 +1,119 @@
+package component
+
+import (
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// columns searched by free text filter terms - hostname, ip, id (mac), os,
+// and vendor
+var filterTextColumns = []int{0, 1, 2, 3, 4}
+
+// maps structured filter keys to the server table column they match
+var filterKeyColumns = map[string]int{
+	"hostname": 0,
+	"host":     0,
+	"ip":       1,
+	"mac":      2,
+	"id":       2,
+	"os":       3,
+	"vendor":   4,
+	"ssh":      5,
+	"status":   6,
+	"port":     7,
+}
+
+// serverFilter filters server table rows. Each whitespace separated term
+// must match for a row to be shown. Terms in the form key:value match a
+// single column e.g. status:online, ssh:enabled, os:ubuntu, ip:10.0.1.0/24,
+// or port:5900, and all other terms match any of the hostname, ip, mac, os,
+// or vendor columns. Values are case-insensitive regular expressions, falling
+// back to plain substrings when they aren't valid expressions.
+type serverFilter struct {
+	text  string
+	terms []func(row []string) bool
+}
+
+// returns a filter for the given text - empty text matches all rows
+func newServerFilter(text string) serverFilter {
+	f := serverFilter{text: strings.TrimSpace(text)}
+
+	for _, term := range strings.Fields(f.text) {
+		f.terms = append(f.terms, parseFilterTerm(term))
+	}
+
+	return f
+}
+
+// returns true if the filter has any terms
+func (f serverFilter) active() bool {
+	return len(f.terms) > 0
+}
+
+// returns true if the row matches every term in the filter
+func (f serverFilter) matches(row []string) bool {
+	for _, term := range f.terms {
+		if !term(row) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// returns a matcher for a single filter term
+func parseFilterTerm(term string) func(row []string) bool {
+	key, value, ok := strings.Cut(term, ":")
+	col, known := filterKeyColumns[strings.ToLower(key)]
+
+	// terms like mac addresses contain colons but aren't structured
+	if !ok || !known || value == "" {
+		re := filterRegexp(term)
+
+		return func(row []string) bool {
+			return slices.ContainsFunc(filterTextColumns, func(c int) bool {
+				return re.MatchString(row[c])
+			})
+		}
+	}
+
+	switch col {
+	case 5, 6:
+		// ssh and status values start with enabled, disabled, online,
+		// offline, or stale
+		value = strings.ToLower(value)
+
+		return func(row []string) bool {
+			return strings.HasPrefix(row[col], value)
+		}
+	case 7:
+		return func(row []string) bool {
+			return slices.Contains(strings.Split(row[col], ","), value)
+		}
+	case 1:
+		if _, ipNet, err := net.ParseCIDR(value); err == nil {
+			return func(row []string) bool {
+				ip := net.ParseIP(row[col])
+				return ip != nil && ipNet.Contains(ip)
+			}
+		}
+	}
+
+	re := filterRegexp(value)
+
+	return func(row []string) bool {
+		return re.MatchString(row[col])
+	}
+}
+
+// compiles a case-insensitive expression for the given text, matching it
+// literally if it isn't a valid expression
+func filterRegexp(text string) *regexp.Regexp {
+	if re, err := regexp.Compile("(?i)" + text); err == nil {
+		return re
+	}
+
+	return regexp.MustCompile("(?i)" + regexp.QuoteMeta(text))
+}
@@ -0,0 +1,74 @@
+package component
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerFilter(t *testing.T) {
+	web := []string{
+		"web-01", "192.168.1.10", "aa:bb:cc:dd:ee:01", "Ubuntu 22.04",
+		"Dell Inc.", "enabled", "online", "22,80,443",
+	}
+
+	printer := []string{
+		"printer", "10.0.1.5", "aa:bb:cc:dd:ee:02", "Unknown",
+		"HP", "disabled", "offline", "9100",
+	}
+
+	nas := []string{
+		"nas", "10.0.2.7", "aa:bb:cc:dd:ee:03", "Linux",
+		"Synology", "enabled (auth failed)", "stale", "22,5000",
+	}
+
+	rows := [][]string{web, printer, nas}
+
+	tests := []struct {
+		name    string
+		text    string
+		matches [][]string
+	}{
+		{"matches all rows when empty", "", rows},
+		{"matches free text in any searched column", "dell", [][]string{web}},
+		{"matches free text regular expressions", "^(web|nas)", [][]string{web, nas}},
+		{"matches free text mac addresses", "aa:bb:cc:dd:ee:02", [][]string{printer}},
+		{"requires every term to match", "synology linux", [][]string{nas}},
+		{"matches hostname terms", "hostname:web", [][]string{web}},
+		{"matches host alias terms", "host:^nas$", [][]string{nas}},
+		{"matches ip cidr terms", "ip:10.0.0.0/16", [][]string{printer, nas}},
+		{"matches ip text terms", "ip:192.168", [][]string{web}},
+		{"matches mac terms", "mac:ee:03", [][]string{nas}},
+		{"matches id terms", "id:ee:01", [][]string{web}},
+		{"matches os terms", "os:ubuntu", [][]string{web}},
+		{"matches vendor terms", "vendor:synology", [][]string{nas}},
+		{"matches ssh terms by prefix", "ssh:enabled", [][]string{web, nas}},
+		{"matches status terms by prefix", "status:off", [][]string{printer}},
+		{"matches exact port terms", "port:22", [][]string{web, nas}},
+		{"does not match partial ports", "port:2", [][]string{}},
+		{"ignores case of terms", "STATUS:ONLINE OS:UBUNTU", [][]string{web}},
+		{"ignores case of free text", "SYNOLOGY", [][]string{nas}},
+		{"ignores case of keys", "Vendor:hp", [][]string{printer}},
+		{"treats unknown keys as free text", "rack:1", [][]string{}},
+		{"treats invalid expressions as text", "web-(", [][]string{}},
+		{"matches nothing when no row matches", "mainframe", [][]string{}},
+		{"matches nothing when a term does not match", "web status:offline", [][]string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(st *testing.T) {
+			filter := newServerFilter(test.text)
+
+			matched := [][]string{}
+
+			for _, row := range rows {
+				if filter.matches(row) {
+					matched = append(matched, row)
+				}
+			}
+
+			assert.Equal(st, test.matches, matched)
+			assert.Equal(st, test.text != "", filter.active())
+		})
+	}
+}
@@ -2,10 +2,13 @@ package component
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/rivo/tview"
 	"github.com/robgonnella/go-lanscan/pkg/network"
 	"github.com/robgonnella/ops/internal/config"
+	"github.com/robgonnella/ops/internal/discovery"
+	"github.com/robgonnella/ops/internal/event"
 	"github.com/robgonnella/ops/internal/ui/style"
 )
 
@@ -17,15 +20,23 @@ const appText = `
 ╚██████╔╝██║     ███████║
  ╚═════╝ ╚═╝     ╚══════╝`
 
+// number of rows available in each legend column
+const legendRows = 8
+
 // Header shown above all views. Includes app title and dynamic key legend
 type Header struct {
 	root            *tview.Flex
 	legendContainer *tview.Flex
 	legendCol1      *tview.Flex
 	legendCol2      *tview.Flex
+	legendCol3      *tview.Flex
+	inputs          *tview.Pages
 	switchViewInput *SwitchViewInput
+	filterInput     *FilterInput
 	currentContext  *tview.TextView
 	currentTarget   *tview.TextView
+	scanStatus      *tview.TextView
+	filterStatus    *tview.TextView
 	networkInfo     network.Network
 	conf            config.Config
 	extraLegendMap  map[string]tview.Primitive
@@ -36,6 +47,8 @@ func NewHeader(
 	networkInfo network.Network,
 	conf config.Config,
 	onViewSwitch func(text string),
+	onFilterChange func(text string),
+	onFilterDone func(),
 ) *Header {
 	h := &Header{}
 
@@ -50,6 +63,8 @@ func NewHeader(
 
 	h.legendCol2 = tview.NewFlex().SetDirection(tview.FlexRow)
 
+	h.legendCol3 = tview.NewFlex().SetDirection(tview.FlexRow)
+
 	title := tview.NewTextView().
 		SetText(appText).
 		SetTextColor(style.ColorPurple)
@@ -67,6 +82,7 @@ func NewHeader(
 
 	h.legendContainer.AddItem(h.legendCol1, 60, 1, false)
 	h.legendContainer.AddItem(h.legendCol2, 0, 1, false)
+	h.legendContainer.AddItem(h.legendCol3, 0, 1, false)
 
 	h.root.AddItem(h.legendContainer, 0, 1, false)
 
@@ -74,6 +90,13 @@ func NewHeader(
 
 	h.switchViewInput = switchViewInput
 
+	h.filterInput = NewFilterInput(onFilterChange, onFilterDone)
+
+	// the switch view and filter inputs share the same space
+	h.inputs = tview.NewPages()
+	h.inputs.AddPage("switch", h.switchViewInput.Primitive(), true, true)
+	h.inputs.AddPage("filter", h.filterInput.Primitive(), true, false)
+
 	h.currentContext = tview.NewTextView().
 		SetText(fmt.Sprintf("Context: %s", h.conf.Name))
 
@@ -93,11 +116,23 @@ func NewHeader(
 	h.currentTarget.SetTextColor(style.ColorLightGreen)
 	h.currentTarget.SetTextAlign(tview.AlignLeft)
 
+	h.scanStatus = tview.NewTextView().SetText("Scan: waiting for first scan")
+
+	h.scanStatus.SetTextColor(style.ColorLightGreen)
+	h.scanStatus.SetTextAlign(tview.AlignLeft)
+
+	h.filterStatus = tview.NewTextView()
+
+	h.filterStatus.SetTextColor(style.ColorOrange)
+	h.filterStatus.SetTextAlign(tview.AlignLeft)
+
 	h.root.AddItem(emptyText, 1, 1, false)
 	h.root.AddItem(h.currentContext, 1, 1, false)
 	h.root.AddItem(emptyText, 1, 1, false)
 	h.root.AddItem(h.currentTarget, 1, 1, false)
-	h.root.AddItem(h.switchViewInput.Primitive(), 3, 1, false)
+	h.root.AddItem(h.scanStatus, 1, 1, false)
+	h.root.AddItem(h.filterStatus, 1, 1, false)
+	h.root.AddItem(h.inputs, 3, 1, false)
 
 	h.extraLegendMap = map[string]tview.Primitive{}
 
@@ -126,6 +161,32 @@ func (h *Header) UpdateConfAndNetworkInfo(conf config.Config, netInfo network.Ne
 	)
 }
 
+// UpdateScanStatus updates scan progress from scan started and completed events
+func (h *Header) UpdateScanStatus(evt event.Event) {
+	cycle, ok := evt.Payload.(discovery.ScanCycle)
+
+	if !ok {
+		return
+	}
+
+	switch evt.Type {
+	case discovery.ScanStartedEvent:
+		h.scanStatus.SetText(
+			fmt.Sprintf("Scan: in progress - started %s", cycle.StartedAt.Format(time.TimeOnly)),
+		)
+	case discovery.ScanCompletedEvent:
+		h.scanStatus.SetText(
+			fmt.Sprintf(
+				"Scan: %d hosts found, %d offline in %s - completed %s",
+				cycle.HostsFound,
+				cycle.HostsOffline,
+				cycle.Duration.Round(time.Millisecond),
+				cycle.StartedAt.Add(cycle.Duration).Format(time.TimeOnly),
+			),
+		)
+	}
+}
+
 // AddLegendKey adds a new key and description to the legend
 func (h *Header) AddLegendKey(key, description string) {
 	v := tview.NewTextView().
@@ -135,7 +196,18 @@ func (h *Header) AddLegendKey(key, description string) {
 
 	h.extraLegendMap[key] = v
 
-	h.legendCol2.AddItem(v, 0, 1, false)
+	// overflow into the next column once the first is full
+	if h.legendCol2.GetItemCount() < legendRows {
+		h.legendCol2.AddItem(v, 0, 1, false)
+		return
+	}
+
+	if h.legendCol3.GetItemCount() == 0 {
+		// align with the first legend column
+		h.legendCol3.AddItem(tview.NewTextView(), 1, 1, false)
+	}
+
+	h.legendCol3.AddItem(v, 1, 1, false)
 }
 
 // RemoveLegendKey removes key and description from legend
@@ -143,6 +215,7 @@ func (h *Header) RemoveLegendKey(key string) {
 	for k, primitive := range h.extraLegendMap {
 		if k == key {
 			h.legendCol2.RemoveItem(primitive)
+			h.legendCol3.RemoveItem(primitive)
 			delete(h.extraLegendMap, key)
 		}
 	}
@@ -155,9 +228,41 @@ func (h *Header) RemoveAllExtraLegendKeys() {
 		h.legendCol2.RemoveItem(primitive)
 		delete(h.extraLegendMap, k)
 	}
+
+	h.legendCol3.Clear()
+}
+
+// UpdateFilterStatus displays the active server filter and how many
+// servers it matches
+func (h *Header) UpdateFilterStatus(filter string, shown, total int) {
+	if filter == "" {
+		h.filterStatus.SetText("")
+		return
+	}
+
+	h.filterStatus.SetText(
+		fmt.Sprintf("Filter: %s - showing %d of %d servers", filter, shown, total),
+	)
 }
 
 // SwitchViewInput returns access to the Header's SwitchViewInput component
 func (h *Header) SwitchViewInput() *SwitchViewInput {
 	return h.switchViewInput
 }
+
+// FilterInput returns access to the Header's FilterInput component
+func (h *Header) FilterInput() *FilterInput {
+	return h.filterInput
+}
+
+// ShowSwitchViewInput displays the switch view input in place of the
+// filter input
+func (h *Header) ShowSwitchViewInput() {
+	h.inputs.SwitchToPage("switch")
+}
+
+// ShowFilterInput displays the filter input in place of the switch view
+// input
+func (h *Header) ShowFilterInput() {
+	h.inputs.SwitchToPage("filter")
+}
@@ -0,0 +1,84 @@
+package component
+
+import (
+	"strings"
+
+	"github.com/rivo/tview"
+	"github.com/robgonnella/ops/internal/config"
+	"github.com/robgonnella/ops/internal/discovery"
+	"github.com/robgonnella/ops/internal/ui/style"
+)
+
+// SSHOverrides table displaying the ssh settings resolved for each server
+// and which overrides were applied
+type SSHOverrides struct {
+	table         *tview.Table
+	columnHeaders []string
+}
+
+// NewSSHOverrides returns a new instance of SSHOverrides
+func NewSSHOverrides() *SSHOverrides {
+	columnHeaders := []string{"HOSTNAME", "IP", "ID", "VENDOR", "USER", "IDENTITY", "PORT", "PROXY JUMP", "OVERRIDE"}
+
+	table := createTable("ssh overrides", columnHeaders)
+
+	return &SSHOverrides{
+		table:         table,
+		columnHeaders: columnHeaders,
+	}
+}
+
+// Primitive returns the root primitive for SSHOverrides
+func (o *SSHOverrides) Primitive() tview.Primitive {
+	return o.table
+}
+
+// SetResults displays the ssh settings the given config resolves to for
+// each server
+func (o *SSHOverrides) SetResults(conf config.Config, results []discovery.DiscoveryResult) {
+	o.table.Clear()
+	setTableHeaders(o.table, o.columnHeaders)
+
+	for rowIdx, result := range results {
+		creds := conf.SSHCredentials(result.SSHTarget())
+
+		sources := creds.Overrides
+
+		if creds.FromSSHConfig {
+			sources = append(sources, "~/.ssh/config")
+		}
+
+		override := strings.Join(sources, ", ")
+		color := style.ColorOrange
+
+		if override == "" {
+			override = "default"
+			color = style.ColorDimGrey
+		}
+
+		row := []string{
+			result.Hostname,
+			result.IP,
+			result.ID,
+			result.Vendor,
+			creds.User,
+			creds.Identity,
+			creds.Port,
+			creds.ProxyJump,
+			override,
+		}
+
+		for col, text := range row {
+			cell := tview.NewTableCell(text)
+			cell.SetExpansion(1)
+			cell.SetAlign(tview.AlignLeft)
+			cell.SetTextColor(style.ColorWhite)
+
+			if col == 8 {
+				cell.SetTextColor(color)
+			}
+
+			o.table.SetCell(rowIdx+2, col, cell)
+		}
+	}
+}
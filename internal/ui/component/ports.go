@@ -0,0 +1,133 @@
+package component
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/robgonnella/ops/internal/discovery"
+	"github.com/robgonnella/ops/internal/ui/key"
+	"github.com/robgonnella/ops/internal/ui/style"
+)
+
+// HostPorts table displaying the status of each scanned port for a single
+// server
+type HostPorts struct {
+	table         *tview.Table
+	columnHeaders []string
+	result        discovery.DiscoveryResult
+}
+
+// NewHostPorts returns a new instance of HostPorts
+func NewHostPorts(onDismiss func()) *HostPorts {
+	columnHeaders := []string{"PORT", "SERVICE", "STATUS", "BANNER"}
+
+	table := createTable("ports", columnHeaders)
+
+	table.SetInputCapture(func(evt *tcell.EventKey) *tcell.EventKey {
+		if evt.Key() == key.KeyEsc {
+			onDismiss()
+			return nil
+		}
+
+		return evt
+	})
+
+	return &HostPorts{
+		table:         table,
+		columnHeaders: columnHeaders,
+	}
+}
+
+// Primitive returns the root primitive for HostPorts
+func (p *HostPorts) Primitive() tview.Primitive {
+	return p.table
+}
+
+// ID returns the id of the server currently being displayed
+func (p *HostPorts) ID() string {
+	return p.result.ID
+}
+
+// SetResult displays the status of each scanned port for the given server
+func (p *HostPorts) SetResult(result discovery.DiscoveryResult, scanPorts []string) {
+	p.result = result
+
+	p.table.Clear()
+	setTableHeaders(p.table, p.columnHeaders)
+	p.table.SetTitle(result.Hostname + " ports")
+
+	ports := []uint16{}
+
+	for _, sp := range scanPorts {
+		if port, err := strconv.ParseUint(sp, 10, 16); err == nil {
+			ports = append(ports, uint16(port))
+		}
+	}
+
+	// include open ports that may no longer be configured for scanning
+	for _, port := range result.OpenPorts {
+		if !slices.Contains(ports, port) {
+			ports = append(ports, port)
+		}
+	}
+
+	slices.Sort(ports)
+
+	banners := map[uint16]string{}
+
+	for _, b := range result.Banners {
+		banners[b.Port] = formatBanner(b)
+	}
+
+	for rowIdx, port := range ports {
+		status := "closed"
+		color := style.ColorDimGrey
+
+		if slices.Contains(result.OpenPorts, port) {
+			status = "open"
+			color = style.ColorMediumGreen
+		}
+
+		row := []string{
+			strconv.Itoa(int(port)),
+			discovery.ServiceName(port),
+			status,
+			banners[port],
+		}
+
+		for col, text := range row {
+			cell := tview.NewTableCell(text)
+			cell.SetExpansion(1)
+			cell.SetAlign(tview.AlignLeft)
+			cell.SetTextColor(style.ColorWhite)
+
+			if col == 2 {
+				cell.SetTextColor(color)
+			}
+
+			p.table.SetCell(rowIdx+2, col, cell)
+		}
+	}
+}
+
+// formats a banner as a single line of text
+func formatBanner(b discovery.Banner) string {
+	parts := []string{}
+
+	if b.Product != "" {
+		parts = append(parts, b.Product)
+	}
+
+	if b.Title != "" {
+		parts = append(parts, "\""+b.Title+"\"")
+	}
+
+	if b.TLSSubject != "" {
+		parts = append(parts, "cert: "+b.TLSSubject)
+	}
+
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,133 @@
+package component
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/robgonnella/ops/internal/config"
+)
+
+// maps sort columns to the server table column displaying them
+var sortColumnIndexes = map[config.SortColumn]int{
+	config.SortHostname: 0,
+	config.SortIP:       1,
+	config.SortOS:       3,
+	config.SortVendor:   4,
+	config.SortLastSeen: 8,
+}
+
+// returns the sort column displayed in the given table column
+func sortColumnAt(col int) (config.SortColumn, bool) {
+	for column, idx := range sortColumnIndexes {
+		if idx == col {
+			return column, true
+		}
+	}
+
+	return config.SortDefault, false
+}
+
+// returns the next sort column after the given one, wrapping back to the
+// default sort
+func nextSortColumn(column config.SortColumn) config.SortColumn {
+	for i, c := range config.SortColumns {
+		if c == column {
+			return config.SortColumns[(i+1)%len(config.SortColumns)]
+		}
+	}
+
+	return config.SortDefault
+}
+
+// compares two rows by the user selected sort column. Rows missing a value
+// for the column are always sorted last and ties are sorted by ip so the
+// order is stable across updates.
+func (t *ServerTable) compareRows(r1, r2 []string) int {
+	missing1, missing2 := t.missingSortValue(r1), t.missingSortValue(r2)
+
+	if missing1 && !missing2 {
+		return 1
+	}
+
+	if !missing1 && missing2 {
+		return -1
+	}
+
+	if missing1 && missing2 {
+		return compareIPs(r1[1], r2[1])
+	}
+
+	result := 0
+
+	switch t.sort.Column {
+	case config.SortIP:
+		result = compareIPs(r1[1], r2[1])
+	case config.SortLastSeen:
+		result = t.compareLastSeen(r1, r2)
+	default:
+		col := sortColumnIndexes[t.sort.Column]
+		result = strings.Compare(strings.ToLower(r1[col]), strings.ToLower(r2[col]))
+	}
+
+	if t.sort.Descending {
+		result = -result
+	}
+
+	if result == 0 {
+		result = compareIPs(r1[1], r2[1])
+	}
+
+	return result
+}
+
+// returns true if the row has no value for the current sort column
+func (t *ServerTable) missingSortValue(row []string) bool {
+	switch t.sort.Column {
+	case config.SortIP:
+		return false
+	case config.SortLastSeen:
+		return row[6] != "online" && t.lastSeen[row[2]].IsZero()
+	default:
+		text := row[sortColumnIndexes[t.sort.Column]]
+		return text == "" || text == "Unknown"
+	}
+}
+
+// compares when two rows were last seen - online servers are considered
+// seen now so they don't reorder on every scan
+func (t *ServerTable) compareLastSeen(r1, r2 []string) int {
+	online1, online2 := r1[6] == "online", r2[6] == "online"
+
+	switch {
+	case online1 && online2:
+		return 0
+	case online1:
+		return 1
+	case online2:
+		return -1
+	default:
+		return t.lastSeen[r1[2]].Compare(t.lastSeen[r2[2]])
+	}
+}
+
+// returns the text displayed in the last seen column for a row
+func (t *ServerTable) lastSeenText(row []string) string {
+	if row[6] == "online" {
+		return "now"
+	}
+
+	seen := t.lastSeen[row[2]]
+
+	if seen.IsZero() {
+		return ""
+	}
+
+	return seen.Local().Format(time.DateTime)
+}
+
+// compares two ip addresses by their bytes
+func compareIPs(ip1, ip2 string) int {
+	return bytes.Compare(net.ParseIP(ip1), net.ParseIP(ip2))
+}
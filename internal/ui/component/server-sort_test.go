@@ -0,0 +1,162 @@
+package component
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/robgonnella/ops/internal/config"
+	"github.com/robgonnella/ops/internal/discovery"
+	"github.com/robgonnella/ops/internal/ui/key"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerSort(t *testing.T) {
+	row := func(hostname, ip, id, os, vendor, status string) []string {
+		return []string{hostname, ip, id, os, vendor, "enabled", status, "22"}
+	}
+
+	hostnames := func(rows [][]string) []string {
+		names := []string{}
+
+		for _, r := range rows {
+			names = append(names, r[0])
+		}
+
+		return names
+	}
+
+	now := time.Now()
+
+	rows := [][]string{
+		row("web", "192.168.1.100", "id-web", "Ubuntu", "Dell", "online"),
+		row("", "192.168.1.9", "id-empty", "", "", "offline"),
+		row("Alpha", "192.168.1.20", "id-alpha", "debian", "HP", "offline"),
+		row("Unknown", "10.0.0.5", "id-unknown", "Unknown", "Unknown", "stale"),
+		row("beta", "192.168.1.3", "id-beta", "Alpine", "apple", "online"),
+	}
+
+	lastSeen := map[string]time.Time{
+		"id-empty": now.Add(-time.Hour),
+		"id-alpha": now.Add(-time.Minute),
+	}
+
+	tests := []struct {
+		name     string
+		sort     config.ServerSort
+		expected []string
+	}{
+		{
+			name:     "sorts ips numerically",
+			sort:     config.ServerSort{Column: config.SortIP},
+			expected: []string{"Unknown", "beta", "", "Alpha", "web"},
+		},
+		{
+			name:     "sorts ips numerically descending",
+			sort:     config.ServerSort{Column: config.SortIP, Descending: true},
+			expected: []string{"web", "Alpha", "", "beta", "Unknown"},
+		},
+		{
+			name:     "sorts hostnames ignoring case with missing hostnames last",
+			sort:     config.ServerSort{Column: config.SortHostname},
+			expected: []string{"Alpha", "beta", "web", "Unknown", ""},
+		},
+		{
+			name:     "sorts hostnames descending with missing hostnames last",
+			sort:     config.ServerSort{Column: config.SortHostname, Descending: true},
+			expected: []string{"web", "beta", "Alpha", "Unknown", ""},
+		},
+		{
+			name:     "sorts vendors with missing vendors last",
+			sort:     config.ServerSort{Column: config.SortVendor},
+			expected: []string{"beta", "web", "Alpha", "Unknown", ""},
+		},
+		{
+			name:     "sorts os with missing os last",
+			sort:     config.ServerSort{Column: config.SortOS},
+			expected: []string{"beta", "Alpha", "web", "Unknown", ""},
+		},
+		{
+			name:     "sorts last seen with online servers newest",
+			sort:     config.ServerSort{Column: config.SortLastSeen},
+			expected: []string{"", "Alpha", "beta", "web", "Unknown"},
+		},
+		{
+			name:     "sorts last seen descending with never seen servers last",
+			sort:     config.ServerSort{Column: config.SortLastSeen, Descending: true},
+			expected: []string{"beta", "web", "Alpha", "", "Unknown"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(st *testing.T) {
+			table := &ServerTable{sort: test.sort, lastSeen: lastSeen}
+
+			sorted := slices.Clone(rows)
+			slices.SortStableFunc(sorted, table.compareRows)
+
+			assert.Equal(st, test.expected, hostnames(sorted))
+		})
+	}
+
+	t.Run("cycles sort columns back to default", func(st *testing.T) {
+		column := config.SortDefault
+
+		for _, expected := range append(slices.Clone(config.SortColumns[1:]), config.SortDefault) {
+			column = nextSortColumn(column)
+			assert.Equal(st, expected, column)
+		}
+	})
+
+	t.Run("reverses the selected sort column", func(st *testing.T) {
+		sorts := []config.ServerSort{}
+
+		table := newSortTestTable(func(sort config.ServerSort) {
+			sorts = append(sorts, sort)
+		})
+
+		table.SetSort(config.ServerSort{Column: config.SortHostname})
+		pressKey(table, key.Rune_O)
+
+		expected := config.ServerSort{Column: config.SortHostname, Descending: true}
+
+		assert.Equal(st, expected, table.Sort())
+		assert.Equal(st, []config.ServerSort{expected}, sorts)
+	})
+
+	t.Run("does not reverse the default sort", func(st *testing.T) {
+		sorts := []config.ServerSort{}
+
+		table := newSortTestTable(func(sort config.ServerSort) {
+			sorts = append(sorts, sort)
+		})
+
+		pressKey(table, key.Rune_O)
+
+		assert.Equal(st, config.ServerSort{}, table.Sort())
+		assert.Empty(st, sorts)
+	})
+}
+
+// returns a server table that only reports sort changes
+func newSortTestTable(onSort func(sort config.ServerSort)) *ServerTable {
+	return NewServerTable(
+		"host",
+		"127.0.0.1",
+		func(ip string) {},
+		func(result discovery.DiscoveryResult) {},
+		func(result discovery.DiscoveryResult) {},
+		func(ids []string) {},
+		func(ids []string) {},
+		func(key string, result discovery.DiscoveryResult) bool { return false },
+		func(result discovery.DiscoveryResult) {},
+		onSort,
+	)
+}
+
+// sends a key press to the server table
+func pressKey(table *ServerTable, r rune) {
+	capture := table.table.GetInputCapture()
+	capture(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone))
+}
@@ -4,12 +4,17 @@ import (
 	"bytes"
 	"net"
 	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
+	"github.com/robgonnella/ops/internal/config"
 	"github.com/robgonnella/ops/internal/discovery"
 	"github.com/robgonnella/ops/internal/event"
+	"github.com/robgonnella/ops/internal/inventory"
 	"github.com/robgonnella/ops/internal/ui/key"
 	"github.com/robgonnella/ops/internal/ui/style"
 )
@@ -21,15 +26,49 @@ type ServerTable struct {
 	hostIP        string
 	hostHostname  string
 	rows          [][]string
+	results       map[string]discovery.DiscoveryResult
+	stale         map[string]bool
+	keyChanges    map[string]discovery.HostKeyChange
+	selected      map[string]bool
+	lastSeen      map[string]time.Time
+	filter        serverFilter
+	sort          config.ServerSort
+	onSort        func(sort config.ServerSort)
 	mux           sync.RWMutex
 }
 
 // NewServerTable returns a new instance of ServerTable
-func NewServerTable(hostHostname, hostIP string, OnSSH func(ip string)) *ServerTable {
-	columnHeaders := []string{"HOSTNAME", "IP", "ID", "OS", "VENDOR", "SSH", "STATUS"}
+func NewServerTable(
+	hostHostname,
+	hostIP string,
+	OnSSH func(ip string),
+	OnDetails func(result discovery.DiscoveryResult),
+	OnPorts func(result discovery.DiscoveryResult),
+	OnExec func(ids []string),
+	OnTransfer func(ids []string),
+	OnAction func(key string, result discovery.DiscoveryResult) bool,
+	OnHighlight func(result discovery.DiscoveryResult),
+	OnSort func(sort config.ServerSort),
+) *ServerTable {
+	columnHeaders := []string{"HOSTNAME", "IP", "ID", "OS", "VENDOR", "SSH", "STATUS", "PORTS", "LAST SEEN"}
 
 	table := createTable("servers", columnHeaders)
 
+	t := &ServerTable{
+		table:         table,
+		columnHeaders: columnHeaders,
+		hostIP:        hostIP,
+		hostHostname:  hostHostname,
+		rows:          [][]string{},
+		results:       map[string]discovery.DiscoveryResult{},
+		stale:         map[string]bool{},
+		keyChanges:    map[string]discovery.HostKeyChange{},
+		selected:      map[string]bool{},
+		lastSeen:      map[string]time.Time{},
+		onSort:        OnSort,
+		mux:           sync.RWMutex{},
+	}
+
 	table.SetInputCapture(func(evt *tcell.EventKey) *tcell.EventKey {
 		if evt.Rune() == key.Rune_s {
 			row, _ := table.GetSelection()
@@ -38,17 +77,90 @@ func NewServerTable(hostHostname, hostIP string, OnSSH func(ip string)) *ServerT
 			return nil
 		}
 
+		if evt.Rune() == key.Rune_p {
+			row, _ := table.GetSelection()
+			id := table.GetCell(row, 2).Text
+
+			if result, ok := t.Result(id); ok {
+				OnPorts(result)
+			}
+
+			return nil
+		}
+
+		if evt.Rune() == key.RuneSpace {
+			row, _ := table.GetSelection()
+			id := table.GetCell(row, 2).Text
+
+			if id != "" {
+				t.ToggleSelected(id)
+			}
+
+			return nil
+		}
+
+		if evt.Rune() == key.Rune_x {
+			if ids := t.actionIDs(); len(ids) > 0 {
+				OnExec(ids)
+			}
+
+			return nil
+		}
+
+		if evt.Rune() == key.Rune_f {
+			if ids := t.actionIDs(); len(ids) > 0 {
+				OnTransfer(ids)
+			}
+
+			return nil
+		}
+
+		if evt.Rune() == key.Rune_o {
+			t.changeSort(config.ServerSort{Column: nextSortColumn(t.Sort().Column)})
+			return nil
+		}
+
+		if evt.Rune() == key.Rune_O {
+			sort := t.Sort()
+
+			// the default sort has no direction to reverse
+			if sort.Column == config.SortDefault {
+				return nil
+			}
+
+			sort.Descending = !sort.Descending
+			t.changeSort(sort)
+			return nil
+		}
+
+		if evt.Key() == key.KeyEnter {
+			row, _ := table.GetSelection()
+			id := table.GetCell(row, 2).Text
+
+			if result, ok := t.Result(id); ok {
+				OnDetails(result)
+			}
+
+			return nil
+		}
+
+		if evt.Key() == tcell.KeyRune {
+			// custom actions configured by the user
+			if result, ok := t.Highlighted(); ok && OnAction(string(evt.Rune()), result) {
+				return nil
+			}
+		}
+
 		return evt
 	})
 
-	return &ServerTable{
-		table:         table,
-		columnHeaders: columnHeaders,
-		hostIP:        hostIP,
-		hostHostname:  hostHostname,
-		rows:          [][]string{},
-		mux:           sync.RWMutex{},
-	}
+	table.SetSelectionChangedFunc(func(row, column int) {
+		if result, ok := t.Result(table.GetCell(row, 2).Text); ok {
+			OnHighlight(result)
+		}
+	})
+
+	return t
 }
 
 // Primitive returns the root primitive for ServerTable
@@ -56,68 +168,350 @@ func (t *ServerTable) Primitive() tview.Primitive {
 	return t.table
 }
 
-// UpdateTable updates the table with the incoming server from the event
-func (t *ServerTable) UpdateTable(evt event.Event) {
-	payload, ok := evt.Payload.(discovery.DiscoveryResult)
+// Result returns the latest known result for the given server id
+func (t *ServerTable) Result(id string) (discovery.DiscoveryResult, bool) {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
 
-	if !ok {
-		return
+	result, ok := t.results[id]
+
+	return result, ok
+}
+
+// Highlighted returns the latest known result for the highlighted server
+func (t *ServerTable) Highlighted() (discovery.DiscoveryResult, bool) {
+	row, _ := t.table.GetSelection()
+	return t.Result(t.table.GetCell(row, 2).Text)
+}
+
+// Results returns the latest known result for each server in table order
+func (t *ServerTable) Results() []discovery.DiscoveryResult {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+
+	results := []discovery.DiscoveryResult{}
+
+	for _, row := range t.rows {
+		if result, ok := t.results[row[2]]; ok {
+			results = append(results, result)
+		}
 	}
 
-	status := "offline"
+	return results
+}
 
-	if payload.Status == discovery.ServerOnline {
-		status = "online"
+// HostKeyChange returns the unacknowledged ssh host key change for the
+// server with the given ip if there is one
+func (t *ServerTable) HostKeyChange(ip string) (discovery.HostKeyChange, bool) {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+
+	for id, result := range t.results {
+		if result.IP != ip {
+			continue
+		}
+
+		if change, ok := t.keyChanges[id]; ok {
+			return change, true
+		}
 	}
 
-	ssh := "disabled"
+	return discovery.HostKeyChange{}, false
+}
 
-	if payload.Port.Status == discovery.PortOpen {
-		ssh = "enabled"
+// ClearHostKeyChange removes the host key warning for the given server
+func (t *ServerTable) ClearHostKeyChange(id string) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	delete(t.keyChanges, id)
+	t.render()
+}
+
+// ToggleSelected adds or removes the given server from the multi-selection
+func (t *ServerTable) ToggleSelected(id string) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	if t.selected[id] {
+		delete(t.selected, id)
+	} else {
+		t.selected[id] = true
 	}
 
-	hostname := payload.Hostname
-	id := payload.ID
-	ip := payload.IP
-	os := payload.OS
-	vendor := payload.Vendor
+	t.render()
+}
+
+// Selected returns the ids of all selected servers shown by the current
+// filter in table order
+func (t *ServerTable) Selected() []string {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+
+	ids := []string{}
+
+	for _, row := range t.rows {
+		if t.selected[row[2]] && t.filter.matches(row) {
+			ids = append(ids, row[2])
+		}
+	}
+
+	return ids
+}
+
+// SetFilter shows only the servers matching the given filter text. See
+// serverFilter for the supported syntax. Empty text shows all servers.
+func (t *ServerTable) SetFilter(text string) {
+	t.mux.Lock()
+	t.filter = newServerFilter(text)
+	t.render()
+	t.mux.Unlock()
+
+	// highlight the first match - done after unlocking as selection
+	// handlers read results
+	t.table.ScrollToBeginning()
+	t.table.Select(2, 0)
+}
+
+// Filter returns the active filter text
+func (t *ServerTable) Filter() string {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+
+	return t.filter.text
+}
+
+// Count returns the number of servers shown by the current filter and the
+// total number of servers
+func (t *ServerTable) Count() (int, int) {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+
+	shown := 0
+
+	for _, row := range t.rows {
+		if t.filter.matches(row) {
+			shown++
+		}
+	}
+
+	return shown, len(t.rows)
+}
+
+// SetSort sorts servers by the given column and direction
+func (t *ServerTable) SetSort(sort config.ServerSort) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.sort = sort
+	t.sortRows(true)
+	t.render()
+}
+
+// Sort returns the current sort column and direction
+func (t *ServerTable) Sort() config.ServerSort {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+
+	return t.sort
+}
+
+// sorts by the given column and direction at the user's request
+func (t *ServerTable) changeSort(sort config.ServerSort) {
+	t.SetSort(sort)
+	t.onSort(sort)
+}
+
+// sorts by the clicked column, reversing the order if it is already sorted
+// by that column
+func (t *ServerTable) onHeaderClick(column config.SortColumn) {
+	sort := t.Sort()
+
+	if sort.Column == column {
+		sort.Descending = !sort.Descending
+	} else {
+		sort = config.ServerSort{Column: column}
+	}
+
+	t.changeSort(sort)
+}
+
+// returns the selected servers or the highlighted server if none are
+// selected
+func (t *ServerTable) actionIDs() []string {
+	if ids := t.Selected(); len(ids) > 0 {
+		return ids
+	}
+
+	row, _ := t.table.GetSelection()
+
+	if id := t.table.GetCell(row, 2).Text; id != "" {
+		return []string{id}
+	}
+
+	return nil
+}
+
+// ClearSelected removes all servers from the multi-selection
+func (t *ServerTable) ClearSelected() {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.selected = map[string]bool{}
+	t.render()
+}
+
+// LoadInventory replaces all rows with the given previously discovered
+// hosts. Each host is marked stale until it is re-confirmed by a scan.
+func (t *ServerTable) LoadInventory(hosts []*inventory.Host) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.rows = [][]string{}
+	t.results = map[string]discovery.DiscoveryResult{}
+	t.stale = map[string]bool{}
+	t.keyChanges = map[string]discovery.HostKeyChange{}
+	t.selected = map[string]bool{}
+	t.lastSeen = map[string]time.Time{}
+
+	for _, h := range hosts {
+		t.lastSeen[h.ID] = h.LastSeen
+
+		if change, ok := h.HostKeyChange(); ok {
+			t.keyChanges[h.ID] = change
+		}
+
+		t.results[h.ID] = h.Result()
+		row := resultToRow(h.Result())
+		row[6] = "stale"
+		t.rows = append(t.rows, row)
+		t.stale[h.ID] = true
+	}
+
+	t.sortRows(true)
+	t.render()
+}
+
+// UpdateTable updates the table with the incoming server from the event
+func (t *ServerTable) UpdateTable(evt event.Event) {
+	if change, ok := evt.Payload.(discovery.HostKeyChange); ok {
+		t.mux.Lock()
+		defer t.mux.Unlock()
+
+		t.keyChanges[change.ID] = change
+		t.render()
+		return
+	}
+
+	payload, ok := evt.Payload.(discovery.DiscoveryResult)
 
-	row := []string{hostname, ip, id, os, vendor, ssh, status}
+	if !ok {
+		return
+	}
+
+	row := resultToRow(payload)
+	id := payload.ID
 
 	t.mux.Lock()
 	defer t.mux.Unlock()
 
+	t.results[id] = mergeResult(t.results[id], payload)
+
+	if payload.Status == discovery.ServerOnline {
+		t.lastSeen[id] = time.Now()
+	}
+
 	idx := slices.IndexFunc(t.rows, func(r []string) bool {
 		return r[2] == id
 	})
 
 	exists := idx > -1
+
+	if evt.Type == discovery.HostnameResolvedEvent {
+		// only fill in hostnames we don't already know
+		if exists && (t.rows[idx][0] == "" || t.rows[idx][0] == "Unknown") {
+			t.rows[idx][0] = row[0]
+			t.resortRows()
+			t.render()
+		}
+		return
+	}
+
+	if evt.Type == discovery.PortUpdateEvent {
+		// only the open ports change for these events
+		if exists {
+			t.rows[idx][7] = row[7]
+			t.render()
+		}
+		return
+	}
+
+	if evt.Type == discovery.HostOfflineEvent || evt.Type == discovery.HostReturnedEvent {
+		if !exists {
+			return
+		}
+
+		// only the status changes for these events
+		t.rows[idx][6] = row[6]
+		delete(t.stale, id)
+		t.resortRows()
+		t.render()
+		return
+	}
+
+	isStale := t.stale[id]
 	isARP := evt.Type == discovery.ArpUpdateEvent
 	isSYN := evt.Type == discovery.SynUpdateEvent
 
-	if exists && isARP {
+	if exists && isARP && !isStale {
 		// we already have this entry no need to do anything else
 		return
 	}
 
 	if !exists && isARP {
 		t.rows = append(t.rows, row)
+	} else if exists && isARP {
+		// re-confirmed stale entry - keep previously known details until
+		// the syn result comes in
+		r := t.rows[idx]
+		row[0] = r[0]
+		row[3] = r[3]
+		row[5] = r[5]
+		row[7] = r[7]
+		t.rows[idx] = row
+		delete(t.stale, id)
 	} else if exists && isSYN {
 		r := t.rows[idx]
 		// keep previous vendor as syn results don't have vendor
 		row[4] = r[4]
 		t.rows[idx] = row
+		delete(t.stale, id)
 	} else {
 		// this should never happen
 		return
 	}
 
+	t.sortRows(isSYN)
+	t.render()
+}
+
+// sorts rows by the user selected column, or by ssh enabled first (if
+// requested) and then by ip when using the default sort
+func (t *ServerTable) sortRows(sshFirst bool) {
+	if t.sort.Column != config.SortDefault {
+		slices.SortStableFunc(t.rows, t.compareRows)
+		return
+	}
+
 	slices.SortFunc(t.rows, func(r1, r2 []string) int {
-		if isSYN && r1[5] == "enabled" && r2[5] == "disabled" {
+		ssh1 := strings.HasPrefix(r1[5], "enabled")
+		ssh2 := strings.HasPrefix(r2[5], "enabled")
+
+		if sshFirst && ssh1 && !ssh2 {
 			return -1
 		}
 
-		if isSYN && r1[5] == "disabled" && r2[5] == "enabled" {
+		if sshFirst && !ssh1 && ssh2 {
 			return 1
 		}
 
@@ -126,12 +520,41 @@ func (t *ServerTable) UpdateTable(evt event.Event) {
 
 		return bytes.Compare(ip1, ip2)
 	})
+}
+
+// re-sorts rows after a partial update if the user selected a sort column.
+// The default sort is only applied for new and fully updated servers.
+func (t *ServerTable) resortRows() {
+	if t.sort.Column != config.SortDefault {
+		t.sortRows(false)
+	}
+}
 
+// clears and redraws all rows in the table
+func (t *ServerTable) render() {
 	t.table.Clear()
 	setTableHeaders(t.table, t.columnHeaders)
+	t.renderSortHeaders()
+
+	rowIdx := 0
+
+	for _, row := range t.rows {
+		if !t.filter.matches(row) {
+			continue
+		}
+
+		_, keyChanged := t.keyChanges[row[2]]
+		selected := t.selected[row[2]]
+
+		for col, text := range append(slices.Clone(row), t.lastSeenText(row)) {
+			if keyChanged && col == 5 {
+				text = "HOST KEY CHANGED"
+			}
+
+			if selected && col == 0 {
+				text = "* " + text
+			}
 
-	for rowIdx, row := range t.rows {
-		for col, text := range row {
 			cell := tview.NewTableCell(text)
 			cell.SetExpansion(1)
 			cell.SetAlign(tview.AlignLeft)
@@ -145,8 +568,136 @@ func (t *ServerTable) UpdateTable(evt event.Event) {
 				color = style.ColorDimGrey
 			}
 
+			if text == "stale" || strings.HasPrefix(text, "enabled (") {
+				color = style.ColorOrange
+			}
+
+			if selected {
+				color = style.ColorPurple
+			}
+
+			if keyChanged {
+				color = style.ColorRed
+			}
+
 			cell.SetTextColor(color)
 			t.table.SetCell(rowIdx+2, col, cell)
 		}
+
+		rowIdx++
+	}
+}
+
+// marks the sorted column header with the sort direction and makes sortable
+// headers clickable
+func (t *ServerTable) renderSortHeaders() {
+	for col, header := range t.columnHeaders {
+		column, ok := sortColumnAt(col)
+
+		if !ok {
+			continue
+		}
+
+		cell := t.table.GetCell(0, col)
+
+		if column == t.sort.Column && t.sort.Descending {
+			cell.SetText(header + " ▼")
+		} else if column == t.sort.Column {
+			cell.SetText(header + " ▲")
+		}
+
+		cell.SetClickedFunc(func() bool {
+			t.onHeaderClick(column)
+			return true
+		})
+	}
+}
+
+// merges an incoming result into the previously known result for a server
+// so partial results (arp, status changes) don't erase gathered details
+func mergeResult(prev, next discovery.DiscoveryResult) discovery.DiscoveryResult {
+	if prev.ID == "" {
+		return next
+	}
+
+	if next.Type == discovery.SynUpdateEvent {
+		merged := next
+
+		// syn results don't have vendor and may have failed to gather
+		// hostname or facts
+		if merged.Vendor == "" {
+			merged.Vendor = prev.Vendor
+		}
+
+		if merged.Hostname == "" || merged.Hostname == "Unknown" {
+			merged.Hostname = prev.Hostname
+			merged.HostnameSource = prev.HostnameSource
+		}
+
+		if merged.Facts == nil {
+			merged.Facts = prev.Facts
+		}
+
+		return merged
+	}
+
+	merged := prev
+	merged.IP = next.IP
+	merged.Status = next.Status
+
+	if next.Type == discovery.HostnameResolvedEvent &&
+		(prev.Hostname == "" || prev.Hostname == "Unknown") {
+		merged.Hostname = next.Hostname
+		merged.HostnameSource = next.HostnameSource
+	}
+
+	if next.Vendor != "" {
+		merged.Vendor = next.Vendor
+	}
+
+	if next.OpenPorts != nil {
+		merged.OpenPorts = next.OpenPorts
+	}
+
+	if next.Banners != nil {
+		merged.Banners = next.Banners
+	}
+
+	return merged
+}
+
+// converts a discovery result into a table row
+func resultToRow(result discovery.DiscoveryResult) []string {
+	status := "offline"
+
+	if result.Status == discovery.ServerOnline {
+		status = "online"
+	}
+
+	ssh := "disabled"
+
+	if result.Port.Status == discovery.PortOpen {
+		ssh = "enabled"
+
+		if result.DetailError != "" {
+			ssh = "enabled (" + result.DetailError + ")"
+		}
+	}
+
+	ports := make([]string, 0, len(result.OpenPorts))
+
+	for _, p := range result.OpenPorts {
+		ports = append(ports, strconv.Itoa(int(p)))
+	}
+
+	return []string{
+		result.Hostname,
+		result.IP,
+		result.ID,
+		result.OS,
+		result.Vendor,
+		ssh,
+		status,
+		strings.Join(ports, ","),
 	}
 }
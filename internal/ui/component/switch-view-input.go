@@ -27,7 +27,7 @@ func NewSwitchViewInput(onSubmit func(text string)) *SwitchViewInput {
 		input.SetBorder(true)
 		input.SetBorderColor(style.ColorPurple)
 		input.SetPlaceholder(
-			"Enter view: servers, events, context, configure - type q | quit to quit",
+			"Enter view: servers, events, context, configure, overrides - type q | quit to quit",
 		)
 	})
 
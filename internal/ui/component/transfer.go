@@ -0,0 +1,224 @@
+package component
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/robgonnella/ops/internal/core"
+	"github.com/robgonnella/ops/internal/ui/key"
+	"github.com/robgonnella/ops/internal/ui/style"
+)
+
+// TransferForm form for choosing a file to upload to or download from
+// selected servers
+type TransferForm struct {
+	root *tview.Flex
+}
+
+// NewTransferForm returns a new instance of TransferForm
+func NewTransferForm(
+	hostCount int,
+	onSubmit func(req core.TransferRequest),
+	onCancel func(),
+) *TransferForm {
+	directions := []core.TransferDirection{core.TransferUpload, core.TransferDownload}
+
+	direction := tview.NewDropDown()
+	direction.SetLabel("Direction: ")
+	direction.SetOptions([]string{"upload", "download"}, nil)
+	direction.SetCurrentOption(0)
+
+	localPath := tview.NewInputField()
+	localPath.SetLabel("Local Path: ")
+
+	remotePath := tview.NewInputField()
+	remotePath.SetLabel("Remote Path: ")
+
+	form := tview.NewForm()
+	form.AddFormItem(direction)
+	form.AddFormItem(localPath)
+	form.AddFormItem(remotePath)
+
+	form.AddButton("Start", func() {
+		if localPath.GetText() == "" || remotePath.GetText() == "" {
+			return
+		}
+
+		idx, _ := direction.GetCurrentOption()
+
+		onSubmit(core.TransferRequest{
+			Direction:  directions[idx],
+			LocalPath:  localPath.GetText(),
+			RemotePath: remotePath.GetText(),
+		})
+	})
+
+	form.AddButton("Cancel", onCancel)
+
+	form.SetTitle(fmt.Sprintf("Transfer file for %d server(s)", hostCount))
+	form.SetBorder(true)
+	form.SetBorderColor(style.ColorPurple)
+	form.SetFieldBackgroundColor(tcell.ColorDefault)
+	form.SetButtonBackgroundColor(style.ColorLightGreen)
+	form.SetLabelColor(style.ColorOrange)
+	form.SetButtonTextColor(style.ColorBlack)
+	form.SetButtonActivatedStyle(
+		style.StyleDefault.Background(style.ColorLightGreen),
+	)
+
+	form.SetCancelFunc(onCancel)
+
+	// center the form on screen
+	root := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(
+			tview.NewFlex().SetDirection(tview.FlexRow).
+				AddItem(nil, 0, 1, false).
+				AddItem(form, 11, 1, true).
+				AddItem(nil, 0, 1, false),
+			80,
+			1,
+			true,
+		).
+		AddItem(nil, 0, 1, false)
+
+	return &TransferForm{root: root}
+}
+
+// Primitive returns the root primitive for TransferForm
+func (f *TransferForm) Primitive() tview.Primitive {
+	return f.root
+}
+
+// TransferResults displays the progress and result of copying a file to or
+// from one or more servers
+type TransferResults struct {
+	table         *tview.Table
+	columnHeaders []string
+	rows          map[string]int
+}
+
+// NewTransferResults returns a new instance of TransferResults
+func NewTransferResults(onDismiss func()) *TransferResults {
+	columnHeaders := []string{"HOST", "IP", "STATUS", "PROGRESS", "BYTES", "DURATION", "DESTINATION", "ERROR"}
+
+	table := createTable("transfers", columnHeaders)
+
+	table.SetInputCapture(func(evt *tcell.EventKey) *tcell.EventKey {
+		if evt.Key() == key.KeyEsc {
+			onDismiss()
+			return nil
+		}
+
+		return evt
+	})
+
+	return &TransferResults{
+		table:         table,
+		columnHeaders: columnHeaders,
+		rows:          map[string]int{},
+	}
+}
+
+// Primitive returns the root primitive for TransferResults
+func (r *TransferResults) Primitive() tview.Primitive {
+	return r.table
+}
+
+// Start clears previous results and marks each target as pending
+func (r *TransferResults) Start(req core.TransferRequest, targets []core.ExecTarget) {
+	r.rows = map[string]int{}
+
+	r.table.Clear()
+	setTableHeaders(r.table, r.columnHeaders)
+
+	if req.Direction == core.TransferDownload {
+		r.table.SetTitle("download: " + req.RemotePath + " -> " + req.LocalPath)
+	} else {
+		r.table.SetTitle("upload: " + req.LocalPath + " -> " + req.RemotePath)
+	}
+
+	for rowIdx, target := range targets {
+		r.rows[target.ID] = rowIdx
+		r.setRow(rowIdx, []string{
+			hostLabel(target.Hostname, target.IP),
+			target.IP,
+			"pending",
+			"0%",
+			"",
+			"",
+			"",
+			"",
+		}, style.ColorDimGrey)
+	}
+}
+
+// SetProgress updates the progress for a single server
+func (r *TransferResults) SetProgress(p core.TransferProgress) {
+	rowIdx, ok := r.rows[p.Target.ID]
+
+	if !ok {
+		return
+	}
+
+	r.setRow(rowIdx, []string{
+		hostLabel(p.Target.Hostname, p.Target.IP),
+		p.Target.IP,
+		"copying",
+		strconv.Itoa(p.Percent()) + "%",
+		formatBytes(uint64(p.Transferred)),
+		"",
+		"",
+		"",
+	}, style.ColorOrange)
+}
+
+// SetResults displays the result of the transfer for each server
+func (r *TransferResults) SetResults(results []core.TransferResult) {
+	for _, result := range results {
+		rowIdx, ok := r.rows[result.ID]
+
+		if !ok {
+			continue
+		}
+
+		status := "done"
+		progress := "100%"
+		color := style.ColorMediumGreen
+
+		if result.Failed() {
+			status = "failed"
+			progress = ""
+			color = style.ColorRed
+		}
+
+		r.setRow(rowIdx, []string{
+			hostLabel(result.Hostname, result.IP),
+			result.IP,
+			status,
+			progress,
+			formatBytes(uint64(result.Bytes)),
+			result.Duration.Round(time.Millisecond).String(),
+			result.Destination,
+			result.Error,
+		}, color)
+	}
+}
+
+func (r *TransferResults) setRow(rowIdx int, row []string, statusColor tcell.Color) {
+	for col, text := range row {
+		cell := tview.NewTableCell(text)
+		cell.SetExpansion(1)
+		cell.SetAlign(tview.AlignLeft)
+		cell.SetTextColor(style.ColorWhite)
+
+		if col == 2 {
+			cell.SetTextColor(statusColor)
+		}
+
+		r.table.SetCell(rowIdx+2, col, cell)
+	}
+}
@@ -13,6 +13,20 @@ const (
 	Rune_s = 's'
 	// Rune_d d key as Rune
 	Rune_d = 'd'
+	// Rune_p p key as Rune
+	Rune_p = 'p'
+	// Rune_f f key as Rune
+	Rune_f = 'f'
+	// Rune_o o key as Rune
+	Rune_o = 'o'
+	// Rune_O O key as Rune
+	Rune_O = 'O'
+	// Rune_x x key as Rune
+	Rune_x = 'x'
+	// RuneSlash slash key as Rune
+	RuneSlash = '/'
+	// RuneSpace space key as Rune
+	RuneSpace = ' '
 )
 
 const (
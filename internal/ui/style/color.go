@@ -25,6 +25,8 @@ const (
 	ColorOrange = tcell.ColorOrange
 	// ColorDimGrey represents the color dim grey
 	ColorDimGrey = tcell.ColorDimGrey
+	// ColorRed represents the color red
+	ColorRed = tcell.ColorRed
 )
 
 var (
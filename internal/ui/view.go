@@ -1,33 +1,31 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"reflect"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
-	"github.com/robgonnella/go-lanscan/pkg/network"
 	"github.com/robgonnella/ops/internal/config"
 	"github.com/robgonnella/ops/internal/core"
 	"github.com/robgonnella/ops/internal/discovery"
 	"github.com/robgonnella/ops/internal/event"
+	"github.com/robgonnella/ops/internal/launcher"
 	"github.com/robgonnella/ops/internal/logger"
 	"github.com/robgonnella/ops/internal/ui/component"
 	"github.com/robgonnella/ops/internal/ui/key"
 )
 
-// viewOption provides a way to modify our view during initialization
-// this is helpful when restarting the view and focusing a specific page
-type viewOption func(v *view)
-
-func withShowError(msg string) viewOption {
-	return func(v *view) {
-		v.showErrorModal(msg)
-	}
-}
+// how long to wait after the last sort change before saving it so cycling
+// through sort columns doesn't write the config on every key press
+const sortSaveDelay = 500 * time.Millisecond
 
 // data structure for managing our entire terminal ui application
 type view struct {
@@ -36,6 +34,14 @@ type view struct {
 	pages                  *tview.Pages
 	header                 *component.Header
 	serverTable            *component.ServerTable
+	hostDetails            *component.HostDetails
+	hostPorts              *component.HostPorts
+	sshOverrides           *component.SSHOverrides
+	execResults            *component.ExecResults
+	transferResults        *component.TransferResults
+	cancelExec             context.CancelFunc
+	cancelTransfer         context.CancelFunc
+	actionRuns             int
 	eventTable             *component.EventTable
 	configureForm          *component.ConfigureForm
 	contextTable           *component.ConfigContext
@@ -44,12 +50,22 @@ type view struct {
 	eventManager           event.Manager
 	eventUpdateChan        chan event.Event
 	serverUpdateChan       chan event.Event
+	scanUpdateChan         chan event.Event
 	errorListener          chan event.Event
 	eventListenerIDs       []int
 	prevFocusedName        string
 	focusedName            string
 	viewNames              []string
 	showingSwitchViewInput bool
+	showingFilterInput     bool
+	actionLegendKeys       []string
+	suspended              bool
+	pendingUpdates         map[string]func()
+	pendingOrder           []string
+	pendingEvents          int
+	pendingSort            *config.ServerSort
+	sortTimer              *time.Timer
+	mux                    sync.Mutex
 	log                    logger.Logger
 }
 
@@ -69,13 +85,10 @@ func newView(allConfigs []*config.Config, appCore *core.Core, eventManager event
 }
 
 // initializes the terminal ui application
-func (v *view) initialize(
-	allConfigs []*config.Config,
-	options ...viewOption,
-) {
+func (v *view) initialize(allConfigs []*config.Config) {
 	netInfo := v.appCore.NetworkInfo()
 
-	v.viewNames = []string{"servers", "events", "context", "configure"}
+	v.viewNames = []string{"servers", "events", "context", "configure", "overrides"}
 	v.showingSwitchViewInput = false
 
 	v.app = tview.NewApplication()
@@ -87,12 +100,28 @@ func (v *view) initialize(
 		netInfo,
 		v.appCore.Conf(),
 		v.onActionSubmit,
+		v.onFilterChange,
+		v.onFilterDone,
 	)
 	v.serverTable = component.NewServerTable(
 		netInfo.Hostname(),
 		netInfo.UserIP().String(),
 		v.onSSH,
+		v.onDetails,
+		v.onPorts,
+		v.onExec,
+		v.onTransfer,
+		v.onAction,
+		v.onHighlight,
+		v.onSort,
 	)
+	v.serverTable.SetSort(v.appCore.Conf().Sort)
+	v.hostDetails = component.NewHostDetails(v.onDismissDetails)
+	v.hostPorts = component.NewHostPorts(v.onDismissDetails)
+	v.sshOverrides = component.NewSSHOverrides()
+	v.execResults = component.NewExecResults(v.onDismissExec)
+	v.transferResults = component.NewTransferResults(v.onDismissTransfer)
+	v.loadInventory()
 	v.eventTable = component.NewEventTable()
 	v.contextTable = component.NewConfigContext(
 		v.appCore.Conf().ID,
@@ -109,16 +138,22 @@ func (v *view) initialize(
 	)
 
 	v.pages.AddPage("servers", v.serverTable.Primitive(), true, false)
+	v.pages.AddPage("details", v.hostDetails.Primitive(), true, false)
+	v.pages.AddPage("ports", v.hostPorts.Primitive(), true, false)
+	v.pages.AddPage("exec", v.execResults.Primitive(), true, false)
+	v.pages.AddPage("transfer", v.transferResults.Primitive(), true, false)
 	v.pages.AddPage("events", v.eventTable.Primitive(), true, false)
 	v.pages.AddPage("configure", v.configureForm.Primitive(), true, false)
 	v.pages.AddPage("context", v.contextTable.Primitive(), true, false)
+	v.pages.AddPage("overrides", v.sshOverrides.Primitive(), true, false)
 
 	v.root.
-		AddItem(v.header.Primitive(), 15, 1, false).
+		AddItem(v.header.Primitive(), 17, 1, false).
 		AddItem(v.pages, 0, 1, true)
 
 	v.serverUpdateChan = make(chan event.Event)
 	v.eventUpdateChan = make(chan event.Event)
+	v.scanUpdateChan = make(chan event.Event)
 	v.errorListener = make(chan event.Event)
 	v.eventListenerIDs = []int{}
 
@@ -129,11 +164,12 @@ func (v *view) initialize(
 	v.processBackgroundEventUpdates()
 	v.processErrorEvents()
 
-	go func() {
-		for _, o := range options {
-			o(v)
-		}
-	}()
+	for _, a := range v.appCore.Conf().ReservedActions() {
+		v.log.Warn().
+			Str("key", a.Key).
+			Str("label", a.Label).
+			Msg("ignoring custom action bound to a reserved key")
+	}
 
 	v.focus(v.focusedName)
 }
@@ -158,6 +194,189 @@ func (v *view) onActionSubmit(text string) {
 	v.showingSwitchViewInput = false
 }
 
+// displays the details pane for the selected server
+func (v *view) onDetails(result discovery.DiscoveryResult) {
+	v.hostDetails.SetResult(result)
+	v.focus("details")
+}
+
+// displays the port breakdown for the selected server
+func (v *view) onPorts(result discovery.DiscoveryResult) {
+	v.hostPorts.SetResult(result, v.appCore.Conf().ScanPorts())
+	v.focus("ports")
+}
+
+// keeps details, ports, and overrides panes up to date with the latest
+// results for their servers
+func (v *view) refreshDetails() {
+	switch v.focusedName {
+	case "details":
+		if result, ok := v.serverTable.Result(v.hostDetails.ID()); ok {
+			v.hostDetails.SetResult(result)
+		}
+	case "ports":
+		if result, ok := v.serverTable.Result(v.hostPorts.ID()); ok {
+			v.hostPorts.SetResult(result, v.appCore.Conf().ScanPorts())
+		}
+	case "overrides":
+		v.sshOverrides.SetResults(v.appCore.Conf(), v.serverTable.Results())
+	}
+}
+
+// dismisses details or ports pane - focuses servers view
+func (v *view) onDismissDetails() {
+	v.focus("servers")
+}
+
+// prompts for a command to run on the given servers
+func (v *view) onExec(ids []string) {
+	prompt := component.NewExecPrompt(
+		len(ids),
+		func(command string) {
+			v.app.SetRoot(v.root, true)
+			v.exec(ids, command)
+		},
+		v.dismissErrorModal,
+	)
+
+	v.app.SetRoot(prompt.Primitive(), true)
+}
+
+// runs a command on the given servers in the background streaming output
+// to the exec results view as it is produced
+func (v *view) exec(ids []string, command string) {
+	if v.cancelExec != nil {
+		v.cancelExec()
+	}
+
+	// cancelled when replaced by another command or dismissed
+	ctx, cancel := context.WithCancel(context.Background())
+	v.cancelExec = cancel
+
+	v.actionRuns++
+	run := v.actionRuns
+
+	v.serverTable.ClearSelected()
+	v.execResults.Start(command, v.actionTargets(ids))
+	v.focus("exec")
+
+	go func() {
+		outputs := atomic.Int64{}
+
+		results, err := v.appCore.Exec(ctx, ids, command, core.ExecOptions{
+			OnOutput: func(o core.ExecOutput) {
+				// every line is kept while suspended
+				key := fmt.Sprintf("exec:%d:output:%d", run, outputs.Add(1))
+
+				v.queueActionUpdate(ctx, key, func() {
+					v.execResults.AddOutput(o)
+				})
+			},
+		})
+
+		if err != nil {
+			v.eventManager.ReportError(err)
+			return
+		}
+
+		v.queueActionUpdate(ctx, fmt.Sprintf("exec:%d:results", run), func() {
+			v.execResults.SetResults(results)
+		})
+	}()
+}
+
+// dismisses exec results - cancels the command if it is still running
+func (v *view) onDismissExec() {
+	if v.cancelExec != nil {
+		v.cancelExec()
+		v.cancelExec = nil
+	}
+
+	v.focus("servers")
+}
+
+// prompts for a file to transfer to or from the given servers
+func (v *view) onTransfer(ids []string) {
+	form := component.NewTransferForm(
+		len(ids),
+		func(req core.TransferRequest) {
+			v.app.SetRoot(v.root, true)
+			v.transfer(ids, req)
+		},
+		v.dismissErrorModal,
+	)
+
+	v.app.SetRoot(form.Primitive(), true)
+}
+
+// copies a file to or from the given servers in the background displaying
+// progress for each server in the transfer results view
+func (v *view) transfer(ids []string, req core.TransferRequest) {
+	if v.cancelTransfer != nil {
+		v.cancelTransfer()
+	}
+
+	// cancelled when replaced by another transfer or dismissed
+	ctx, cancel := context.WithCancel(context.Background())
+	v.cancelTransfer = cancel
+
+	v.actionRuns++
+	run := v.actionRuns
+
+	v.serverTable.ClearSelected()
+	v.transferResults.Start(req, v.actionTargets(ids))
+	v.focus("transfer")
+
+	go func() {
+		results, err := v.appCore.Transfer(ctx, ids, req, core.TransferOptions{
+			OnProgress: func(p core.TransferProgress) {
+				// only the latest progress is kept for each server
+				key := fmt.Sprintf("transfer:%d:progress:%s", run, p.Target.ID)
+
+				v.queueActionUpdate(ctx, key, func() {
+					v.transferResults.SetProgress(p)
+				})
+			},
+		})
+
+		if err != nil {
+			v.eventManager.ReportError(err)
+			return
+		}
+
+		v.queueActionUpdate(ctx, fmt.Sprintf("transfer:%d:results", run), func() {
+			v.transferResults.SetResults(results)
+		})
+	}()
+}
+
+// dismisses transfer results - cancels the transfer if it is still running
+func (v *view) onDismissTransfer() {
+	if v.cancelTransfer != nil {
+		v.cancelTransfer()
+		v.cancelTransfer = nil
+	}
+
+	v.focus("servers")
+}
+
+// returns display targets for the given servers
+func (v *view) actionTargets(ids []string) []core.ExecTarget {
+	targets := []core.ExecTarget{}
+
+	for _, id := range ids {
+		if result, ok := v.serverTable.Result(id); ok {
+			targets = append(targets, core.ExecTarget{
+				ID:       result.ID,
+				IP:       result.IP,
+				Hostname: result.Hostname,
+			})
+		}
+	}
+
+	return targets
+}
+
 // dismisses configuration form - focuses previously focused view
 func (v *view) onDismissConfigureForm() {
 	v.onActionSubmit(v.prevFocusedName)
@@ -165,11 +384,16 @@ func (v *view) onDismissConfigureForm() {
 
 // updates current config with result from config form inputs
 func (v *view) onConfigureFormUpdate(conf config.Config) {
+	// the form may hold a sort that has since changed in the server table
+	conf.Sort = v.serverTable.Sort()
+
 	if reflect.DeepEqual(conf, v.appCore.Conf()) {
 		v.onDismissConfigureForm()
 		return
 	}
 
+	v.cancelSortSave()
+
 	if err := v.appCore.UpdateConfig(conf); err != nil {
 		v.eventManager.ReportError(err)
 		return
@@ -217,6 +441,9 @@ func (v *view) onContextSelect(id string) {
 		return
 	}
 
+	// the pending sort belongs to the config being switched away from
+	v.flushSortSave()
+
 	if err := v.appCore.SetConfig(id); err != nil {
 		v.eventManager.ReportError(err)
 		return
@@ -232,10 +459,26 @@ func (v *view) onContextSelect(id string) {
 	v.contextTable.UpdateConfigs(v.appCore.Conf().ID, confs)
 	v.configureForm.UpdateConfig(v.appCore.Conf())
 	v.header.UpdateConfAndNetworkInfo(v.appCore.Conf(), v.appCore.NetworkInfo())
+	v.serverTable.SetSort(v.appCore.Conf().Sort)
+	v.loadInventory()
 
 	v.focus("servers")
 }
 
+// loads previously discovered hosts for the active context into the
+// server table so we don't start with an empty list
+func (v *view) loadInventory() {
+	hosts, err := v.appCore.Inventory()
+
+	if err != nil {
+		v.log.Error().Err(err).Msg("failed to load inventory")
+		return
+	}
+
+	v.serverTable.LoadInventory(hosts)
+	v.refreshFilterStatus()
+}
+
 // dismisses confirmation modal when deleting a context
 func (v *view) dismissContextDelete() {
 	v.contextToDelete = ""
@@ -355,16 +598,29 @@ func (v *view) bindKeys() {
 		}
 
 		if evt.Rune() == key.RuneColon {
-			if v.showingSwitchViewInput {
+			if v.showingSwitchViewInput || v.showingFilterInput {
 				return evt
 			}
 
+			v.header.ShowSwitchViewInput()
 			v.app.SetFocus(v.header.SwitchViewInput().Primitive())
 			v.showingSwitchViewInput = true
 
 			return nil
 		}
 
+		if evt.Rune() == key.RuneSlash && v.focusedName == "servers" {
+			if v.showingSwitchViewInput || v.showingFilterInput {
+				return evt
+			}
+
+			v.header.ShowFilterInput()
+			v.app.SetFocus(v.header.FilterInput().Primitive())
+			v.showingFilterInput = true
+
+			return nil
+		}
+
 		return evt
 	})
 }
@@ -382,6 +638,22 @@ func (v *view) focus(name string) {
 	case "servers":
 		v.header.RemoveAllExtraLegendKeys()
 		v.header.AddLegendKey("s", "ssh to selected machine")
+		v.header.AddLegendKey("enter", "show machine details")
+		v.header.AddLegendKey("p", "show machine ports")
+		v.header.AddLegendKey("space", "select machine")
+		v.header.AddLegendKey("x", "run command on selected machines")
+		v.header.AddLegendKey("f", "transfer file for selected machines")
+		v.header.AddLegendKey("/", "filter machines")
+		v.header.AddLegendKey("o / O", "change sort column / order")
+	case "details", "ports":
+		v.header.RemoveAllExtraLegendKeys()
+		v.header.AddLegendKey("esc", "back to servers")
+	case "exec", "transfer":
+		v.header.RemoveAllExtraLegendKeys()
+		v.header.AddLegendKey("esc", "cancel and back to servers")
+	case "overrides":
+		v.sshOverrides.SetResults(v.appCore.Conf(), v.serverTable.Results())
+		v.header.RemoveAllExtraLegendKeys()
 	case "context":
 		confs, err := v.appCore.GetConfigs()
 
@@ -404,61 +676,362 @@ func (v *view) focus(name string) {
 	v.prevFocusedName = v.focusedName
 	v.focusedName = name
 
+	if name == "servers" {
+		v.refreshActionLegend()
+	}
+
 	v.pages.SwitchToPage(name)
 	v.app.SetFocus(p)
 }
 
-// Attempts to ssh to the given server using current config's ssh properties.
-// This requires stopping the terminal ui application so we can return
-// to the normal terminal screen. We ensure our terminal app is restarted
-// once the ssh command finishes aka when the user exists the ssh tunnel.
+// Attempts to ssh to the given server, first warning the user if the
+// server's ssh host key has changed since it was last recorded
 func (v *view) onSSH(ip string) {
-	v.stop()
+	change, ok := v.serverTable.HostKeyChange(ip)
 
-	conf := v.appCore.Conf()
-	user := conf.SSH.User
-	identity := conf.SSH.Identity
-	port := conf.SSH.Port
+	if !ok {
+		v.ssh(ip)
+		return
+	}
 
-	for _, o := range conf.SSH.Overrides {
-		if o.Target == ip {
-			if o.User != "" {
-				user = o.User
-			}
+	buttons := []component.ModalButton{
+		{
+			Label: "Trust & Connect",
+			OnClick: func() {
+				if err := v.appCore.TrustHostKey(change.ID); err != nil {
+					v.showErrorModal("failed to trust host key: " + err.Error())
+					return
+				}
 
-			if o.Identity != "" {
-				identity = o.Identity
-			}
+				v.serverTable.ClearHostKeyChange(change.ID)
+				v.app.SetRoot(v.root, true)
+				v.ssh(ip)
+			},
+		},
+		{
+			Label:   "Cancel",
+			OnClick: v.dismissErrorModal,
+		},
+	}
 
-			if o.Port != "" {
-				port = o.Port
-			}
+	warning := component.NewModal(change.Warning(), buttons)
+	v.app.SetRoot(warning.Primitive(), false)
+}
+
+// displays a warning when a server presents a new ssh host key
+func (v *view) showHostKeyWarning(change discovery.HostKeyChange) {
+	v.showErrorModal(change.Warning())
+}
+
+// Uses the current config's ssh properties to ssh to the given server.
+// The terminal ui is suspended so we can return to the normal terminal
+// screen while the core keeps scanning in the background. The ui resumes
+// with all updates received during the session once the user exits the
+// ssh tunnel.
+func (v *view) ssh(ip string) {
+	l, err := launcher.New(v.appCore.Conf().Session, v.suspend)
+
+	if err == nil {
+		err = l.Launch(v.session(ip))
+	}
+
+	if err != nil {
+		v.showErrorModal("failed to ssh to " + ip + ": " + err.Error())
+	}
+}
+
+// returns the session used to ssh to the given server with its resolved
+// ssh settings
+func (v *view) session(ip string) launcher.Session {
+	target := v.sshTarget(ip)
+	creds := v.appCore.Conf().SSHCredentials(target)
+
+	name := target.Hostname
+
+	if name == "" {
+		name = ip
+	}
+
+	return launcher.Session{
+		Name:     name,
+		IP:       ip,
+		Hostname: target.Hostname,
+		User:     creds.User,
+		Port:     creds.Port,
+		Identity: creds.Identity,
+		Command:  append([]string{"ssh"}, creds.Args(ip)...),
+	}
+}
+
+// runs the custom action bound to the given key for the server if the
+// action applies to it
+func (v *view) onAction(key string, result discovery.DiscoveryResult) bool {
+	for _, action := range v.appCore.Conf().ActionsFor(result.ActionTarget()) {
+		if action.Key == key {
+			v.runAction(action, result.IP)
+			return true
 		}
 	}
 
-	cmd := exec.Command(
-		"ssh",
-		"-i",
-		identity,
-		"-p",
-		port,
-		"-l",
-		user,
-		ip,
-	)
+	return false
+}
 
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
+// runs a custom action's command for the given server
+func (v *view) runAction(action config.Action, ip string) {
+	if err := v.launchAction(action, ip); err != nil {
+		v.showErrorModal("failed to run " + action.Label + " for " + ip + ": " + err.Error())
+	}
+}
+
+// interactive actions are opened with the session launcher and all others
+// are started in the background
+func (v *view) launchAction(action config.Action, ip string) error {
+	session := v.session(ip)
+
+	tmpl, err := launcher.NewTemplateLauncher(action.Command)
+
+	if err != nil {
+		return err
+	}
+
+	if !action.Interactive {
+		return tmpl.Launch(session)
+	}
 
-	err := cmd.Run()
+	command, err := tmpl.Render(session)
 
 	if err != nil {
-		v.restart(withShowError("failed to ssh to " + ip + ": " + err.Error()))
+		return err
+	}
+
+	l, err := launcher.New(v.appCore.Conf().Session, v.suspend)
+
+	if err != nil {
+		return err
+	}
+
+	session.Command = []string{"sh", "-c", command}
+
+	return l.Launch(session)
+}
+
+// schedules saving the user's server sort choice for the current config
+func (v *view) onSort(sort config.ServerSort) {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	v.pendingSort = &sort
+
+	if v.sortTimer != nil {
+		v.sortTimer.Stop()
+	}
+
+	v.sortTimer = time.AfterFunc(sortSaveDelay, func() {
+		if v.saveSort() {
+			v.queueUpdateDraw("sort", func() {
+				v.configureForm.UpdateConfig(v.appCore.Conf())
+			})
+		}
+	})
+}
+
+// saves the pending server sort if any, returning whether it was saved
+func (v *view) saveSort() bool {
+	v.mux.Lock()
+	sort := v.pendingSort
+	v.pendingSort = nil
+	v.mux.Unlock()
+
+	if sort == nil {
+		return false
+	}
+
+	if err := v.appCore.SetServerSort(*sort); err != nil {
+		v.eventManager.ReportError(err)
+		return false
+	}
+
+	return true
+}
+
+// immediately saves any pending server sort rather than waiting
+func (v *view) flushSortSave() {
+	v.mux.Lock()
+	if v.sortTimer != nil {
+		v.sortTimer.Stop()
+	}
+	v.mux.Unlock()
+
+	v.saveSort()
+}
+
+// discards any pending server sort save
+func (v *view) cancelSortSave() {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	if v.sortTimer != nil {
+		v.sortTimer.Stop()
+	}
+
+	v.pendingSort = nil
+}
+
+// filters the server table live as the filter text changes
+func (v *view) onFilterChange(text string) {
+	v.serverTable.SetFilter(text)
+	v.refreshFilterStatus()
+}
+
+// returns to the server table once the user is done editing the filter
+func (v *view) onFilterDone() {
+	v.showingFilterInput = false
+	v.header.ShowSwitchViewInput()
+	v.focus("servers")
+}
+
+// displays the active filter and how many servers it matches in the header
+func (v *view) refreshFilterStatus() {
+	shown, total := v.serverTable.Count()
+	v.header.UpdateFilterStatus(v.serverTable.Filter(), shown, total)
+}
+
+// updates the legend when a different server is highlighted
+func (v *view) onHighlight(result discovery.DiscoveryResult) {
+	v.refreshActionLegend()
+}
+
+// shows the custom actions that apply to the highlighted server in the
+// legend while the servers view is focused
+func (v *view) refreshActionLegend() {
+	if v.focusedName != "servers" {
+		return
+	}
+
+	for _, key := range v.actionLegendKeys {
+		v.header.RemoveLegendKey(key)
+	}
+
+	v.actionLegendKeys = nil
+
+	result, ok := v.serverTable.Highlighted()
+
+	if !ok {
+		return
+	}
+
+	for _, action := range v.appCore.Conf().ActionsFor(result.ActionTarget()) {
+		v.header.AddLegendKey(action.Key, action.Label)
+		v.actionLegendKeys = append(v.actionLegendKeys, action.Key)
+	}
+}
+
+// suspends the terminal ui and runs f with the normal terminal screen.
+// Updates received while suspended are held and applied in order once
+// the ui resumes. Must be called from the ui goroutine e.g. a key handler.
+func (v *view) suspend(f func()) {
+	v.mux.Lock()
+	v.suspended = true
+	v.pendingUpdates = map[string]func(){}
+	v.pendingOrder = []string{}
+	v.mux.Unlock()
+
+	v.app.Suspend(func() {
+		restoreStdout()
+		f()
+		maskStdout()
+	})
+
+	v.mux.Lock()
+	pending := v.pendingUpdates
+	order := v.pendingOrder
+	v.pendingUpdates = nil
+	v.pendingOrder = nil
+	v.suspended = false
+	v.mux.Unlock()
+
+	for _, key := range order {
+		pending[key]()
+	}
+}
+
+// queues an update to run on the ui goroutine and redraws, or holds it
+// until the ui resumes if suspended so drawing doesn't interfere with the
+// normal terminal screen. Held updates with the same key are collapsed so
+// only the latest is applied, keeping the queue bounded while suspended.
+func (v *view) queueUpdateDraw(key string, update func()) {
+	v.mux.Lock()
+
+	if v.suspended {
+		if _, ok := v.pendingUpdates[key]; ok {
+			// move to the end so updates apply in the order last received
+			v.pendingOrder = slices.DeleteFunc(v.pendingOrder, func(k string) bool {
+				return k == key
+			})
+		}
+
+		v.pendingUpdates[key] = update
+		v.pendingOrder = append(v.pendingOrder, key)
+		v.mux.Unlock()
 		return
 	}
 
-	v.restart()
+	v.mux.Unlock()
+
+	v.app.QueueUpdateDraw(update)
+}
+
+// queues a draw update for a running exec or transfer. The update is
+// dropped if the run was cancelled before it is applied so a replaced run
+// can't write to the results view of the run that replaced it.
+func (v *view) queueActionUpdate(ctx context.Context, key string, update func()) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	v.queueUpdateDraw(key, func() {
+		if ctx.Err() != nil {
+			return
+		}
+
+		update()
+	})
+}
+
+// returns the key for a held event table update - only the most recent
+// events the table can display are kept
+func (v *view) eventUpdateKey() string {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	v.pendingEvents = (v.pendingEvents + 1) % component.MaxEvents
+
+	return fmt.Sprintf("event:%d", v.pendingEvents)
+}
+
+// returns the key for a held server table update. Only the latest event
+// of each type is kept for each host and port.
+func serverUpdateKey(evt event.Event) string {
+	switch payload := evt.Payload.(type) {
+	case discovery.DiscoveryResult:
+		return fmt.Sprintf("server:%s:%s:%d", payload.ID, evt.Type, payload.Port.ID)
+	case discovery.HostKeyChange:
+		return fmt.Sprintf("server:%s:%s", payload.ID, evt.Type)
+	default:
+		return fmt.Sprintf("server:%s", evt.Type)
+	}
+}
+
+// returns what is known about the server with the given ip for matching
+// ssh overrides
+func (v *view) sshTarget(ip string) config.SSHTarget {
+	for _, result := range v.serverTable.Results() {
+		if result.IP == ip {
+			return result.SSHTarget()
+		}
+	}
+
+	return config.SSHTarget{IP: ip}
 }
 
 // maps names to primitives for focusing
@@ -466,6 +1039,16 @@ func (v *view) getFocusNamePrimitive(name string) tview.Primitive {
 	switch name {
 	case "servers":
 		return v.serverTable.Primitive()
+	case "details":
+		return v.hostDetails.Primitive()
+	case "ports":
+		return v.hostPorts.Primitive()
+	case "overrides":
+		return v.sshOverrides.Primitive()
+	case "exec":
+		return v.execResults.Primitive()
+	case "transfer":
+		return v.transferResults.Primitive()
 	case "events":
 		return v.eventTable.Primitive()
 	case "context":
@@ -477,13 +1060,21 @@ func (v *view) getFocusNamePrimitive(name string) tview.Primitive {
 	}
 }
 
-// completely stops the tui app and all backend processes.
-// this requires a full restart including re-instantiation of entire backend
+// completely stops the tui app and all backend processes
 func (v *view) stop() {
+	if v.cancelExec != nil {
+		v.cancelExec()
+		v.cancelExec = nil
+	}
+	if v.cancelTransfer != nil {
+		v.cancelTransfer()
+		v.cancelTransfer = nil
+	}
 	for _, id := range v.eventListenerIDs {
 		v.eventManager.RemoveListener(id)
 	}
 	v.eventListenerIDs = []int{}
+	v.flushSortSave()
 	if err := v.appCore.Stop(); err != nil {
 		v.eventManager.ReportFatalError(err)
 	}
@@ -491,59 +1082,30 @@ func (v *view) stop() {
 	restoreStdout()
 }
 
-// restarts the entire application including re-instantiation of entire backend
-func (v *view) restart(options ...viewOption) {
-	v.stop()
-
-	restoreStdout()
-
-	conf := v.appCore.Conf()
-
-	netInfo, err := network.NewDefaultNetwork()
-
-	if err != nil {
-		v.log.Fatal().Err(err).Msg("failed to get default network info")
-	}
-
-	appCore, err := core.CreateNewAppCore(netInfo, v.eventManager, false)
-
-	if err != nil {
-		v.log.Fatal().Err(err).Msg("failed to restart app core")
-	}
-
-	if err := appCore.SetConfig(conf.ID); err != nil {
-		v.log.Fatal().Err(err).Msg("failed to set config on restart")
-	}
-
-	v.appCore = appCore
-
-	allConfigs, err := v.appCore.GetConfigs()
-
-	if err != nil {
-		v.log.Fatal().Err(err).Msg("failed to retrieve configs")
-	}
-
-	maskStdout()
-
-	v.initialize(allConfigs, options...)
-
-	if err := v.run(); err != nil {
-		restoreStdout()
-		v.log.Fatal().Err(err).Msg("failed to restart view")
-	}
-}
-
 // registers event listeners
 func (v *view) registerEventListeners() {
 	v.eventListenerIDs = append(
 		v.eventListenerIDs,
 		v.eventManager.RegisterListener(discovery.ArpUpdateEvent, v.eventUpdateChan),
 		v.eventManager.RegisterListener(discovery.SynUpdateEvent, v.eventUpdateChan),
+		v.eventManager.RegisterListener(discovery.HostOfflineEvent, v.eventUpdateChan),
+		v.eventManager.RegisterListener(discovery.HostReturnedEvent, v.eventUpdateChan),
+		v.eventManager.RegisterListener(discovery.HostnameResolvedEvent, v.eventUpdateChan),
 	)
 	v.eventListenerIDs = append(
 		v.eventListenerIDs,
 		v.eventManager.RegisterListener(discovery.ArpUpdateEvent, v.serverUpdateChan),
 		v.eventManager.RegisterListener(discovery.SynUpdateEvent, v.serverUpdateChan),
+		v.eventManager.RegisterListener(discovery.HostOfflineEvent, v.serverUpdateChan),
+		v.eventManager.RegisterListener(discovery.HostReturnedEvent, v.serverUpdateChan),
+		v.eventManager.RegisterListener(discovery.HostnameResolvedEvent, v.serverUpdateChan),
+		v.eventManager.RegisterListener(discovery.PortUpdateEvent, v.serverUpdateChan),
+		v.eventManager.RegisterListener(discovery.HostKeyChangedEvent, v.serverUpdateChan),
+	)
+	v.eventListenerIDs = append(
+		v.eventListenerIDs,
+		v.eventManager.RegisterListener(discovery.ScanStartedEvent, v.scanUpdateChan),
+		v.eventManager.RegisterListener(discovery.ScanCompletedEvent, v.scanUpdateChan),
 	)
 	v.eventListenerIDs = append(
 		v.eventListenerIDs,
@@ -561,15 +1123,29 @@ func (v *view) processBackgroundEventUpdates() {
 				if !ok {
 					return
 				}
-				v.app.QueueUpdateDraw(func() {
+				v.queueUpdateDraw(v.eventUpdateKey(), func() {
 					v.eventTable.UpdateTable(evt)
 				})
 			case evt, ok := <-v.serverUpdateChan:
 				if !ok {
 					return
 				}
-				v.app.QueueUpdateDraw(func() {
+				v.queueUpdateDraw(serverUpdateKey(evt), func() {
 					v.serverTable.UpdateTable(evt)
+					v.refreshDetails()
+					v.refreshActionLegend()
+					v.refreshFilterStatus()
+
+					if change, ok := evt.Payload.(discovery.HostKeyChange); ok {
+						v.showHostKeyWarning(change)
+					}
+				})
+			case evt, ok := <-v.scanUpdateChan:
+				if !ok {
+					return
+				}
+				v.queueUpdateDraw("scan", func() {
+					v.header.UpdateScanStatus(evt)
 				})
 			}
 		}
@@ -580,13 +1156,17 @@ func (v *view) processBackgroundEventUpdates() {
 func (v *view) processErrorEvents() {
 	go func() {
 		for evt := range v.errorListener {
+			message := evt.Payload.(error).Error()
+
 			switch evt.Type {
 			case event.FatalErrorEventType:
-				v.showFatalErrorModal(evt.Payload.(error).Error())
-				v.app.Draw()
+				v.queueUpdateDraw("fatal", func() {
+					v.showFatalErrorModal(message)
+				})
 			case event.ErrorEventType:
-				v.showErrorModal(evt.Payload.(error).Error())
-				v.app.Draw()
+				v.queueUpdateDraw("error", func() {
+					v.showErrorModal(message)
+				})
 			}
 		}
 	}()
@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/robgonnella/ops/internal/discovery"
+	"github.com/robgonnella/ops/internal/event"
+	"github.com/robgonnella/ops/internal/ui/component"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestView(t *testing.T) {
+	t.Run("collapses updates held while suspended", func(st *testing.T) {
+		v := &view{
+			suspended:      true,
+			pendingUpdates: map[string]func(){},
+			pendingOrder:   []string{},
+		}
+
+		applied := []string{}
+
+		queue := func(key, name string) {
+			v.queueUpdateDraw(key, func() {
+				applied = append(applied, name)
+			})
+		}
+
+		queue("scan", "scan-1")
+		queue("error", "error-1")
+		queue("scan", "scan-2")
+		queue("fatal", "fatal-1")
+
+		assert.Equal(st, []string{"error", "scan", "fatal"}, v.pendingOrder)
+		assert.Len(st, v.pendingUpdates, 3)
+
+		for _, key := range v.pendingOrder {
+			v.pendingUpdates[key]()
+		}
+
+		assert.Equal(st, []string{"error-1", "scan-2", "fatal-1"}, applied)
+	})
+
+	t.Run("drops updates from cancelled runs", func(st *testing.T) {
+		v := &view{
+			suspended:      true,
+			pendingUpdates: map[string]func(){},
+			pendingOrder:   []string{},
+		}
+
+		applied := []string{}
+
+		replaced, cancel := context.WithCancel(context.Background())
+		current := context.Background()
+
+		v.queueActionUpdate(replaced, "exec:1:output:1", func() {
+			applied = append(applied, "replaced-1")
+		})
+
+		cancel()
+
+		v.queueActionUpdate(replaced, "exec:1:output:2", func() {
+			applied = append(applied, "replaced-2")
+		})
+
+		v.queueActionUpdate(current, "exec:2:output:1", func() {
+			applied = append(applied, "current-1")
+		})
+
+		assert.Equal(st, []string{"exec:1:output:1", "exec:2:output:1"}, v.pendingOrder)
+
+		for _, key := range v.pendingOrder {
+			v.pendingUpdates[key]()
+		}
+
+		assert.Equal(st, []string{"current-1"}, applied)
+	})
+
+	t.Run("keeps only the most recent event table updates", func(st *testing.T) {
+		v := &view{}
+
+		keys := map[string]bool{}
+
+		for i := 0; i < component.MaxEvents*3; i++ {
+			keys[v.eventUpdateKey()] = true
+		}
+
+		assert.Len(st, keys, component.MaxEvents)
+	})
+
+	t.Run("keys server updates by host, type, and port", func(st *testing.T) {
+		result := discovery.DiscoveryResult{
+			ID:   "00:00:00:00:00:01",
+			Port: discovery.Port{ID: 22},
+		}
+
+		syn := event.Event{Type: discovery.SynUpdateEvent, Payload: result}
+		arp := event.Event{Type: discovery.ArpUpdateEvent, Payload: result}
+
+		other := result
+		other.Port.ID = 80
+
+		assert.Equal(st, serverUpdateKey(syn), serverUpdateKey(syn))
+		assert.NotEqual(st, serverUpdateKey(syn), serverUpdateKey(arp))
+		assert.NotEqual(
+			st,
+			serverUpdateKey(syn),
+			serverUpdateKey(event.Event{Type: discovery.SynUpdateEvent, Payload: other}),
+		)
+
+		change := discovery.HostKeyChange{ID: result.ID}
+		keyChanged := event.Event{Type: discovery.HostKeyChangedEvent, Payload: change}
+
+		assert.Equal(
+			st,
+			fmt.Sprintf("server:%s:%s", change.ID, discovery.HostKeyChangedEvent),
+			serverUpdateKey(keyChanged),
+		)
+	})
+}
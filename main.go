@@ -36,6 +36,8 @@ func setRuntTimeConfig() error {
 
 	configFile := path.Join(configDir, "config.json")
 
+	inventoryFile := path.Join(configDir, "inventory.json")
+
 	defaultSSHIdentity := path.Join(userHomeDir, ".ssh", "id_rsa")
 
 	user := os.Getenv("USER")
@@ -44,8 +46,10 @@ func setRuntTimeConfig() error {
 	viper.Set("log-file", logFile)
 	viper.Set("config-dir", configDir)
 	viper.Set("config-path", configFile)
+	viper.Set("inventory-path", inventoryFile)
 	viper.Set("default-ssh-identity", defaultSSHIdentity)
 	viper.Set("user", user)
+	viper.Set("ssh-key-passphrase", os.Getenv("OPS_SSH_KEY_PASSPHRASE"))
 
 	return nil
 }
@@ -73,6 +77,13 @@ func main() {
 	// execute the cobra command and exit with error code if necessary
 	err = cmd.ExecuteContext(context.Background())
 
+	var exitErr *commands.ExitError
+
+	if errors.As(err, &exitErr) {
+		log.Error().Err(exitErr.Err).Msg("")
+		os.Exit(exitErr.Code)
+	}
+
 	if err != nil {
 		log.Fatal().Err(err).Msg("")
 	}